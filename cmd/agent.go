@@ -0,0 +1,286 @@
+package cmd
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/style77/gas/internal/accounts"
+	"github.com/style77/gas/internal/git"
+	"github.com/style77/gas/internal/helpers"
+	"github.com/style77/gas/internal/sshagent"
+	"github.com/style77/gas/internal/sshauth"
+	"github.com/style77/gas/internal/sshconfig"
+	"golang.org/x/crypto/ssh"
+)
+
+// Config keys of the agent integration.
+const (
+	agentAddOnSwitchKey  = "agent.addonswitch"
+	agentLifetimeKey     = "agent.lifetime"
+	agentConfirmKey      = "agent.confirm"
+	agentRemoveOthersKey = "agent.removeothers"
+)
+
+// agentCmd represents the agent command
+var agentCmd = &cobra.Command{
+	Use:   "agent",
+	Short: "Run an SSH agent exposing only the keys of one account",
+	Long: `Run an SSH agent in the foreground that proxies to the agent in $SSH_AUTH_SOCK
+but only exposes the key of a single account, so ssh can't offer the wrong key first.
+
+A socket is created in ~/.gas/agent for every account with an SSH alias, exposing that
+account's key, and default.sock exposes the key of the current global account.
+The sockets are read only: keys can't be added, removed or locked through them, use
+the agent in $SSH_AUTH_SOCK or 'gas agent add' for that.
+Run 'gas agent configure' to point the IdentityAgent of every alias at its socket.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		dir, err := sshagent.DefaultDir()
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+
+		upstream := os.Getenv("SSH_AUTH_SOCK")
+		if filepath.Dir(upstream) == dir {
+			fmt.Println("SSH_AUTH_SOCK points at a gas agent socket, set it to the ssh-agent socket instead.")
+			return
+		}
+		dial := sshagent.UpstreamDialer(upstream)
+
+		sockets := map[string]sshagent.KeysFunc{
+			sshagent.DefaultSocketName: globalAccountKeys,
+		}
+		for _, account := range accounts.GetAccounts() {
+			if account.SSHAlias != "" {
+				sockets[account.SSHAlias] = accountKeys(account)
+			}
+		}
+
+		var listeners []net.Listener
+		defer func() {
+			for _, listener := range listeners {
+				listener.Close()
+			}
+		}()
+
+		for name, keys := range sockets {
+			path := sshagent.SocketPath(dir, name)
+			listener, err := sshagent.Listen(path)
+			if err != nil {
+				fmt.Println(err)
+				return
+			}
+			listeners = append(listeners, listener)
+
+			go sshagent.Serve(listener, dial, keys)
+			fmt.Printf("Serving '%s'.\n", path)
+		}
+
+		signals := make(chan os.Signal, 1)
+		signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+		<-signals
+	},
+}
+
+// agentConfigureCmd represents the agent configure command
+var agentConfigureCmd = &cobra.Command{
+	Use:   "configure",
+	Short: "Point the IdentityAgent of every account's SSH alias at its gas agent socket",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		remove, _ := cmd.Flags().GetBool("remove")
+
+		config, err := sshconfig.Load(sshconfig.DefaultPath())
+		if err != nil {
+			fmt.Printf("Could not read SSH config file: %v\n", err)
+			return
+		}
+
+		for _, account := range accounts.GetAccounts() {
+			if account.SSHAlias == "" || config.FindHost(account.SSHAlias) == nil {
+				continue
+			}
+
+			if remove {
+				err = config.RemoveOption(account.SSHAlias, "IdentityAgent")
+			} else {
+				socket := sshagent.SocketPath(filepath.Join("~", ".gas", "agent"), account.SSHAlias)
+				err = config.SetOption(account.SSHAlias, "IdentityAgent", socket)
+			}
+			if err != nil {
+				fmt.Println(err)
+				return
+			}
+		}
+
+		if err := config.Save(); err != nil {
+			fmt.Println(err)
+			return
+		}
+
+		if remove {
+			fmt.Println("Removed IdentityAgent from the account aliases.")
+		} else {
+			fmt.Println("Configured IdentityAgent for the account aliases. Keep 'gas agent' running to use them.")
+		}
+	},
+}
+
+// agentAddCmd represents the agent add command
+var agentAddCmd = &cobra.Command{
+	Use:   "add [account]",
+	Short: "Add the key of an account to ssh-agent",
+	Long: `Add the key of an account to the agent in $SSH_AUTH_SOCK, so that passphrase
+protected keys are only unlocked once.
+
+The defaults of the flags can be set in the agent section of ~/.gas.yaml with the
+lifetime, confirm and removeothers keys. Set agent.addonswitch to add the key whenever
+you switch accounts.`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		var account accounts.Account
+		var err error
+		if len(args) > 0 {
+			account, err = accounts.ResolveAccount(args[0])
+		} else {
+			account, err = accounts.InteractiveSelectAccount()
+		}
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		if account.Handle == "" {
+			fmt.Println("No account selected.")
+			return
+		}
+
+		options := sshagent.AddOptions{
+			Lifetime: viper.GetDuration(agentLifetimeKey),
+			Confirm:  viper.GetBool(agentConfirmKey),
+		}
+		removeOthers := viper.GetBool(agentRemoveOthersKey)
+		if cmd.Flags().Changed("lifetime") {
+			options.Lifetime, _ = cmd.Flags().GetDuration("lifetime")
+		}
+		if cmd.Flags().Changed("confirm") {
+			options.Confirm, _ = cmd.Flags().GetBool("confirm")
+		}
+		if cmd.Flags().Changed("remove-others") {
+			removeOthers, _ = cmd.Flags().GetBool("remove-others")
+		}
+
+		if err := addAccountKeyToAgent(account, options, removeOthers); err != nil {
+			fmt.Println(err)
+		}
+	},
+}
+
+// addAccountKeyToAgent adds the key of the account to the agent in $SSH_AUTH_SOCK, optionally removing the keys
+// gas added for other accounts.
+func addAccountKeyToAgent(account accounts.Account, options sshagent.AddOptions, removeOthers bool) error {
+	keyPath, err := helpers.ExpandPath(account.SSHKeyPath)
+	if err != nil {
+		return err
+	}
+
+	privateKey, err := sshauth.LoadPrivateKey(keyPath, keyPassphrasePrompt(account, account.SSHKeyPath))
+	if err != nil {
+		return err
+	}
+
+	if account.CertificateFile != "" {
+		options.Certificate, err = helpers.ReadCertificate(account.CertificateFile)
+		if err != nil {
+			return err
+		}
+	}
+
+	upstream, closer, err := sshagent.UpstreamDialer(os.Getenv("SSH_AUTH_SOCK"))()
+	if err != nil {
+		return err
+	}
+	defer closer.Close()
+
+	if err := sshagent.AddKey(upstream, account.Handle, privateKey, options); err != nil {
+		return err
+	}
+
+	message := fmt.Sprintf("Added key '%s' of account '%s' to ssh-agent", account.SSHKeyPath, account.Handle)
+	if options.Lifetime > 0 {
+		message += fmt.Sprintf(" for %s", options.Lifetime)
+	}
+	if options.Confirm {
+		message += ", every use needs to be confirmed"
+	}
+	fmt.Println(message + ".")
+
+	if !removeOthers {
+		return nil
+	}
+
+	publicKey, err := helpers.ReadPublicKey(account.SSHKeyPath)
+	if err != nil {
+		return err
+	}
+	removed, err := sshagent.RemoveManagedKeys(upstream, publicKey)
+	for _, comment := range removed {
+		fmt.Printf("Removed key '%s' from ssh-agent.\n", comment)
+	}
+
+	return err
+}
+
+// keyPassphrasePrompt returns the passphrase of the account's key from the secret store, or asks for it.
+func keyPassphrasePrompt(account accounts.Account, keyPath string) func() ([]byte, error) {
+	return func() ([]byte, error) {
+		if passphrase := account.KeyPassphrase(); passphrase != "" {
+			return []byte(passphrase), nil
+		}
+
+		var passphrase string
+		err := survey.AskOne(&survey.Password{Message: fmt.Sprintf("Enter passphrase for '%s':", keyPath)}, &passphrase)
+		return []byte(passphrase), err
+	}
+}
+
+// accountKeys returns the keys exposed for the provided account.
+func accountKeys(account accounts.Account) sshagent.KeysFunc {
+	return func() ([]ssh.PublicKey, error) {
+		publicKey, err := helpers.ReadPublicKey(account.SSHKeyPath)
+		if err != nil {
+			return nil, err
+		}
+
+		return []ssh.PublicKey{publicKey}, nil
+	}
+}
+
+// globalAccountKeys returns the keys of the current global account, looked up again for every connection.
+func globalAccountKeys() ([]ssh.PublicKey, error) {
+	account, ok := accounts.GetAccountByEmail(git.GetCurrentGlobal())
+	if !ok {
+		return nil, fmt.Errorf("the global git identity does not belong to any account")
+	}
+
+	return accountKeys(account)()
+}
+
+func init() {
+	rootCmd.AddCommand(agentCmd)
+	agentCmd.AddCommand(agentConfigureCmd)
+	agentCmd.AddCommand(agentAddCmd)
+
+	agentConfigureCmd.Flags().Bool("remove", false, "Remove IdentityAgent from the account aliases instead.")
+
+	agentAddCmd.Flags().Duration("lifetime", 0, "How long the agent keeps the key, e.g. 4h. Defaults to agent.lifetime or until the agent exits.")
+	agentAddCmd.Flags().Bool("confirm", false, "Ask the agent to confirm every use of the key. Defaults to agent.confirm.")
+	agentAddCmd.Flags().Bool("remove-others", false, "Remove keys gas added for other accounts. Defaults to agent.removeothers.")
+}
@@ -0,0 +1,89 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/style77/gas/internal/accounts"
+	"github.com/style77/gas/internal/audit"
+	"github.com/style77/gas/internal/git"
+	"github.com/style77/gas/internal/repo"
+)
+
+// auditCmd represents the audit command
+var auditCmd = &cobra.Command{
+	Use:   "audit [revision-range]",
+	Short: "Find commits made with the wrong identity",
+	Long: `Scan the author and committer emails of the commits in the current repository
+and report the ones made with the identity of another configured account.
+
+By default the commits reachable from HEAD are scanned and the expected account is
+the one bound to the repository's remote. Use --account to audit against another account.`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		accountRaw, _ := cmd.Flags().GetString("account")
+		since, _ := cmd.Flags().GetString("since")
+		allBranches, _ := cmd.Flags().GetBool("all-branches")
+		asJSON, _ := cmd.Flags().GetBool("json")
+
+		var expected accounts.Account
+		if accountRaw != "" {
+			var err error
+			expected, err = accounts.ResolveAccount(accountRaw)
+			if err != nil {
+				fmt.Println(err)
+				return
+			}
+		} else {
+			identity := repo.ResolveIdentity(repo.DefaultRemote())
+			if identity.ExpectedAccount == nil {
+				fmt.Println("Could not determine the account expected for this repository. Use --account to select one.")
+				return
+			}
+			expected = *identity.ExpectedAccount
+		}
+
+		logArgs := []string{}
+		if since != "" {
+			logArgs = append(logArgs, "--since="+since)
+		}
+		if allBranches {
+			logArgs = append(logArgs, "--branches", "--remotes")
+		}
+		if len(args) > 0 {
+			logArgs = append(logArgs, args[0])
+		} else if !allBranches {
+			logArgs = append(logArgs, "HEAD")
+		}
+
+		commits, err := git.ListCommits(logArgs...)
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+
+		report := audit.Audit(commits, expected, accounts.GetAccounts())
+
+		if asJSON {
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "  ")
+			if err := encoder.Encode(report); err != nil {
+				fmt.Println(err)
+			}
+			return
+		}
+
+		report.Print(os.Stdout)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(auditCmd)
+
+	auditCmd.Flags().StringP("account", "a", "", "Account the commits are expected to be made with. Defaults to the account bound to the repository's remote.")
+	auditCmd.Flags().String("since", "", "Only scan commits more recent than a date, e.g. '2024-01-01' or '3 months ago'.")
+	auditCmd.Flags().Bool("all-branches", false, "Scan all local and remote-tracking branches.")
+	auditCmd.Flags().Bool("json", false, "Print the report as JSON.")
+}
@@ -0,0 +1,145 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/style77/gas/internal/accounts"
+	"github.com/style77/gas/internal/credential"
+	"github.com/style77/gas/internal/sshconfig"
+)
+
+// credentialCmd represents the credential command
+var credentialCmd = &cobra.Command{
+	Use:   "credential <get|store|erase>",
+	Short: "Git credential helper serving the API tokens of accounts for HTTPS remotes",
+	Long: `Implements git's credential helper protocol, so HTTPS remotes authenticate with the token
+of the account stored by 'gas login' or set in GAS_TOKEN_<ACCOUNT>. Git runs it as configured
+by 'gas setup --https', e.g.
+
+  git config credential.helper '!gas credential'
+
+The account is picked by the host of the remote and the username in its URL, like
+https://<login>@github.com/owner/repo.git, or else by the owner of the repository when git
+sends the path (credential.useHttpPath). Tokens git reports as working are stored, and
+rejected tokens are erased.`,
+}
+
+// credentialGetCmd represents the credential get command
+var credentialGetCmd = &cobra.Command{
+	Use:   "get",
+	Short: "Print the credential of the account matching the request",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		request, account, ok := credentialAccount()
+		if !ok {
+			return
+		}
+
+		token, err := account.LookupToken()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "gas: could not read the token of account '%s': %v\n", account.Handle, err)
+			return
+		}
+		if token == "" {
+			fmt.Fprintf(os.Stderr, "gas: account '%s' has no token, run 'gas login %s'.\n", account.Handle, account.Handle)
+			return
+		}
+
+		username, password := account.HTTPSCredential(token)
+		response := credential.Credential{Protocol: request.Protocol, Host: request.Host, Username: username, Password: password}
+		if err := response.Write(os.Stdout); err != nil {
+			fmt.Fprintf(os.Stderr, "gas: %v\n", err)
+		}
+	},
+}
+
+// credentialStoreCmd represents the credential store command
+var credentialStoreCmd = &cobra.Command{
+	Use:   "store",
+	Short: "Store a token git authenticated with for the matching account",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		request, account, ok := credentialAccount()
+		if !ok || request.Password == "" {
+			return
+		}
+
+		// A token from the environment takes precedence anyway, and the stored one doesn't need to be written again.
+		received := account.TokenFromHTTPSCredential(request.Username, request.Password)
+		token, err := account.LookupToken()
+		if err != nil || token == received || os.Getenv(account.TokenEnvVar()) != "" {
+			return
+		}
+
+		if err := account.SaveToken(received); err != nil {
+			fmt.Fprintf(os.Stderr, "gas: could not store the token of account '%s': %v\n", account.Handle, err)
+		}
+	},
+}
+
+// credentialEraseCmd represents the credential erase command
+var credentialEraseCmd = &cobra.Command{
+	Use:   "erase",
+	Short: "Erase a token the forge rejected from the matching account",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		request, account, ok := credentialAccount()
+		if !ok {
+			return
+		}
+
+		// Only the rejected token is erased, not one stored after it.
+		token, err := account.LookupToken()
+		if err != nil || token == "" || (request.Password != "" && token != account.TokenFromHTTPSCredential(request.Username, request.Password)) {
+			return
+		}
+		if os.Getenv(account.TokenEnvVar()) != "" {
+			fmt.Fprintf(os.Stderr, "gas: the token of account '%s' in %s was rejected.\n", account.Handle, account.TokenEnvVar())
+			return
+		}
+
+		if err := account.DeleteToken(); err != nil {
+			fmt.Fprintf(os.Stderr, "gas: could not erase the token of account '%s': %v\n", account.Handle, err)
+			return
+		}
+		fmt.Fprintf(os.Stderr, "gas: erased the rejected token of account '%s', run 'gas login %s' to get a new one.\n", account.Handle, account.Handle)
+	},
+}
+
+// credentialAccount reads the credential request from stdin and picks its account.
+// Messages go to stderr, as git reads the response from stdout.
+func credentialAccount() (credential.Credential, accounts.Account, bool) {
+	request, err := credential.Read(os.Stdin)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "gas: %v\n", err)
+		return request, accounts.Account{}, false
+	}
+	if request.Protocol != "https" {
+		return request, accounts.Account{}, false
+	}
+
+	config, err := sshconfig.Load(sshconfig.DefaultPath())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "gas: could not read SSH config file: %v\n", err)
+		return request, accounts.Account{}, false
+	}
+
+	accountList, errs := accounts.LoadAccounts()
+	for _, err := range errs {
+		fmt.Fprintf(os.Stderr, "gas: %v\n", err)
+	}
+	hosts := map[string]string{}
+	for _, account := range accountList {
+		hosts[account.Handle] = accountHost(config, account)
+	}
+
+	account, ok := credential.SelectAccount(request, accountList, hosts)
+	return request, account, ok
+}
+
+func init() {
+	rootCmd.AddCommand(credentialCmd)
+	credentialCmd.AddCommand(credentialGetCmd, credentialStoreCmd, credentialEraseCmd)
+}
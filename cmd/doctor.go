@@ -0,0 +1,143 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/style77/gas/internal/accounts"
+	"github.com/style77/gas/internal/doctor"
+	"github.com/style77/gas/internal/git"
+	"github.com/style77/gas/internal/sshconfig"
+)
+
+// doctorCmd represents the doctor command
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Check the accounts, keys and SSH config for problems",
+	Long: `Check everything pushing as the right account depends on: that ~/.gas.yaml parses,
+every account's key exists with safe permissions and parses, every alias exists in the
+SSH config and uses the account's key, no two accounts share a key, the global identity
+belongs to an account, git and OpenSSH are new enough, known_hosts has the forge's key and
+no account still needs migrating to the current format of ~/.gas.yaml.
+
+Every finding comes with a suggested fix. Run with --fix to apply the ones that don't
+need a decision, such as key permissions, missing aliases and migrating accounts.
+The command exits with status 1 when errors remain.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		fix, _ := cmd.Flags().GetBool("fix")
+		asJSON, _ := cmd.Flags().GetBool("json")
+
+		env, err := doctorEnvironment()
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+
+		findings := doctor.Run(env)
+		if fix {
+			// keep the JSON output parseable
+			out := os.Stdout
+			if asJSON {
+				out = os.Stderr
+			}
+
+			sshConfigBefore := env.SSHConfig.String()
+			fixed := 0
+			for _, finding := range findings {
+				if finding.Fix == nil {
+					continue
+				}
+				if err := finding.Fix(); err != nil {
+					fmt.Fprintf(out, "Could not fix '%s': %v\n", finding.Message, err)
+					continue
+				}
+				fixed++
+				fmt.Fprintf(out, "Fixed: %s\n", finding.Message)
+			}
+
+			if env.SSHConfig.String() != sshConfigBefore {
+				if err := env.SSHConfig.Save(); err != nil {
+					fmt.Fprintln(out, err)
+				}
+			}
+			if fixed > 0 {
+				env.Accounts, env.AccountErrs = accounts.LoadAccounts()
+				findings = doctor.Run(env)
+			}
+		}
+
+		if asJSON {
+			if findings == nil {
+				findings = []doctor.Finding{}
+			}
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "  ")
+			encoder.SetEscapeHTML(false)
+			if err := encoder.Encode(findings); err != nil {
+				fmt.Println(err)
+				return
+			}
+		} else {
+			doctor.Print(os.Stdout, findings)
+		}
+
+		for _, finding := range findings {
+			if finding.Severity == doctor.SeverityError {
+				os.Exit(1)
+			}
+		}
+	},
+}
+
+// doctorEnvironment gathers the configuration the checks look at.
+func doctorEnvironment() (doctor.Environment, error) {
+	var env doctor.Environment
+
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return env, fmt.Errorf("could not find user home directory: %w", err)
+	}
+
+	configPath := viper.ConfigFileUsed()
+	if configPath == "" {
+		configPath = filepath.Join(homeDir, ".gas.yaml")
+	}
+	config := viper.New()
+	config.SetConfigFile(configPath)
+	config.SetConfigType("yaml")
+	env.ConfigErr = config.ReadInConfig()
+
+	env.Accounts, env.AccountErrs = accounts.LoadAccounts()
+
+	env.SSHConfig, err = sshconfig.Load(sshconfig.DefaultPath())
+	if err != nil {
+		return env, fmt.Errorf("could not read SSH config file: %w", err)
+	}
+
+	env.GlobalEmail = git.GetCurrentGlobal()
+	env.GitVersion = git.GetVersion()
+	if output, err := exec.Command("ssh", "-V").CombinedOutput(); err == nil {
+		env.SSHVersion = string(output)
+	}
+	env.Now = time.Now()
+	env.KnownHostsFiles = []string{
+		filepath.Join(homeDir, ".ssh", "known_hosts"),
+		filepath.Join(homeDir, ".ssh", "known_hosts2"),
+	}
+
+	return env, nil
+}
+
+func init() {
+	rootCmd.AddCommand(doctorCmd)
+
+	doctorCmd.Flags().Bool("fix", false, "Apply the fixes that don't need a decision.")
+	doctorCmd.Flags().Bool("json", false, "Print the findings as JSON.")
+}
@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/style77/gas/internal/hooks"
+	"github.com/style77/gas/internal/shim"
+)
+
+// previousHooksPathKey is the config key holding the global core.hooksPath configured before gas took it over.
+const previousHooksPathKey = "hooks.previouspath"
+
+// hooksCmd represents the hooks command
+var hooksCmd = &cobra.Command{
+	Use:   "hooks",
+	Short: "Manage the git hooks guarding commit identity",
+	Long: `Manage the git hooks guarding commit identity.
+
+The hooks are installed globally through core.hooksPath, so they also run for commits
+made from IDEs and other tools that don't go through GAS. The pre-commit hook checks the
+commit author and the pre-push hook checks every pushed commit against the account expected
+for the repository's remotes. The repository's own hooks are still run afterwards.`,
+}
+
+// hooksInstallCmd represents the hooks install command
+var hooksInstallCmd = &cobra.Command{
+	Use:   "install",
+	Short: "Install the gas managed git hooks globally",
+	Run: func(cmd *cobra.Command, args []string) {
+		dir, err := hooks.DefaultDir()
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+
+		gasPath, err := shim.Executable()
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+
+		previous, err := hooks.Install(dir, gasPath)
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+
+		if previous != "" {
+			viper.Set(previousHooksPathKey, previous)
+			if err := viper.WriteConfig(); err != nil {
+				fmt.Printf("Failed to save previous hooks path '%s'. Error: %s\n", previous, err)
+				return
+			}
+			fmt.Printf("Hooks from the previous global hooks path '%s' will still be run.\n", previous)
+		}
+
+		fmt.Printf("Installed git hooks in '%s' and set global core.hooksPath.\n", dir)
+		fmt.Println("Repositories setting their own core.hooksPath (e.g. husky) keep using their hooks instead.")
+	},
+}
+
+// hooksUninstallCmd represents the hooks uninstall command
+var hooksUninstallCmd = &cobra.Command{
+	Use:   "uninstall",
+	Short: "Remove the gas managed git hooks",
+	Run: func(cmd *cobra.Command, args []string) {
+		dir, err := hooks.DefaultDir()
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+
+		previous := viper.GetString(previousHooksPathKey)
+		if err := hooks.Uninstall(dir, previous); err != nil {
+			fmt.Println(err)
+			return
+		}
+
+		viper.Set(previousHooksPathKey, "")
+		if err := viper.WriteConfig(); err != nil {
+			fmt.Printf("Failed to update config file. Error: %s\n", err)
+			return
+		}
+
+		fmt.Println("Removed git hooks and restored global core.hooksPath.")
+	},
+}
+
+// hookCmd represents the hook command
+var hookCmd = &cobra.Command{
+	Use:                "hook <name> [args...]",
+	Short:              "Run a gas managed git hook",
+	Long:               `Run a gas managed git hook. This is called by the hooks installed with 'gas hooks install'.`,
+	Args:               cobra.MinimumNArgs(1),
+	DisableFlagParsing: true,
+	Run: func(cmd *cobra.Command, args []string) {
+		chainDirs := hooks.ChainDirs(viper.GetString(previousHooksPathKey))
+		os.Exit(hooks.Run(args[0], args[1:], os.Stdin, chainDirs))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(hooksCmd)
+	hooksCmd.AddCommand(hooksInstallCmd)
+	hooksCmd.AddCommand(hooksUninstallCmd)
+
+	rootCmd.AddCommand(hookCmd)
+}
@@ -0,0 +1,352 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/style77/gas/internal/accounts"
+	"github.com/style77/gas/internal/git"
+	"github.com/style77/gas/internal/helpers"
+	"github.com/style77/gas/internal/rotate"
+	"github.com/style77/gas/internal/sshauth"
+	"github.com/style77/gas/internal/sshconfig"
+	"golang.org/x/crypto/ssh"
+)
+
+// keyCmd represents the key command
+var keyCmd = &cobra.Command{
+	Use:   "key",
+	Short: "Manage the SSH keys of accounts",
+}
+
+// keyRotateCmd represents the key rotate command
+var keyRotateCmd = &cobra.Command{
+	Use:   "rotate <account>",
+	Short: "Replace the SSH key of an account with a newly generated one",
+	Long: `Generate a new SSH key for the account, upload it to the forge when an API token is
+configured with 'gas login' or the GAS_TOKEN_<ACCOUNT> environment variable, verify the forge
+authenticates it as the account, and only then point the account and the IdentityFile of its SSH
+alias at it.
+The old key is then moved to ~/.gas/archive/<account>, and removed from the forge with --remove-old.
+When the account signs commits with its SSH key, the new key is uploaded and the old one removed as a
+signing key as well.
+The account is given by handle, ID, email, SSH alias or a unique prefix of them, never by a fuzzy match.
+
+Progress is saved in ~/.gas/rotate/<account>.json after every step. If a step fails, e.g.
+because the new key wasn't added to the forge yet, run the command again to continue.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		removeOld, _ := cmd.Flags().GetBool("remove-old")
+		noUpload, _ := cmd.Flags().GetBool("no-upload")
+
+		account, err := accounts.ResolveAccountStrictly(args[0])
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		if account.CertificateFile != "" {
+			fmt.Printf("Account '%s' authenticates with a certificate, request a new one from your certificate authority instead.\n", account.Handle)
+			return
+		}
+
+		statePath, err := rotate.StatePath(account.Handle)
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		state, err := rotate.LoadState(statePath)
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		if state != nil {
+			fmt.Printf("Continuing the rotation of '%s' started %s.\n", account.Handle, state.StartedAt.Local().Format("2006-01-02 15:04"))
+		} else {
+			state, err = newRotation(account, time.Now())
+			if err != nil {
+				fmt.Println(err)
+				return
+			}
+		}
+
+		config, err := sshconfig.Load(sshconfig.DefaultPath())
+		if err != nil {
+			fmt.Printf("Could not read SSH config file: %v\n", err)
+			return
+		}
+		host := accountHost(config, account)
+		client, err := account.Forge(host)
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		token := account.Token()
+
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+
+		rotator := &rotate.Rotator{
+			StatePath:  statePath,
+			ArchiveDir: filepath.Join(homeDir, ".gas", "archive", account.Handle),
+			Out:        os.Stdout,
+			Generate: func(keyPath string) error {
+				// Leftovers of an interrupted ssh-keygen would make it ask to overwrite them.
+				os.Remove(keyPath)
+				os.Remove(keyPath + ".pub")
+				_, err := helpers.GenerateSSHKeyAt(account.Email, keyPath, helpers.RealCommandExecutor{})
+				return err
+			},
+			Verify: func(keyPath string) error {
+				_, result, err := authenticateAccount(account, keyPath, sshauth.Target{}, []string{filepath.Join("~", ".ssh", "known_hosts")})
+				if err != nil {
+					return err
+				}
+				// Forges not telling the login, like Bitbucket Cloud, only confirm that the key authenticates.
+				if account.Login != "" && result.Login != "" && !strings.EqualFold(result.Login, account.Login) {
+					return fmt.Errorf("it authenticates as '%s'", result.Login)
+				}
+				return nil
+			},
+			Configure: func(keyPath string) error {
+				return configureRotatedKey(&account, config, keyPath)
+			},
+		}
+
+		signing := account.Signing.Format == accounts.SSHSigningFormat
+		if token != "" && !noUpload {
+			rotator.Upload = func(keyPath string) (int64, error) {
+				return uploadRotatedKey(client, token, keyPath, signing)
+			}
+		}
+
+		if removeOld {
+			if token == "" {
+				fmt.Printf("Removing the old key from the forge requires an API token, run 'gas login %s' or set %s.\n", account.Handle, account.TokenEnvVar())
+				return
+			}
+			rotator.FindOld = func(keyPath string) (int64, error) {
+				return findUploadedKey(client, token, keyPath)
+			}
+			rotator.RemoveOld = func(keyPath string, id int64) error {
+				if err := removeUploadedKey(client, token, keyPath, id); err != nil {
+					return err
+				}
+				if signing {
+					return removeSigningKey(client, token, keyPath)
+				}
+				return nil
+			}
+		}
+
+		if err := rotator.Run(state); err != nil {
+			fmt.Println(err)
+			if state.Step == rotate.StepUploaded && rotator.Upload == nil {
+				if publicKey, err := helpers.AuthorizedKey(state.NewKeyPath); err == nil {
+					kind := "public key"
+					if signing {
+						kind = "public key as an authentication and a signing key"
+					}
+					fmt.Printf("\nAdd the new %s to your %s account, then run 'gas key rotate %s' again:\n%s\n", kind, host, account.Handle, publicKey)
+				}
+			} else {
+				fmt.Printf("Run 'gas key rotate %s' again to continue.\n", account.Handle)
+			}
+			return
+		}
+
+		fmt.Printf("Rotated the key of account '%s'.\n", account.Handle)
+		if !removeOld {
+			fmt.Printf("Remember to remove the old key from your %s account.\n", host)
+		}
+	},
+}
+
+// newRotation starts a rotation replacing the key of the account with a new key next to it.
+func newRotation(account accounts.Account, now time.Time) (*rotate.State, error) {
+	oldKeyPath, err := helpers.ExpandPath(account.SSHKeyPath)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := os.Stat(oldKeyPath); err != nil {
+		return nil, fmt.Errorf("could not find the key of account '%s': %w", account.Handle, err)
+	}
+
+	base := filepath.Join(filepath.Dir(oldKeyPath), fmt.Sprintf("id_rsa_%s_%s", account.Handle, now.Format("20060102")))
+	newKeyPath := base
+	for i := 2; ; i++ {
+		if _, err := os.Stat(newKeyPath); errors.Is(err, os.ErrNotExist) {
+			break
+		}
+		newKeyPath = fmt.Sprintf("%s_%d", base, i)
+	}
+
+	return &rotate.State{
+		Account:    account.Handle,
+		OldKeyPath: oldKeyPath,
+		NewKeyPath: newKeyPath,
+		StartedAt:  now,
+	}, nil
+}
+
+// configureRotatedKey points the account, the IdentityFile of its SSH alias and its signing setup at the new key.
+func configureRotatedKey(account *accounts.Account, config *sshconfig.Config, keyPath string) error {
+	account.SSHKeyPath = keyPath
+	if err := account.Save(); err != nil {
+		return fmt.Errorf("could not update account: %w", err)
+	}
+
+	if account.SSHAlias != "" && config.FindHost(account.SSHAlias) != nil {
+		if err := config.SetOption(account.SSHAlias, "IdentityFile", keyPath); err != nil {
+			return err
+		}
+		if err := config.Save(); err != nil {
+			return err
+		}
+	} else {
+		fmt.Printf("Account '%s' has no SSH alias, update the IdentityFile in your SSH config by hand.\n", account.Handle)
+	}
+
+	if account.Signing.Format != accounts.SSHSigningFormat {
+		return nil
+	}
+	if git.IsCurrentGlobal(account.Email) {
+		return account.ConfigureSigning(git.GlobalScope)
+	}
+
+	allowedSignersPath, err := accounts.AllowedSignersPath()
+	if err != nil {
+		return err
+	}
+	return accounts.WriteAllowedSigners(allowedSignersPath, accounts.GetAccounts())
+}
+
+// uploadRotatedKey registers the public key of the private key at keyPath on the forge account of the token unless
+// it's registered already, e.g. by an interrupted rotation, and returns its ID. With signing, it's also registered
+// as a signing key.
+func uploadRotatedKey(client git.Forge, token, keyPath string, signing bool) (int64, error) {
+	publicKey, err := helpers.AuthorizedKey(keyPath)
+	if err != nil {
+		return 0, err
+	}
+
+	id, err := findUploadedKey(client, token, keyPath)
+	if err != nil {
+		return 0, err
+	}
+	if id == 0 {
+		key, err := client.AddPublicKey(token, git.KeyTitle(), publicKey)
+		if err != nil {
+			return 0, err
+		}
+		id = key.ID
+	}
+	if !signing {
+		return id, nil
+	}
+
+	signingKeys, err := client.ListOwnSigningKeys(token)
+	if err != nil {
+		return 0, err
+	}
+	if signingID, err := findKey(signingKeys, keyPath); err != nil || signingID != 0 {
+		return id, err
+	}
+	if _, err := client.AddSigningKey(token, git.KeyTitle(), publicKey); err != nil {
+		return 0, fmt.Errorf("could not upload the signing key: %w", err)
+	}
+
+	return id, nil
+}
+
+// findUploadedKey returns the forge's ID of the public key of the private key at keyPath on the forge account of the
+// token, 0 if it isn't registered.
+func findUploadedKey(client git.Forge, token, keyPath string) (int64, error) {
+	keys, err := client.ListOwnPublicKeys(token)
+	if err != nil {
+		return 0, err
+	}
+
+	return findKey(keys, keyPath)
+}
+
+// findKey returns the ID of the public key of the private key at keyPath among keys, 0 if it's not one of them.
+func findKey(keys []git.PublicKey, keyPath string) (int64, error) {
+	publicKey, err := helpers.ReadPublicKey(keyPath)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, key := range keys {
+		uploaded, _, _, _, err := ssh.ParseAuthorizedKey([]byte(key.Key))
+		if err == nil && string(uploaded.Marshal()) == string(publicKey.Marshal()) {
+			return key.ID, nil
+		}
+	}
+
+	return 0, nil
+}
+
+// removeUploadedKey removes the public key of the private key at keyPath from the forge account of the token, by id
+// if it's not 0.
+func removeUploadedKey(client git.Forge, token, keyPath string, id int64) error {
+	if id == 0 {
+		var err error
+		if id, err = findUploadedKey(client, token, keyPath); err != nil {
+			return err
+		}
+	}
+	if id == 0 {
+		fmt.Println("The old key is not registered on the forge, nothing to remove.")
+		return nil
+	}
+
+	err := client.DeletePublicKey(token, id)
+	var statusErr *git.StatusError
+	if errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusNotFound {
+		fmt.Println("The old key was already removed from the forge.")
+		return nil
+	}
+
+	return err
+}
+
+// removeSigningKey removes the signing key of the private key at keyPath from the forge account of the token, if
+// the forge keeps signing keys apart from the keys removeUploadedKey removes.
+func removeSigningKey(client git.Forge, token, keyPath string) error {
+	keys, err := client.ListOwnSigningKeys(token)
+	if err != nil {
+		return err
+	}
+	id, err := findKey(keys, keyPath)
+	if err != nil || id == 0 {
+		return err
+	}
+
+	err = client.DeleteSigningKey(token, id)
+	var statusErr *git.StatusError
+	if errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusNotFound {
+		return nil
+	}
+	if err == nil {
+		fmt.Println("Removed the old signing key from the forge.")
+	}
+
+	return err
+}
+
+func init() {
+	rootCmd.AddCommand(keyCmd)
+	keyCmd.AddCommand(keyRotateCmd)
+
+	keyRotateCmd.Flags().Bool("remove-old", false, "Remove the old key from the forge after the new one is verified. Requires an API token.")
+	keyRotateCmd.Flags().Bool("no-upload", false, "Don't upload the new key even if an API token is configured.")
+}
@@ -0,0 +1,176 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+	"github.com/style77/gas/internal/accounts"
+	"github.com/style77/gas/internal/git"
+	"github.com/style77/gas/internal/helpers"
+	"github.com/style77/gas/internal/sshauth"
+	"github.com/style77/gas/internal/sshconfig"
+)
+
+// knownHostsCmd represents the known-hosts command
+var knownHostsCmd = &cobra.Command{
+	Use:   "known-hosts",
+	Short: "Manage the host keys of the forges in known_hosts",
+}
+
+// knownHostsSyncCmd represents the known-hosts sync command
+var knownHostsSyncCmd = &cobra.Command{
+	Use:   "sync [host...]",
+	Short: "Write the host keys published by the forges to known_hosts",
+	Long: `Fetch the SSH host keys from the meta API of every GitHub forge used by the accounts,
+or of the provided hosts, and write them to known_hosts, replacing the existing entries.
+
+The keys of github.com are checked against fingerprints embedded in GAS, and nothing is
+written if they don't match. GitHub Enterprise Server hosts are trusted over HTTPS.
+Aliases connecting through another host name or port, like ssh.github.com:443, get a
+HostKeyAlias so ssh checks them against the same keys.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		knownHostsPath, _ := cmd.Flags().GetString("file")
+		apiURL, _ := cmd.Flags().GetString("api-url")
+
+		knownHostsPath, err := helpers.ExpandPath(knownHostsPath)
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+
+		config, err := sshconfig.Load(sshconfig.DefaultPath())
+		if err != nil {
+			fmt.Printf("Could not read SSH config file: %v\n", err)
+			return
+		}
+
+		hosts := args
+		if len(hosts) == 0 {
+			hosts = forgeHosts(config)
+		}
+		if apiURL != "" && len(hosts) != 1 {
+			fmt.Println("--api-url can only be used with a single host.")
+			return
+		}
+
+		failed := false
+		for _, host := range hosts {
+			client := git.NewGitHubClient(host)
+			if apiURL != "" {
+				client.BaseURL = apiURL
+			}
+
+			lines, err := client.FetchSSHHostKeys()
+			if err != nil {
+				fmt.Printf("Skipping '%s': %v\n", host, err)
+				failed = true
+				continue
+			}
+
+			keys, err := sshauth.ParseHostKeys(lines)
+			if err != nil {
+				fmt.Printf("Skipping '%s': %v\n", host, err)
+				failed = true
+				continue
+			}
+
+			if mismatched := sshauth.VerifyPinnedHostKeys(host, keys); len(mismatched) > 0 {
+				fmt.Printf("Refusing to write the keys of '%s': these keys don't match the fingerprints pinned in GAS:\n", host)
+				for _, fingerprint := range mismatched {
+					fmt.Printf("  - %s\n", fingerprint)
+				}
+				failed = true
+				continue
+			}
+
+			replaced, err := sshauth.UpdateKnownHosts(knownHostsPath, host, 22, keys)
+			if err != nil {
+				fmt.Println(err)
+				failed = true
+				continue
+			}
+			fmt.Printf("Wrote %d host keys of '%s' to '%s', replacing %d entries.\n", len(keys), host, knownHostsPath, replaced)
+		}
+
+		if setHostKeyAliases(config) {
+			if err := config.Save(); err != nil {
+				fmt.Println(err)
+				failed = true
+			}
+		}
+
+		if failed {
+			os.Exit(1)
+		}
+	},
+}
+
+// forgeHost returns the host publishing the host keys ssh is presented with when connecting to alias.
+func forgeHost(config *sshconfig.Config, alias string) (string, sshconfig.Resolved) {
+	resolved := config.Resolve(alias)
+	return accounts.ForgeOfHost(resolved.HostName), resolved
+}
+
+// accountHost returns the host of the forge the account connects to, the account's configured host for accounts
+// without an alias.
+func accountHost(config *sshconfig.Config, account accounts.Account) string {
+	if account.SSHAlias == "" {
+		return account.ForgeHost()
+	}
+
+	host, _ := forgeHost(config, account.SSHAlias)
+	return host
+}
+
+// forgeHosts returns the hosts the GitHub accounts connect to, as only GitHub publishes its host keys in an API.
+func forgeHosts(config *sshconfig.Config) []string {
+	var hosts []string
+	seen := map[string]bool{}
+	for _, account := range accounts.GetAccounts() {
+		if account.ForgeProvider() != git.GitHubProvider {
+			continue
+		}
+		host := accountHost(config, account)
+		if !seen[host] {
+			seen[host] = true
+			hosts = append(hosts, host)
+		}
+	}
+
+	return hosts
+}
+
+// setHostKeyAliases sets HostKeyAlias for the aliases connecting to their forge through another host name or port,
+// so that ssh checks them against the keys written for the forge. It reports whether the config changed.
+func setHostKeyAliases(config *sshconfig.Config) bool {
+	changed := false
+	for _, account := range accounts.GetAccounts() {
+		if account.SSHAlias == "" || config.FindHost(account.SSHAlias) == nil {
+			continue
+		}
+
+		host, resolved := forgeHost(config, account.SSHAlias)
+		if (resolved.HostName == host && resolved.Port == 22) || resolved.Options["hostkeyalias"] != "" {
+			continue
+		}
+
+		if err := config.SetOption(account.SSHAlias, "HostKeyAlias", host); err != nil {
+			fmt.Println(err)
+			continue
+		}
+		changed = true
+		fmt.Printf("Set HostKeyAlias '%s' for alias '%s'.\n", host, account.SSHAlias)
+	}
+
+	return changed
+}
+
+func init() {
+	rootCmd.AddCommand(knownHostsCmd)
+	knownHostsCmd.AddCommand(knownHostsSyncCmd)
+
+	knownHostsSyncCmd.Flags().String("file", filepath.Join("~", ".ssh", "known_hosts"), "known_hosts file to write.")
+	knownHostsSyncCmd.Flags().String("api-url", "", "API URL to fetch the host keys from, e.g. https://github.example.com/api/v3. Only with a single host.")
+}
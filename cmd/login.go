@@ -0,0 +1,119 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/style77/gas/internal/accounts"
+	"github.com/style77/gas/internal/git"
+	"github.com/style77/gas/internal/oauth"
+	"github.com/style77/gas/internal/sshconfig"
+)
+
+// Config keys of the OAuth login.
+const (
+	loginClientIDKey = "login.clientid"
+	loginOAuthURLKey = "login.oauthurl"
+	loginAPIURLKey   = "login.apiurl"
+)
+
+// loginScopes are the scopes gas needs to verify the login and to manage the keys of an account.
+var loginScopes = []string{"read:user", "admin:public_key", "admin:ssh_signing_key"}
+
+// loginCmd represents the login command
+var loginCmd = &cobra.Command{
+	Use:   "login [account]",
+	Short: "Authorize gas to use the GitHub API for an account",
+	Long: `Log in to GitHub with the OAuth device flow: gas prints a code to enter in the browser
+and waits until the authorization is granted. The token must belong to the account's GitHub user.
+
+The token is stored in the secret store, by default in ~/.gas/secrets (see 'gas secret' for
+how it's protected), never in ~/.gas.yaml, and is used by commands
+calling the API, such as 'gas key rotate'. A GAS_TOKEN_<ACCOUNT> environment variable takes
+precedence over it. The account is given by handle, ID, email, SSH alias or a unique prefix of them,
+never by a fuzzy match.
+
+The OAuth app is set with --client-id or login.clientid in ~/.gas.yaml. The OAuth server and
+the API default to the forge of the account's SSH alias and can be set with --oauth-url and
+--api-url, or login.oauthurl and login.apiurl.`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		account, err := loginAccount(args)
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		if account.ForgeProvider() != git.GitHubProvider {
+			fmt.Printf("'gas login' only supports GitHub. Create a personal access token on %s and store it with 'gas secret set token/%s', or set %s.\n", git.ProviderName(account.ForgeProvider()), account.Handle, account.TokenEnvVar())
+			return
+		}
+
+		clientID := flagOrConfig(cmd, "client-id", loginClientIDKey)
+		if clientID == "" {
+			fmt.Printf("No OAuth app configured. Register an OAuth app with the device flow enabled and pass its client ID with --client-id or set %s.\n", loginClientIDKey)
+			return
+		}
+
+		config, err := sshconfig.Load(sshconfig.DefaultPath())
+		if err != nil {
+			fmt.Printf("Could not read SSH config file: %v\n", err)
+			return
+		}
+		host := accountHost(config, account)
+
+		flow := &oauth.DeviceFlow{
+			BaseURL:  flagOrConfig(cmd, "oauth-url", loginOAuthURLKey),
+			ClientID: clientID,
+			Scopes:   loginScopes,
+		}
+		if flow.BaseURL == "" && host != "github.com" {
+			flow.BaseURL = "https://" + host
+		}
+
+		client := git.NewGitHubClient(host)
+		if apiURL := flagOrConfig(cmd, "api-url", loginAPIURLKey); apiURL != "" {
+			client.BaseURL = apiURL
+		}
+
+		login, err := account.LogIn(flow, client, os.Stdout)
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		fmt.Printf("Logged in as '%s'.\n", login)
+	},
+}
+
+// loginAccount returns the provided account, or lets the user pick one.
+func loginAccount(args []string) (accounts.Account, error) {
+	if len(args) > 0 {
+		return accounts.ResolveAccountStrictly(args[0])
+	}
+
+	account, err := accounts.InteractiveSelectAccount()
+	if err == nil && account.Handle == "" {
+		err = fmt.Errorf("no account selected")
+	}
+
+	return account, err
+}
+
+// flagOrConfig returns the value of the flag if it was set, or the value of the config key.
+func flagOrConfig(cmd *cobra.Command, flag, key string) string {
+	if cmd.Flags().Changed(flag) {
+		value, _ := cmd.Flags().GetString(flag)
+		return value
+	}
+
+	return viper.GetString(key)
+}
+
+func init() {
+	rootCmd.AddCommand(loginCmd)
+
+	loginCmd.Flags().String("client-id", "", "Client ID of the OAuth app to log in with.")
+	loginCmd.Flags().String("oauth-url", "", "Base URL of the OAuth server, e.g. https://github.com.")
+	loginCmd.Flags().String("api-url", "", "Base URL of the API, e.g. https://api.github.com.")
+}
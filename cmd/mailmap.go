@@ -0,0 +1,95 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+	"github.com/style77/gas/internal/accounts"
+	"github.com/style77/gas/internal/git"
+	"github.com/style77/gas/internal/mailmap"
+	"github.com/style77/gas/internal/repo"
+)
+
+// mailmapCmd represents the mailmap command
+var mailmapCmd = &cobra.Command{
+	Use:   "mailmap",
+	Short: "Generate .mailmap entries from configured accounts",
+	Long: `Generate or update the repository's .mailmap so that commits made with the email
+of any configured account are attributed to the account expected for the repository.
+
+Existing entries are kept, entries for the same commit identity are updated.
+Use --check to fail when the .mailmap is stale instead of writing it.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		accountRaw, _ := cmd.Flags().GetString("account")
+		check, _ := cmd.Flags().GetBool("check")
+
+		var canonical accounts.Account
+		if accountRaw != "" {
+			var err error
+			canonical, err = accounts.ResolveAccount(accountRaw)
+			if err != nil {
+				fmt.Println(err)
+				return
+			}
+		} else {
+			identity := repo.ResolveIdentity(repo.DefaultRemote())
+			if identity.ExpectedAccount == nil {
+				fmt.Println("Could not determine the account expected for this repository. Use --account to select one.")
+				return
+			}
+			canonical = *identity.ExpectedAccount
+		}
+
+		topLevel, err := git.GetTopLevel()
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		mailmapPath := filepath.Join(topLevel, ".mailmap")
+
+		commits, err := git.ListCommits("--all")
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+
+		content, err := os.ReadFile(mailmapPath)
+		if err != nil && !os.IsNotExist(err) {
+			fmt.Printf("Could not read .mailmap: %v\n", err)
+			return
+		}
+
+		m := mailmap.Parse(string(content))
+		changed := m.Merge(mailmap.Generate(commits, canonical, accounts.GetAccounts()))
+
+		if check {
+			if changed {
+				fmt.Println(".mailmap is stale. Run 'gas mailmap' to update it.")
+				os.Exit(1)
+			}
+			fmt.Println(".mailmap is up to date.")
+			return
+		}
+
+		if !changed {
+			fmt.Println(".mailmap is already up to date.")
+			return
+		}
+
+		if err := os.WriteFile(mailmapPath, []byte(m.String()), 0644); err != nil {
+			fmt.Printf("Could not write .mailmap: %v\n", err)
+			return
+		}
+
+		fmt.Printf("Updated '%s' to map account emails onto '%s' <%s>.\n", mailmapPath, canonical.Name, canonical.Email)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(mailmapCmd)
+
+	mailmapCmd.Flags().StringP("account", "a", "", "Account commits should be attributed to. Defaults to the account bound to the repository's remote.")
+	mailmapCmd.Flags().Bool("check", false, "Fail if the .mailmap is stale instead of updating it.")
+}
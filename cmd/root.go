@@ -3,12 +3,12 @@ package cmd
 import (
 	"fmt"
 	"os"
-	"strings"
 
-	"github.com/AlecAivazis/survey/v2"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 	"github.com/style77/gas/internal/git"
+	"github.com/style77/gas/internal/repo"
+	"github.com/style77/gas/internal/shim"
 )
 
 var rootCmd = &cobra.Command{
@@ -27,6 +27,10 @@ func isUnknownCommandError(err error) bool {
 }
 
 func Execute() {
+	if shim.IsInvokedAsGit(os.Args[0]) {
+		runAsGitShim()
+	}
+
 	rootCmd.SilenceErrors = true
 
 	rootErr := rootCmd.Execute()
@@ -35,12 +39,11 @@ func Execute() {
 			args := os.Args[1:]
 
 			// Check if this is desired account
-			currentAccount := git.GetCurrentGlobal()
-
-			var isProperAccount bool
-			survey.AskOne(&survey.Confirm{
-				Message: fmt.Sprintf("Do you want to run '%s' as '%s'?", strings.Join(args, " "), currentAccount),
-			}, &isProperAccount)
+			isProperAccount, err := repo.ConfirmIdentity(args)
+			if err != nil {
+				fmt.Println(err)
+				os.Exit(1)
+			}
 
 			if isProperAccount {
 				err := git.HandleGitCommand(args)
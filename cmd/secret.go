@@ -0,0 +1,190 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/style77/gas/internal/secrets"
+)
+
+// secretCmd represents the secret command
+var secretCmd = &cobra.Command{
+	Use:   "secret",
+	Short: "Manage the secrets gas stores, such as API tokens and key passphrases",
+	Long: `Manage the secrets gas stores outside of ~/.gas.yaml. Secrets are named like
+'token/<account>' for the API token stored by 'gas login' and 'passphrase/<account>' for
+the passphrase of the account's SSH key.
+
+The backend is selected with secrets.backend in ~/.gas.yaml:
+  file  a file in ~/.gas/secrets readable only by you (default). Its key is stored next to it
+        in ~/.gas/secrets.key, so anyone who can read one can read the other: it keeps secrets
+        out of plain sight, not safe from your user account. With secrets.passphrase set to
+        true, the file is encrypted with a key derived from a passphrase, read from
+        GAS_SECRETS_PASSPHRASE or asked for, and ~/.gas/secrets.key isn't used. After changing
+        secrets.passphrase, the file is re-encrypted the next time it's read.
+  pass  the password store of pass(1), under gas/<name>.
+  env   read only, from GAS_<NAME> environment variables, e.g. GAS_TOKEN_WORK. Meant for CI.`,
+}
+
+// secretSetCmd represents the secret set command
+var secretSetCmd = &cobra.Command{
+	Use:   "set <name>",
+	Short: "Store a secret",
+	Long: `Store a secret. The value is asked for, or read from the first line of stdin with --stdin,
+so it doesn't end up in the shell history.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		fromStdin, _ := cmd.Flags().GetBool("stdin")
+
+		if err := secrets.ValidateName(args[0]); err != nil {
+			fmt.Println(err)
+			return
+		}
+
+		var value string
+		if fromStdin {
+			line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+			if err != nil && line == "" {
+				fmt.Printf("Could not read the secret from stdin: %v\n", err)
+				return
+			}
+			value = strings.TrimRight(line, "\r\n")
+		} else if err := survey.AskOne(&survey.Password{Message: fmt.Sprintf("Enter the value of '%s':", args[0])}, &value); err != nil {
+			fmt.Println(err)
+			return
+		}
+		if value == "" {
+			fmt.Println("The secret can't be empty.")
+			return
+		}
+
+		store, err := secrets.Default()
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		if err := store.Set(args[0], value); err != nil {
+			fmt.Printf("Could not store secret '%s': %v\n", args[0], err)
+			return
+		}
+
+		fmt.Printf("Stored secret '%s'.\n", args[0])
+	},
+}
+
+// secretGetCmd represents the secret get command
+var secretGetCmd = &cobra.Command{
+	Use:   "get <name>",
+	Short: "Print a secret",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := secrets.ValidateName(args[0]); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+
+		store, err := secrets.Default()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+
+		value, err := store.Get(args[0])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Could not read secret '%s': %v\n", args[0], err)
+			os.Exit(1)
+		}
+
+		fmt.Println(value)
+	},
+}
+
+// secretRmCmd represents the secret rm command
+var secretRmCmd = &cobra.Command{
+	Use:   "rm <name>",
+	Short: "Remove a secret",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := secrets.ValidateName(args[0]); err != nil {
+			fmt.Println(err)
+			return
+		}
+
+		store, err := secrets.Default()
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+
+		if err := store.Delete(args[0]); err != nil {
+			fmt.Printf("Could not remove secret '%s': %v\n", args[0], err)
+			return
+		}
+
+		fmt.Printf("Removed secret '%s'.\n", args[0])
+	},
+}
+
+// secretMigrateCmd represents the secret migrate command
+var secretMigrateCmd = &cobra.Command{
+	Use:   "migrate <backend>",
+	Short: "Move every secret to another backend and select it",
+	Long: `Move every secret from the current backend to the provided one, then select the new
+backend in ~/.gas.yaml. Secrets are only removed from the current backend once all of them
+were copied. The env backend is read only, so secrets can't be moved into or out of it.`,
+	Args:      cobra.ExactArgs(1),
+	ValidArgs: []string{secrets.FileBackend, secrets.PassBackend},
+	Run: func(cmd *cobra.Command, args []string) {
+		current := viper.GetString(secrets.BackendKey)
+		if current == "" {
+			current = secrets.FileBackend
+		}
+		if args[0] == current {
+			fmt.Printf("Secrets are already stored in the '%s' backend.\n", current)
+			return
+		}
+
+		from, err := secrets.Open(current)
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		to, err := secrets.Open(args[0])
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+
+		migrated, err := secrets.Migrate(from, to)
+		for _, name := range migrated {
+			fmt.Printf("Moved secret '%s'.\n", name)
+		}
+		if err != nil {
+			fmt.Println(err)
+			// Once every secret was copied, the new backend has to be selected even if some weren't removed.
+			if migrated == nil {
+				return
+			}
+		}
+
+		viper.Set(secrets.BackendKey, args[0])
+		if err := viper.WriteConfig(); err != nil {
+			fmt.Printf("Failed to select backend '%s'. Error: %s\n", args[0], err)
+			return
+		}
+
+		fmt.Printf("Secrets are now stored in the '%s' backend.\n", args[0])
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(secretCmd)
+	secretCmd.AddCommand(secretSetCmd, secretGetCmd, secretRmCmd, secretMigrateCmd)
+
+	secretSetCmd.Flags().Bool("stdin", false, "Read the secret from stdin instead of asking for it.")
+}
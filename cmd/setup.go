@@ -2,10 +2,14 @@ package cmd
 
 import (
 	"fmt"
+	"os"
+	"strings"
 
 	"github.com/spf13/cobra"
 	"github.com/style77/gas/internal/accounts"
+	"github.com/style77/gas/internal/git"
 	"github.com/style77/gas/internal/repo"
+	"github.com/style77/gas/internal/sshconfig"
 )
 
 // setupCmd represents the setup command
@@ -15,7 +19,11 @@ var setupCmd = &cobra.Command{
 	Long: `Set the remote URL of the repository to use the specified GitHub account.
 	
 You can specify the account with the --account flag,
-and the remote name with the --remoteName flag.`,
+and the remote name with the --remoteName flag.
+
+With --https, the remote is set to https://<login>@host/owner/repo.git instead of the
+account's SSH alias, and the repository's credential.helper to 'gas credential', which
+authenticates with the account's token. Use it where SSH is blocked.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		accountRaw, _ := cmd.Flags().GetString("account")
 
@@ -29,32 +37,74 @@ and the remote name with the --remoteName flag.`,
 			}
 		} else {
 			var err error
-			account, err = accounts.GetAccount(accountRaw)
+			account, err = accounts.ResolveAccountStrictly(accountRaw)
 			if err != nil {
 				fmt.Println(err)
 				return
 			}
 		}
 
-		if account.Name == "" {
+		if account.Handle == "" {
 			fmt.Println("No account selected.")
 			return
 		}
 
 		remoteName, _ := cmd.Flags().GetString("remoteName")
-		err := repo.SetRemoteUrl(&account, remoteName)
+		https, _ := cmd.Flags().GetBool("https")
+		if https {
+			err := setupHTTPSRemote(&account, remoteName)
+			if err != nil {
+				fmt.Println(err)
+				return
+			}
+		} else {
+			err := repo.SetRemoteUrl(&account, remoteName)
+			if err != nil {
+				fmt.Println(err)
+				return
+			}
+		}
+
+		err := account.ConfigureSigning(git.LocalScope)
 		if err != nil {
 			fmt.Println(err)
 			return
 		}
 
-		fmt.Printf("Configured repo's remote URL to use account '%s'.", account.Name)
+		fmt.Printf("Configured repo's remote URL to use account '%s'.", account.Handle)
 	},
 }
 
+// setupHTTPSRemote points the remote at the account's HTTPS URL and makes gas its credential helper.
+func setupHTTPSRemote(account *accounts.Account, remoteName string) error {
+	config, err := sshconfig.Load(sshconfig.DefaultPath())
+	if err != nil {
+		return fmt.Errorf("could not read SSH config file: %w", err)
+	}
+
+	if err := repo.SetHTTPSRemoteUrl(account, remoteName, accountHost(config, *account)); err != nil {
+		return err
+	}
+
+	executable, err := os.Executable()
+	if err != nil {
+		executable = "gas"
+	}
+	if err := repo.ConfigureCredentialHelper(fmt.Sprintf("!'%s' credential", strings.ReplaceAll(executable, "'", `'\''`))); err != nil {
+		return err
+	}
+
+	if token, _ := account.LookupToken(); token == "" {
+		fmt.Printf("Account '%s' has no token yet, run 'gas login %s' before pushing.\n", account.Handle, account.Handle)
+	}
+
+	return nil
+}
+
 func init() {
 	rootCmd.AddCommand(setupCmd)
 
-	setupCmd.Flags().StringP("account", "a", "", "Account to switch to. This should be the name of the account you wish to switch to.")
+	setupCmd.Flags().StringP("account", "a", "", "Account to set up the repository for, by handle, ID, email, SSH alias or a unique prefix of them.")
 	setupCmd.Flags().StringP("remoteName", "r", "origin", "Remote name to set the URL for.")
+	setupCmd.Flags().Bool("https", false, "Use an HTTPS remote authenticated by the gas credential helper instead of the SSH alias.")
 }
@@ -0,0 +1,103 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/style77/gas/internal/repo"
+	"github.com/style77/gas/internal/shim"
+)
+
+// shimCmd represents the shim command
+var shimCmd = &cobra.Command{
+	Use:   "shim",
+	Short: "Manage the git shim",
+	Long: `Manage the git shim, a link named 'git' pointing to GAS.
+
+When the shim directory comes before the real git in your PATH, every git command
+goes through GAS. Commands that create or publish commits are checked against the
+account expected for the repository, everything else is handed to the real git directly.`,
+}
+
+// shimInstallCmd represents the shim install command
+var shimInstallCmd = &cobra.Command{
+	Use:   "install",
+	Short: "Install GAS as a git shim",
+	Run: func(cmd *cobra.Command, args []string) {
+		dir, err := shimDir(cmd)
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+
+		target, err := shim.Executable()
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+
+		path, err := shim.Install(dir, target)
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+
+		fmt.Printf("Installed git shim at '%s'.\n", path)
+		fmt.Println("Add the following line to your .bashrc or .zshrc file to use it:")
+		fmt.Printf("\n    export PATH=\"%s:$PATH\"\n\n", dir)
+	},
+}
+
+// shimUninstallCmd represents the shim uninstall command
+var shimUninstallCmd = &cobra.Command{
+	Use:   "uninstall",
+	Short: "Remove the git shim",
+	Run: func(cmd *cobra.Command, args []string) {
+		dir, err := shimDir(cmd)
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+
+		target, err := shim.Executable()
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+
+		path, err := shim.Uninstall(dir, target)
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+
+		fmt.Printf("Removed git shim '%s'. You can now remove '%s' from your PATH.\n", path, dir)
+	},
+}
+
+// shimDir returns the directory passed with --dir, or the default shim directory.
+func shimDir(cmd *cobra.Command) (string, error) {
+	dir, _ := cmd.Flags().GetString("dir")
+	if dir != "" {
+		return dir, nil
+	}
+
+	return shim.DefaultDir()
+}
+
+// runAsGitShim runs GAS as a drop-in replacement for git and exits.
+func runAsGitShim() {
+	os.Exit(shim.Run(os.Args[1:], func(args []string) (bool, error) {
+		initConfig()
+		return repo.GuardIdentity(args)
+	}))
+}
+
+func init() {
+	rootCmd.AddCommand(shimCmd)
+	shimCmd.AddCommand(shimInstallCmd)
+	shimCmd.AddCommand(shimUninstallCmd)
+
+	shimCmd.PersistentFlags().StringP("dir", "d", "", "Directory to install the shim into. Defaults to ~/.gas/shim.")
+}
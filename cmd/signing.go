@@ -0,0 +1,126 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/style77/gas/internal/accounts"
+	"github.com/style77/gas/internal/git"
+	"github.com/style77/gas/internal/helpers"
+)
+
+// signingCmd represents the signing command
+var signingCmd = &cobra.Command{
+	Use:   "signing",
+	Short: "Configure commit signing for an account",
+	Long: `Configure commit and tag signing with the SSH key or a GPG key of an account.
+
+The settings are applied when switching to the account or setting up a repository with it.
+GAS also keeps an allowed signers file listing every account's email and public key,
+so that 'git log --show-signature' verifies signatures locally.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		signCommits, _ := cmd.Flags().GetBool("commits")
+		signTags, _ := cmd.Flags().GetBool("tags")
+		disable, _ := cmd.Flags().GetBool("disable")
+		format, _ := cmd.Flags().GetString("format")
+		gpgKeyID, _ := cmd.Flags().GetString("gpg-key")
+		gpgProgram, _ := cmd.Flags().GetString("gpg-program")
+
+		account, err := signingAccount(cmd)
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+
+		account.Signing = accounts.SigningConfig{}
+		if !disable && (signCommits || signTags) {
+			account.Signing = accounts.SigningConfig{
+				Format:      accounts.SSHSigningFormat,
+				SignCommits: signCommits,
+				SignTags:    signTags,
+			}
+
+			switch format {
+			case "ssh":
+			case "gpg", accounts.GPGSigningFormat:
+				account.Signing.Format = accounts.GPGSigningFormat
+				account.Signing.GPGProgram = gpgProgram
+				account.Signing.GPGKeyID = gpgKeyID
+				if gpgKeyID == "" {
+					var err error
+					account.Signing.GPGKeyID, err = accounts.PromptForGPGKey(account.Email, gpgProgram)
+					if err != nil {
+						fmt.Println(err)
+						return
+					}
+					if account.Signing.GPGKeyID == "" {
+						return
+					}
+				}
+
+			default:
+				fmt.Printf("Unsupported signing format '%s'. Use 'ssh' or 'gpg'.\n", format)
+				return
+			}
+		}
+
+		if err := account.Save(); err != nil {
+			fmt.Printf("Failed to save account '%s'. Error: %s\n", account.Handle, err)
+			return
+		}
+
+		allowedSignersPath, err := accounts.AllowedSignersPath()
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		if err := accounts.WriteAllowedSigners(allowedSignersPath, accounts.GetAccounts()); err != nil {
+			fmt.Println(err)
+			return
+		}
+
+		if git.IsCurrentGlobal(account.Email) {
+			if err := account.ConfigureSigning(git.GlobalScope); err != nil {
+				fmt.Println(err)
+				return
+			}
+		} else if account.Signing.Format == accounts.GPGSigningFormat {
+			for _, warning := range account.GPGKeyWarnings(helpers.RealCommandExecutor{}, time.Now()) {
+				fmt.Println("Warning:", warning)
+			}
+		}
+
+		if account.Signing.Enabled() {
+			fmt.Printf("Enabled %s signing for account '%s'.\n", account.Signing.Format, account.Handle)
+		} else {
+			fmt.Printf("Disabled signing for account '%s'.\n", account.Handle)
+		}
+	},
+}
+
+// signingAccount returns the account of the --account flag, or lets the user pick one.
+func signingAccount(cmd *cobra.Command) (accounts.Account, error) {
+	if accountRaw, _ := cmd.Flags().GetString("account"); accountRaw != "" {
+		return accounts.ResolveAccount(accountRaw)
+	}
+
+	account, err := accounts.InteractiveSelectAccount()
+	if err == nil && account.Handle == "" {
+		err = fmt.Errorf("no account selected")
+	}
+
+	return account, err
+}
+
+func init() {
+	rootCmd.AddCommand(signingCmd)
+
+	signingCmd.Flags().StringP("account", "a", "", "Account to configure signing for.")
+	signingCmd.Flags().Bool("commits", true, "Sign commits.")
+	signingCmd.Flags().Bool("tags", true, "Sign tags.")
+	signingCmd.Flags().Bool("disable", false, "Disable signing for the account.")
+	signingCmd.Flags().StringP("format", "f", "ssh", "Signature format, 'ssh' to sign with the account's SSH key or 'gpg'.")
+	signingCmd.Flags().String("gpg-key", "", "ID of the gpg key to sign with. You will be prompted to select one if not provided.")
+	signingCmd.Flags().String("gpg-program", "", "gpg program used for signing. Defaults to git's gpg.program.")
+}
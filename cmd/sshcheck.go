@@ -0,0 +1,92 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/style77/gas/internal/accounts"
+	"github.com/style77/gas/internal/sshconfig"
+)
+
+// sshCheckCmd represents the ssh-check command
+var sshCheckCmd = &cobra.Command{
+	Use:   "ssh-check [account]",
+	Short: "Find SSH config entries that make ssh use another key than the account's",
+	Long: `Check the SSH alias of every account, or only the provided one, for settings that can
+make ssh authenticate as the wrong user: a missing 'IdentitiesOnly yes', and IdentityFiles
+of other blocks such as 'Host *' or 'Host github.com' that are offered before the account's key.
+
+With --fix, 'IdentitiesOnly yes' is added to the aliases missing it. Conflicting IdentityFiles
+are only reported, as they may be used by other hosts.`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		fix, _ := cmd.Flags().GetBool("fix")
+
+		accountList := accounts.GetAccounts()
+		if len(args) > 0 {
+			account, err := accounts.ResolveAccount(args[0])
+			if err != nil {
+				fmt.Println(err)
+				return
+			}
+			accountList = []accounts.Account{account}
+		}
+
+		config, err := sshconfig.Load(sshconfig.DefaultPath())
+		if err != nil {
+			fmt.Printf("Could not read SSH config file: %v\n", err)
+			return
+		}
+
+		patched, conflicting := false, false
+		for _, account := range accountList {
+			if account.SSHAlias == "" {
+				fmt.Printf("Account '%s' has no SSH alias, skipping.\n", account.Handle)
+				continue
+			}
+			if config.FindHost(account.SSHAlias) == nil {
+				fmt.Printf("Alias '%s' of account '%s' is missing from the SSH config.\n", account.SSHAlias, account.Handle)
+				continue
+			}
+
+			ok := true
+			if !config.Resolve(account.SSHAlias).IdentitiesOnly {
+				ok = false
+				if fix {
+					if err := config.SetOption(account.SSHAlias, "IdentitiesOnly", "yes"); err != nil {
+						fmt.Println(err)
+						return
+					}
+					patched = true
+					fmt.Printf("Added 'IdentitiesOnly yes' to alias '%s'.\n", account.SSHAlias)
+				} else {
+					fmt.Printf("Alias '%s' doesn't set 'IdentitiesOnly yes', so keys in ssh-agent are offered first. Run with --fix to add it.\n", account.SSHAlias)
+				}
+			}
+
+			if accounts.PrintIdentityConflicts(config, account.SSHAlias, account.SSHKeyPath) {
+				ok, conflicting = false, true
+			}
+
+			if ok {
+				fmt.Printf("Alias '%s' of account '%s' only offers '%s'.\n", account.SSHAlias, account.Handle, account.SSHKeyPath)
+			}
+		}
+
+		if conflicting {
+			fmt.Printf("\n%s\n", sshconfig.ResolutionOrder)
+		}
+
+		if patched {
+			if err := config.Save(); err != nil {
+				fmt.Println(err)
+			}
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(sshCheckCmd)
+
+	sshCheckCmd.Flags().Bool("fix", false, "Add 'IdentitiesOnly yes' to the aliases missing it.")
+}
@@ -2,9 +2,12 @@ package cmd
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 	"github.com/style77/gas/internal/accounts"
+	"github.com/style77/gas/internal/sshagent"
 )
 
 // switchCmd represents the switch command
@@ -13,7 +16,8 @@ var switchCmd = &cobra.Command{
 	Short: "Switch between GitHub accounts",
 	Long: `Switch to a different GitHub account configured on this machine.
 	
-You can specify the account name with the --account flag,
+You can specify the account with the --account flag, by its handle, ID, email,
+SSH alias or a unique part of them,
 or select it interactively if no account is specified.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		accountRaw, _ := cmd.Flags().GetString("account")
@@ -28,25 +32,55 @@ or select it interactively if no account is specified.`,
 			}
 		} else {
 			var err error
-			account, err = accounts.GetAccount(accountRaw)
+			account, err = accounts.ResolveAccount(accountRaw)
 			if err != nil {
 				fmt.Println(err)
 				return
 			}
 		}
 
-		if account.Name == "" {
+		if account.Handle == "" {
 			fmt.Println("No account selected.")
 			return
 		}
 
-		account.SetGlobal()
-		fmt.Printf("Switched to account '%s'.\n", account.Name)
+		err := account.SetGlobal()
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+
+		fmt.Printf("Switched to account '%s', committing as '%s <%s>'.\n", account.Handle, account.Name, account.Email)
+		if account.Signing.Enabled() {
+			fmt.Printf("Commits are signed with the account's %s key.\n", account.Signing.Format)
+		}
+		description, warning := account.CertificateStatus(time.Now())
+		if description != "" {
+			fmt.Printf("Authenticating with certificate '%s' for %s.\n", account.CertificateFile, description)
+		}
+		if warning != "" {
+			fmt.Println("Warning:", warning)
+		}
+
+		addToAgent := viper.GetBool(agentAddOnSwitchKey)
+		if cmd.Flags().Changed("agent") {
+			addToAgent, _ = cmd.Flags().GetBool("agent")
+		}
+		if addToAgent {
+			options := sshagent.AddOptions{
+				Lifetime: viper.GetDuration(agentLifetimeKey),
+				Confirm:  viper.GetBool(agentConfirmKey),
+			}
+			if err := addAccountKeyToAgent(account, options, viper.GetBool(agentRemoveOthersKey)); err != nil {
+				fmt.Println(err)
+			}
+		}
 	},
 }
 
 func init() {
 	rootCmd.AddCommand(switchCmd)
 
-	switchCmd.Flags().StringP("account", "a", "", "Account to switch to. This should be the name of the account you wish to switch to.")
+	switchCmd.Flags().StringP("account", "a", "", "Account to switch to, by handle, ID, email, SSH alias or a unique part of them.")
+	switchCmd.Flags().Bool("agent", false, "Add the account's key to ssh-agent. Defaults to agent.addonswitch.")
 }
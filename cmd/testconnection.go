@@ -0,0 +1,128 @@
+package cmd
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/style77/gas/internal/accounts"
+	"github.com/style77/gas/internal/sshauth"
+	"github.com/style77/gas/internal/sshconfig"
+)
+
+// testConnectionCmd represents the test-connection command
+var testConnectionCmd = &cobra.Command{
+	Use:   "test-connection <account>",
+	Short: "Check the forge can be reached with the transport of an account",
+	Long: `Check that the forge can be reached over SSH the way the account's alias is configured,
+including a port, ssh.github.com:443, ProxyJump, ProxyCommand and ControlMaster set with
+'gas transport'.
+
+The host and port are first checked with a plain TCP connection, unless ssh connects through
+a proxy. Then ssh itself connects through the alias in batch mode, with the SSH config gas
+manages, and the user the forge authenticates as is reported. Exits with a non-zero status
+if the forge can't be reached.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		timeout, _ := cmd.Flags().GetDuration("timeout")
+
+		account, err := accounts.ResolveAccount(args[0])
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+
+		config, err := sshconfig.Load(sshconfig.DefaultPath())
+		if err != nil {
+			fmt.Printf("Could not read SSH config file: %v\n", err)
+			return
+		}
+
+		alias := account.SSHAlias
+		if alias == "" {
+			alias = account.ForgeHost()
+		}
+		resolved := config.Resolve(alias)
+		target := sshauth.ResolveTarget(resolved)
+		address := net.JoinHostPort(target.Host, strconv.Itoa(target.Port))
+
+		fmt.Printf("Testing account '%s' through alias '%s': %s.\n", account.Handle, alias, describeResolvedTransport(resolved))
+
+		if proxy := resolvedProxy(resolved); proxy != "" {
+			fmt.Printf("Skipping the TCP check of %s, ssh connects through %s.\n", address, proxy)
+		} else {
+			start := time.Now()
+			conn, err := net.DialTimeout("tcp", address, timeout)
+			if err != nil {
+				fmt.Printf("Could not reach %s: %v\n", address, err)
+				if _, ok := accounts.Port443Host(target.Host); ok && target.Port == 22 {
+					fmt.Printf("If port 22 is blocked on this network, try 'gas transport %s --port443'.\n", account.Handle)
+				}
+				os.Exit(1)
+			}
+			conn.Close()
+			fmt.Printf("Reached %s in %s.\n", address, time.Since(start).Round(time.Millisecond))
+		}
+
+		output, err := exec.Command("ssh", "-T", "-F", config.Path,
+			"-o", "BatchMode=yes",
+			"-o", "ConnectTimeout="+strconv.Itoa(int(timeout.Seconds())),
+			target.User+"@"+alias,
+		).CombinedOutput()
+		// forges close the session right after the greeting, usually with a non-zero exit status
+		login, greetingErr := sshauth.ParseGreeting(string(output))
+		if greetingErr != nil {
+			fmt.Printf("ssh could not authenticate through alias '%s': %s\n", alias, strings.TrimSpace(string(output)))
+			if err != nil {
+				fmt.Println(err)
+			}
+			os.Exit(1)
+		}
+
+		if login == "" {
+			fmt.Printf("ssh authenticated through alias '%s'.\n", alias)
+			return
+		}
+
+		fmt.Printf("ssh authenticated as '%s' through alias '%s'.\n", login, alias)
+		if account.Login != "" && !strings.EqualFold(login, account.Login) {
+			fmt.Printf("Warning: this does not match account '%s'. Run 'gas whoami %s' to check the key.\n", account.Handle, account.Handle)
+		}
+	},
+}
+
+// resolvedProxy returns the jump host or proxy command ssh connects through, if any.
+func resolvedProxy(resolved sshconfig.Resolved) string {
+	if jump := resolved.Options["proxyjump"]; jump != "" && !strings.EqualFold(jump, "none") {
+		return "jump host " + jump
+	}
+	if command := resolved.Options["proxycommand"]; command != "" && !strings.EqualFold(command, "none") {
+		return "proxy command '" + command + "'"
+	}
+
+	return ""
+}
+
+// describeResolvedTransport describes how ssh connects for the resolved alias.
+func describeResolvedTransport(resolved sshconfig.Resolved) string {
+	description := net.JoinHostPort(resolved.HostName, strconv.Itoa(resolved.Port))
+	if proxy := resolvedProxy(resolved); proxy != "" {
+		description += " via " + proxy
+	}
+	if master := resolved.Options["controlmaster"]; master != "" && !strings.EqualFold(master, "no") {
+		description += " with multiplexing"
+	}
+
+	return description
+}
+
+func init() {
+	rootCmd.AddCommand(testConnectionCmd)
+
+	testConnectionCmd.Flags().Duration("timeout", sshauth.DefaultTimeout, "How long connecting may take.")
+}
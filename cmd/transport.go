@@ -0,0 +1,125 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/style77/gas/internal/accounts"
+	"github.com/style77/gas/internal/sshconfig"
+)
+
+// transportCmd represents the transport command
+var transportCmd = &cobra.Command{
+	Use:   "transport <account>",
+	Short: "Configure how ssh reaches the forge for an account",
+	Long: `Configure how ssh connects to the forge for an account and render it into the account's
+SSH alias. Without flags, the current transport is printed.
+
+  --port443        connect to ssh.github.com on port 443, for networks blocking port 22
+  --port           connect on a custom port
+  --proxy-jump     connect through a jump host, like 'ssh -J'
+  --proxy-command  connect through a command, e.g. 'nc -X connect -x proxy:8080 %h %p'
+  --control-master share one connection between git operations, kept open for --control-persist
+  --direct         connect directly again, removing the port and proxy settings
+
+The account is given by handle, ID, email, SSH alias or a unique prefix of them, never by a fuzzy match.
+Use 'gas test-connection <account>' to check the forge can be reached with it.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		account, err := accounts.ResolveAccountStrictly(args[0])
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+
+		flags := cmd.Flags()
+		if flags.NFlag() == 0 {
+			fmt.Printf("Transport of account '%s': %s.\n", account.Handle, account.Transport)
+			return
+		}
+
+		transport := account.Transport
+		if direct, _ := flags.GetBool("direct"); direct {
+			transport.Port443, transport.Port, transport.ProxyJump, transport.ProxyCommand = false, 0, "", ""
+		}
+		if flags.Changed("port443") {
+			transport.Port443, _ = flags.GetBool("port443")
+			transport.Port = 0
+		}
+		if flags.Changed("port") {
+			transport.Port, _ = flags.GetInt("port")
+			transport.Port443 = false
+		}
+		if flags.Changed("proxy-jump") {
+			transport.ProxyJump, _ = flags.GetString("proxy-jump")
+			transport.ProxyCommand = ""
+		}
+		if flags.Changed("proxy-command") {
+			transport.ProxyCommand, _ = flags.GetString("proxy-command")
+			transport.ProxyJump = ""
+		}
+		if flags.Changed("control-master") {
+			transport.ControlMaster, _ = flags.GetBool("control-master")
+		}
+		if flags.Changed("control-persist") {
+			transport.ControlPersist, _ = flags.GetString("control-persist")
+			transport.ControlMaster = true
+		}
+
+		if err := transport.Validate(); err != nil {
+			fmt.Println(err)
+			return
+		}
+
+		account.Transport = transport
+		if account.SSHAlias == "" {
+			if err := account.Save(); err != nil {
+				fmt.Printf("Failed to save account '%s'. Error: %s\n", account.Handle, err)
+				return
+			}
+			fmt.Printf("Set transport '%s' for account '%s', but it has no SSH alias to render it into.\n", transport, account.Handle)
+			return
+		}
+
+		config, err := sshconfig.Load(sshconfig.DefaultPath())
+		if err != nil {
+			fmt.Printf("Could not read SSH config file: %v\n", err)
+			return
+		}
+		if config.FindHost(account.SSHAlias) == nil {
+			if err := account.Save(); err != nil {
+				fmt.Printf("Failed to save account '%s'. Error: %s\n", account.Handle, err)
+				return
+			}
+			fmt.Printf("Set transport '%s' for account '%s', but alias '%s' is missing from the SSH config. Run 'gas doctor --fix' to add it.\n", transport, account.Handle, account.SSHAlias)
+			return
+		}
+
+		if err := accounts.ApplyTransport(config, account.SSHAlias, transport); err != nil {
+			fmt.Println(err)
+			return
+		}
+		if err := account.Save(); err != nil {
+			fmt.Printf("Failed to save account '%s'. Error: %s\n", account.Handle, err)
+			return
+		}
+		if err := config.Save(); err != nil {
+			fmt.Println(err)
+			return
+		}
+
+		fmt.Printf("Set transport '%s' for account '%s' in alias '%s'.\n", transport, account.Handle, account.SSHAlias)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(transportCmd)
+
+	transportCmd.Flags().Bool("port443", false, "Connect to ssh.github.com on port 443.")
+	transportCmd.Flags().Int("port", 0, "Connect on a custom port.")
+	transportCmd.Flags().String("proxy-jump", "", "Jump host to connect through, e.g. user@bastion.example.com.")
+	transportCmd.Flags().String("proxy-command", "", "Command to connect through, with ssh's %h and %p tokens.")
+	transportCmd.Flags().Bool("control-master", false, "Share one connection between git operations.")
+	transportCmd.Flags().String("control-persist", "", "How long the shared connection stays open, e.g. 10m. Defaults to "+accounts.DefaultControlPersist+".")
+	transportCmd.Flags().Bool("direct", false, "Connect directly, removing the port and proxy settings.")
+}
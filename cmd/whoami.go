@@ -0,0 +1,153 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/style77/gas/internal/accounts"
+	"github.com/style77/gas/internal/git"
+	"github.com/style77/gas/internal/helpers"
+	"github.com/style77/gas/internal/repo"
+	"github.com/style77/gas/internal/sshauth"
+	"github.com/style77/gas/internal/sshconfig"
+	"golang.org/x/crypto/ssh"
+)
+
+// whoamiCmd represents the whoami command
+var whoamiCmd = &cobra.Command{
+	Use:   "whoami [account]",
+	Short: "Verify which user the forge authenticates an account's key as",
+	Long: `Connect to the forge over SSH using exactly the key of the account and report
+the user the forge authenticates it as, and whether it matches the account.
+
+The host, port and user are taken from the account's SSH alias and can be overridden
+with --host, --port and --user. Without an account argument, the account expected for the
+current repository or the current global account is checked.`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		account, err := whoamiAccount(args)
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+
+		var override sshauth.Target
+		override.Host, _ = cmd.Flags().GetString("host")
+		override.Port, _ = cmd.Flags().GetInt("port")
+		override.User, _ = cmd.Flags().GetString("user")
+		knownHosts, _ := cmd.Flags().GetStringSlice("known-hosts")
+
+		target, result, err := authenticateAccount(account, account.SSHKeyPath, override, knownHosts)
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+
+		if result.Login == "" {
+			fmt.Printf("%s authenticated key '%s', but doesn't tell which user it belongs to.\n", target, account.SSHKeyPath)
+			return
+		}
+
+		fmt.Printf("%s authenticated key '%s' as '%s'.\n", target, account.SSHKeyPath, result.Login)
+		if account.Login == "" {
+			fmt.Printf("Account '%s' has no forge login to compare it with.\n", account.Handle)
+		} else if strings.EqualFold(result.Login, account.Login) {
+			fmt.Printf("This matches account '%s'.\n", account.Handle)
+		} else {
+			fmt.Printf("Warning: this does not match account '%s'. Pushes with this key act as '%s'.\n", account.Handle, result.Login)
+		}
+	},
+}
+
+// authenticateAccount connects to the forge of the account using only the key at keyPath and returns the login
+// it authenticates as. Non-zero fields of override replace the host, port and user taken from the account's alias.
+func authenticateAccount(account accounts.Account, keyPath string, override sshauth.Target, knownHosts []string) (sshauth.Target, sshauth.Result, error) {
+	config, err := sshconfig.Load(sshconfig.DefaultPath())
+	if err != nil {
+		return sshauth.Target{}, sshauth.Result{}, fmt.Errorf("could not read SSH config file: %w", err)
+	}
+
+	alias := account.SSHAlias
+	if alias == "" {
+		alias = account.ForgeHost()
+	}
+	resolved := config.Resolve(alias)
+	target := sshauth.ResolveTarget(resolved)
+
+	if override.Host != "" {
+		target.Host = override.Host
+	}
+	if override.Port != 0 {
+		target.Port = override.Port
+	}
+	if override.User != "" {
+		target.User = override.User
+	}
+
+	expandedPath, err := helpers.ExpandPath(keyPath)
+	if err != nil {
+		return target, sshauth.Result{}, err
+	}
+
+	signer, err := sshauth.LoadSigner(expandedPath, keyPassphrasePrompt(account, keyPath))
+	if err != nil {
+		return target, sshauth.Result{}, err
+	}
+
+	if account.CertificateFile != "" {
+		cert, err := helpers.ReadCertificate(account.CertificateFile)
+		if err != nil {
+			return target, sshauth.Result{}, err
+		}
+		signer, err = ssh.NewCertSigner(cert, signer)
+		if err != nil {
+			return target, sshauth.Result{}, err
+		}
+	}
+
+	expandedKnownHosts := make([]string, len(knownHosts))
+	for i := range knownHosts {
+		expandedKnownHosts[i], _ = helpers.ExpandPath(knownHosts[i])
+	}
+	hostKeyCallback, err := sshauth.KnownHostsCallback(expandedKnownHosts...)
+	if err != nil {
+		return target, sshauth.Result{}, err
+	}
+	hostKeyCallback = sshauth.WithHostKeyAlias(hostKeyCallback, resolved.Options["hostkeyalias"])
+
+	result, err := sshauth.WhoAmI(target, signer, hostKeyCallback)
+	return target, result, err
+}
+
+// whoamiAccount returns the account to check: the provided one, the one expected for the repository or the global one.
+func whoamiAccount(args []string) (accounts.Account, error) {
+	if len(args) > 0 {
+		return accounts.ResolveAccount(args[0])
+	}
+
+	if expected := repo.ResolveIdentity(repo.DefaultRemote()).ExpectedAccount; expected != nil {
+		return *expected, nil
+	}
+
+	if account, ok := accounts.GetAccountByEmail(git.GetCurrentGlobal()); ok {
+		return account, nil
+	}
+
+	account, err := accounts.InteractiveSelectAccount()
+	if err == nil && account.Handle == "" {
+		err = fmt.Errorf("no account selected")
+	}
+
+	return account, err
+}
+
+func init() {
+	rootCmd.AddCommand(whoamiCmd)
+
+	whoamiCmd.Flags().String("host", "", "Host to connect to. Defaults to the HostName of the account's SSH alias.")
+	whoamiCmd.Flags().Int("port", 0, "Port to connect to. Defaults to the Port of the account's SSH alias or 22.")
+	whoamiCmd.Flags().String("user", "", "User to connect as. Defaults to the User of the account's SSH alias or 'git'.")
+	whoamiCmd.Flags().StringSlice("known-hosts", []string{filepath.Join("~", ".ssh", "known_hosts")}, "known_hosts files used to verify the host key.")
+}
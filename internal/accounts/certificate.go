@@ -0,0 +1,81 @@
+package accounts
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/style77/gas/internal/helpers"
+)
+
+// CertificateStatus describes the certificate of the account and why it can't be used soon.
+// Both are empty for accounts without a certificate.
+func (a *Account) CertificateStatus(now time.Time) (string, string) {
+	if a.CertificateFile == "" {
+		return "", ""
+	}
+
+	cert, err := helpers.ReadCertificate(a.CertificateFile)
+	if err != nil {
+		return "", err.Error()
+	}
+
+	return helpers.DescribeCertificate(cert, now), helpers.CertificateWarning(cert, now)
+}
+
+// promptForCertificate asks whether the account authenticates with an SSH certificate of the provided key.
+func promptForCertificate(sshKeyPath string) (string, error) {
+	defaultPath := sshKeyPath + "-cert.pub"
+	expandedPath, _ := helpers.ExpandPath(defaultPath)
+	_, err := helpers.ReadCertificate(expandedPath)
+	hasDefault := err == nil
+
+	useCertificate := hasDefault
+	err = survey.AskOne(&survey.Confirm{
+		Message: "Does this account authenticate with an SSH certificate issued by a certificate authority?",
+		Default: hasDefault,
+	}, &useCertificate)
+	if err != nil || !useCertificate {
+		return "", err
+	}
+
+	var certificateFile string
+	err = survey.AskOne(&survey.Input{
+		Message: "What is the path to the certificate?",
+		Default: defaultPath,
+	}, &certificateFile, survey.WithValidator(func(answer interface{}) error {
+		path, _ := answer.(string)
+		return certificateMatchesKey(path, sshKeyPath)
+	}))
+	if err != nil {
+		return "", err
+	}
+
+	cert, _ := helpers.ReadCertificate(certificateFile)
+	fmt.Printf("Certificate for %s.\n", helpers.DescribeCertificate(cert, time.Now()))
+	if warning := helpers.CertificateWarning(cert, time.Now()); warning != "" {
+		fmt.Println("Warning:", warning)
+	}
+
+	return certificateFile, nil
+}
+
+// certificateMatchesKey checks that the certificate at path certifies the provided key.
+func certificateMatchesKey(path, sshKeyPath string) error {
+	cert, err := helpers.ReadCertificate(path)
+	if err != nil {
+		return err
+	}
+
+	publicKey, err := helpers.ReadPublicKey(sshKeyPath)
+	if err != nil {
+		return err
+	}
+
+	if !bytes.Equal(cert.Key.Marshal(), publicKey.Marshal()) {
+		return fmt.Errorf("certificate '%s' was not issued for key '%s'", path, sshKeyPath)
+	}
+
+	return nil
+}
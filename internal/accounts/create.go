@@ -3,55 +3,112 @@ package accounts
 import (
 	"fmt"
 	"io"
+	"net/url"
 	"os"
 	"path/filepath"
 	"regexp"
 	"runtime"
 	"strings"
+	"time"
 
 	"github.com/AlecAivazis/survey/v2"
 	"github.com/style77/gas/internal/git"
 	"github.com/style77/gas/internal/helpers"
+	"github.com/style77/gas/internal/sshconfig"
 	"golang.org/x/crypto/ssh"
 )
 
-var interactiveAddAccountInvestigationQuestions = []*survey.Question{
-	{
-		Name:     "Email",
-		Prompt:   &survey.Input{Message: "What is email address associated with the github account?"},
-		Validate: isValidEmail,
-	},
-	{
-		Name:     "Name",
-		Prompt:   &survey.Input{Message: "What is the github name you wish to use? It might be your github account username (\"johnDoe98\") or your real name (\"John Doe\")."},
-		Validate: survey.Required,
-	},
+// interactiveAddAccountInvestigationQuestions asks for the email and login of an account on the named forge.
+func interactiveAddAccountInvestigationQuestions(forgeName string) []*survey.Question {
+	return []*survey.Question{
+		{
+			Name:     "Email",
+			Prompt:   &survey.Input{Message: fmt.Sprintf("What is email address associated with the %s account?", forgeName)},
+			Validate: isValidEmail,
+		},
+		{
+			Name:     "Login",
+			Prompt:   loginPrompt(forgeName),
+			Validate: isValidLogin,
+		},
+	}
+}
+
+// loginPrompt asks for the username of the account on the named forge.
+func loginPrompt(forgeName string) *survey.Input {
+	return &survey.Input{Message: fmt.Sprintf("What is your %s username (e.g., \"johnDoe98\")? Leave it empty if you don't know it, GAS won't verify SSH keys then.", forgeName)}
+}
+
+// promptForNameAndHandle asks for the git name of commits made with the account and the handle it's referred to by.
+func promptForNameAndHandle(login string) (string, string, error) {
+	var name, handle string
+	err := survey.AskOne(&survey.Input{Message: "What name should commits made with this account use (e.g., \"John Doe\")?", Default: login}, &name, survey.WithValidator(survey.Required))
+	if err != nil {
+		return "", "", err
+	}
+
+	err = survey.AskOne(&survey.Input{Message: "What short handle do you want to refer to this account by (e.g., \"work\")?", Default: suggestHandle(login)}, &handle, survey.WithValidator(isValidHandle))
+	return strings.TrimSpace(name), strings.ToLower(strings.TrimSpace(handle)), err
 }
 
 // InteractiveNewAccount prompts the user for information to add a new account.
 func InteractiveNewAccount() {
+	forge, err := promptForForge()
+	if err != nil {
+		fmt.Println(err.Error())
+		return
+	}
+	provider, host := forge.ForgeProvider(), forge.ForgeHost()
+	forgeName := git.ProviderName(provider)
+
 	investigationAnswers := struct {
 		Email string
-		Name  string
+		Login string
 	}{}
 
-	err := survey.Ask(interactiveAddAccountInvestigationQuestions, &investigationAnswers)
+	err = survey.Ask(interactiveAddAccountInvestigationQuestions(forgeName), &investigationAnswers)
 
 	if err != nil {
 		fmt.Println(err.Error())
 		return
 	}
 
-	githubClient := &git.RealGitHubClient{}
+	name, handle, err := promptForNameAndHandle(investigationAnswers.Login)
+	if err != nil {
+		fmt.Println(err.Error())
+		return
+	}
 
-	err, isExistingGithubAccount := validateAndPromptForName(&investigationAnswers.Name, githubClient.IsGithubUsernameValid)
+	// The token of the account, if already configured, lets GAS look up Bitbucket users.
+	forge.Handle = handle
+	githubClient, err := forge.Forge(host)
 	if err != nil {
 		fmt.Println(err.Error())
 		return
 	}
 
-	if !isExistingGithubAccount {
-		fmt.Println("You have chosen to use a name that is not a valid GitHub username. Keep in mind GAS won't be able to verify SSH keys for this account.")
+	bitbucket, isBitbucket := githubClient.(*git.BitbucketClient)
+	if isBitbucket && bitbucket.Token == "" {
+		bitbucket.Token, err = promptForBitbucketCredentials()
+		if err != nil {
+			fmt.Println(err.Error())
+			return
+		}
+	}
+
+	var isExistingGithubAccount bool
+	if isBitbucket && bitbucket.Token == "" {
+		fmt.Printf("Keep in mind GAS won't be able to verify SSH keys for this account. Set %s or run 'gas secret set token/%s' to let it.\n", forge.TokenEnvVar(), forge.Handle)
+	} else {
+		err, isExistingGithubAccount = validateAndPromptForLogin(&investigationAnswers.Login, forgeName, githubClient.IsGithubUsernameValid)
+		if err != nil {
+			fmt.Println(err.Error())
+			return
+		}
+
+		if !isExistingGithubAccount {
+			fmt.Printf("You have chosen not to use a valid %s username. Keep in mind GAS won't be able to verify SSH keys for this account.\n", forgeName)
+		}
 	}
 
 	var SSHKeyExists bool
@@ -61,7 +118,7 @@ func InteractiveNewAccount() {
 		return
 	}
 
-	var SSHKeyPath string
+	var SSHKeyPath, certificateFile, token string
 	if SSHKeyExists {
 		err = survey.AskOne(
 			&survey.Input{
@@ -82,8 +139,16 @@ func InteractiveNewAccount() {
 			return
 		}
 
-		if isExistingGithubAccount {
-			isValid, err := isValidSSHKeyForGitHub(SSHKeyPath, investigationAnswers.Name, githubClient)
+		certificateFile, err = promptForCertificate(SSHKeyPath)
+		if err != nil {
+			fmt.Println(err.Error())
+			return
+		}
+
+		if certificateFile != "" {
+			fmt.Println("Keys authenticating with a certificate are not registered on the forge, so GAS won't check them against the account.")
+		} else if isExistingGithubAccount {
+			isValid, err := isValidSSHKeyForGitHub(SSHKeyPath, investigationAnswers.Login, githubClient)
 			if err != nil {
 				fmt.Println(err.Error())
 				return
@@ -91,10 +156,22 @@ func InteractiveNewAccount() {
 
 			if !isValid {
 				fmt.Println("The key you provided is not associated with the account you are trying to add.")
-				return
+				token, err = promptForUploadToken(handle, provider, "the public key")
+				if err != nil {
+					fmt.Println(err.Error())
+					return
+				}
+				if token == "" {
+					return
+				}
+				if err := uploadPublicKey(githubClient, githubClient, token, investigationAnswers.Login, SSHKeyPath); err != nil {
+					fmt.Println(err.Error())
+					return
+				}
+				fmt.Printf("Uploaded the key to %s.\n", forgeName)
 			}
 		} else {
-			fmt.Println("Since the name you provided is not a valid GitHub username, GAS cannot verify the key you provided. Continuing with the account creation process.")
+			fmt.Printf("Since GAS can't look up the account on %s, it cannot verify the key you provided. Continuing with the account creation process.\n", forgeName)
 		}
 	} else {
 		SSHKeyPath, err = helpers.GenerateSSHKey(investigationAnswers.Email, helpers.RealCommandExecutor{})
@@ -102,21 +179,226 @@ func InteractiveNewAccount() {
 			fmt.Println(err.Error())
 			return
 		}
+
+		if isExistingGithubAccount {
+			token, err = promptForUploadToken(handle, provider, "the new public key")
+			if err != nil {
+				fmt.Println(err.Error())
+				return
+			}
+			if token != "" {
+				if err := uploadPublicKey(githubClient, githubClient, token, investigationAnswers.Login, SSHKeyPath); err != nil {
+					fmt.Printf("%s\nAdd the key in '%s.pub' to your %s account by hand.\n", err, SSHKeyPath, forgeName)
+				} else {
+					fmt.Printf("Uploaded the key to %s.\n", forgeName)
+				}
+			}
+		}
 	}
 
-	sshAlias := handleSSHConfig(SSHKeyPath)
+	transport, err := promptForTransport(host)
+	if err != nil {
+		fmt.Println(err.Error())
+		return
+	}
+
+	sshAlias := handleSSHConfig(host, SSHKeyPath, certificateFile, transport)
+
+	signing, err := promptForSigning(investigationAnswers.Email)
+	if err != nil {
+		fmt.Println(err.Error())
+		return
+	}
+
+	if signing.Format == SSHSigningFormat && isExistingGithubAccount {
+		if token == "" {
+			token, err = promptForUploadToken(handle, provider, "the key as a signing key")
+			if err != nil {
+				fmt.Println(err.Error())
+				return
+			}
+		}
+		if token != "" {
+			if err := uploadSigningKey(githubClient, token, SSHKeyPath); err != nil {
+				fmt.Printf("%s\nAdd the key in '%s.pub' to your %s account as a signing key by hand.\n", err, SSHKeyPath, forgeName)
+			} else {
+				fmt.Printf("Uploaded the signing key to %s.\n", forgeName)
+			}
+		}
+	}
 
 	account := Account{
-		Email:      investigationAnswers.Email,
-		Name:       investigationAnswers.Name,
-		SSHKeyPath: SSHKeyPath,
-		SSHAlias:   sshAlias,
+		Handle:          handle,
+		Name:            name,
+		Login:           investigationAnswers.Login,
+		Email:           investigationAnswers.Email,
+		SSHKeyPath:      SSHKeyPath,
+		SSHAlias:        sshAlias,
+		Signing:         signing,
+		CertificateFile: certificateFile,
+		Transport:       transport,
+		Provider:        forge.Provider,
+		Host:            forge.Host,
+		BaseURL:         forge.BaseURL,
 	}
 	SaveAccountToConfig(account)
 }
 
-// handleSSHConfig handles the SSH configuration for the provided SSH key path.
-func handleSSHConfig(sshKeyPath string) string {
+// promptForSigning asks the user whether and how commits and tags of the account should be signed.
+func promptForSigning(email string) (SigningConfig, error) {
+	var signing SigningConfig
+
+	const (
+		noSigning  = "Don't sign commits"
+		sshSigning = "Sign with this SSH key"
+		gpgSigning = "Sign with a GPG key"
+	)
+
+	var choice string
+	err := survey.AskOne(&survey.Select{
+		Message: "Do you want to sign commits made with this account?",
+		Options: []string{noSigning, sshSigning, gpgSigning},
+	}, &choice)
+	if err != nil {
+		return signing, err
+	}
+
+	switch choice {
+	case sshSigning:
+		signing.Format = SSHSigningFormat
+	case gpgSigning:
+		signing.Format = GPGSigningFormat
+		signing.GPGKeyID, err = PromptForGPGKey(email, "")
+		if err != nil {
+			return SigningConfig{}, err
+		}
+		if signing.GPGKeyID == "" {
+			return SigningConfig{}, nil
+		}
+	default:
+		return signing, nil
+	}
+
+	signing.SignCommits = true
+	err = survey.AskOne(&survey.Confirm{Message: "Do you want to sign tags too?", Default: true}, &signing.SignTags)
+	if err != nil {
+		return signing, err
+	}
+
+	return signing, nil
+}
+
+// promptForForge asks which forge the account is on. It returns an account holding only the forge settings.
+func promptForForge() (Account, error) {
+	const (
+		github           = "GitHub (github.com)"
+		gitlab           = "GitLab (gitlab.com)"
+		selfHostedGitLab = "Self-hosted GitLab"
+		codeberg         = "Codeberg (codeberg.org)"
+		selfHostedGitea  = "Self-hosted Gitea or Forgejo"
+		bitbucket        = "Bitbucket (bitbucket.org)"
+	)
+
+	var choice string
+	err := survey.AskOne(&survey.Select{
+		Message: "Which forge is the account on?",
+		Options: []string{github, gitlab, selfHostedGitLab, codeberg, selfHostedGitea, bitbucket},
+	}, &choice)
+	if err != nil {
+		return Account{}, err
+	}
+
+	switch choice {
+	case gitlab:
+		return Account{Provider: git.GitLabProvider}, nil
+	case selfHostedGitLab:
+		var host string
+		err = survey.AskOne(&survey.Input{Message: "What is the host of the GitLab instance (e.g., gitlab.example.com)?"}, &host, survey.WithValidator(isValidHost))
+		return Account{Provider: git.GitLabProvider, Host: strings.ToLower(strings.TrimSpace(host))}, err
+	case codeberg:
+		return Account{Provider: git.GiteaProvider}, nil
+	case selfHostedGitea:
+		var baseURL string
+		err = survey.AskOne(&survey.Input{Message: "What is the address of the instance (e.g., https://git.example.com)?"}, &baseURL, survey.WithValidator(isValidBaseURL))
+		if err != nil {
+			return Account{}, err
+		}
+		baseURL = strings.TrimSuffix(strings.TrimSpace(baseURL), "/")
+		parsed, _ := url.Parse(baseURL)
+		return Account{Provider: git.GiteaProvider, Host: parsed.Hostname(), BaseURL: baseURL}, nil
+	case bitbucket:
+		return Account{Provider: git.BitbucketProvider}, nil
+	default:
+		return Account{Provider: git.GitHubProvider}, nil
+	}
+}
+
+// promptForBitbucketCredentials asks for the credentials GAS looks up Bitbucket users with, as Bitbucket doesn't
+// show them anonymously. An empty value skips the lookup.
+func promptForBitbucketCredentials() (string, error) {
+	var token string
+	err := survey.AskOne(&survey.Password{Message: "Bitbucket only shows users and their keys to authenticated requests. Paste an app password as '<username>:<app password>' or an access token to let GAS verify keys, or leave it empty to skip (it is not stored):"}, &token)
+	return strings.TrimSpace(token), err
+}
+
+// isValidBaseURL checks that the address of a forge instance is an http(s) URL.
+func isValidBaseURL(baseURL interface{}) error {
+	baseURLStr, _ := baseURL.(string)
+	parsed, err := url.Parse(strings.TrimSpace(baseURLStr))
+	if err != nil || parsed.Hostname() == "" || (parsed.Scheme != "https" && parsed.Scheme != "http") {
+		return fmt.Errorf("invalid address, enter a URL like https://git.example.com")
+	}
+
+	return nil
+}
+
+// isValidHost checks that a host name can be used in an SSH config and an API URL.
+func isValidHost(host interface{}) error {
+	hostStr, _ := host.(string)
+	if !regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9.-]*$`).MatchString(strings.TrimSpace(hostStr)) {
+		return fmt.Errorf("invalid host, enter a host name like gitlab.example.com")
+	}
+
+	return nil
+}
+
+// PromptForGPGKey lets the user pick one of the gpg secret keys with a user ID using the provided email.
+// It returns an empty key ID if there are no such keys.
+func PromptForGPGKey(email, program string) (string, error) {
+	keys, err := helpers.ListGPGSecretKeys(program, helpers.RealCommandExecutor{})
+	if err != nil {
+		return "", err
+	}
+
+	keys = helpers.FilterGPGKeysByEmail(keys, email)
+	if len(keys) == 0 {
+		fmt.Printf("No gpg secret keys found for '%s'. Create one with 'gpg --full-generate-key' and configure it with 'gas signing'.\n", email)
+		return "", nil
+	}
+
+	options := make([]string, len(keys))
+	for i, key := range keys {
+		options[i] = key.String()
+		if key.IsExpired(time.Now()) {
+			options[i] += " [expired]"
+		}
+	}
+
+	var selected int
+	err = survey.AskOne(&survey.Select{
+		Message: "Select the gpg key to sign with:",
+		Options: options,
+	}, &selected)
+	if err != nil {
+		return "", err
+	}
+
+	return keys[selected].KeyID, nil
+}
+
+// handleSSHConfig handles the SSH configuration of the forge at host for the provided SSH key path, optional
+// certificate and transport.
+func handleSSHConfig(host, sshKeyPath, certificateFile string, transport TransportConfig) string {
 	var sshAlias string
 	sshConfigPath := filepath.Join(os.Getenv("HOME"), ".ssh", "config")
 
@@ -139,6 +421,9 @@ func handleSSHConfig(sshKeyPath string) string {
 	if existingAlias != "" {
 		sshAlias = existingAlias
 		fmt.Printf("Using existing SSH alias: %s\n", sshAlias)
+		offerIdentitiesOnly(sshConfigPath, sshAlias)
+		setCertificateFile(sshConfigPath, sshAlias, certificateFile)
+		setTransport(sshConfigPath, sshAlias, transport)
 	} else {
 		err = survey.AskOne(&survey.Input{Message: "Enter a unique alias for this SSH key (e.g., github-work):"}, &sshAlias, survey.WithValidator(survey.Required))
 		if err != nil {
@@ -148,10 +433,17 @@ func handleSSHConfig(sshKeyPath string) string {
 
 		newConfigEntry := fmt.Sprintf(`
 Host %s
-    HostName github.com
+    HostName %s
     User git
     IdentityFile %s
-`, sshAlias, sshKeyPath)
+    IdentitiesOnly yes
+`, sshAlias, transport.HostName(host), sshKeyPath)
+		if certificateFile != "" {
+			newConfigEntry += fmt.Sprintf("    CertificateFile %s\n", certificateFile)
+		}
+		for _, option := range transport.Options(host) {
+			newConfigEntry += fmt.Sprintf("    %s %s\n", option.Key, option.Value)
+		}
 
 		if runtime.GOOS == "windows" {
 			newConfigEntry = strings.ReplaceAll(newConfigEntry, "\\", "/")
@@ -173,9 +465,102 @@ Host %s
 		fmt.Printf("Added SSH configuration for alias '%s'.\n", sshAlias)
 	}
 
+	if config, err := sshconfig.Load(sshConfigPath); err == nil {
+		if PrintIdentityConflicts(config, sshAlias, sshKeyPath) {
+			fmt.Printf("\n%s\n", sshconfig.ResolutionOrder)
+		}
+	}
+
 	return sshAlias
 }
 
+// offerIdentitiesOnly offers to add "IdentitiesOnly yes" to an existing alias, so ssh doesn't offer
+// the keys in ssh-agent before the account's key.
+func offerIdentitiesOnly(sshConfigPath, sshAlias string) {
+	config, err := sshconfig.Load(sshConfigPath)
+	if err != nil || config.FindHost(sshAlias) == nil || config.Resolve(sshAlias).IdentitiesOnly {
+		return
+	}
+
+	patch := true
+	err = survey.AskOne(&survey.Confirm{
+		Message: fmt.Sprintf("Alias '%s' doesn't set IdentitiesOnly, so ssh may offer other keys from ssh-agent first. Add 'IdentitiesOnly yes'?", sshAlias),
+		Default: true,
+	}, &patch)
+	if err != nil || !patch {
+		return
+	}
+
+	if err := config.SetOption(sshAlias, "IdentitiesOnly", "yes"); err != nil {
+		fmt.Println(err)
+		return
+	}
+	if err := config.Save(); err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	fmt.Printf("Added 'IdentitiesOnly yes' to alias '%s'.\n", sshAlias)
+}
+
+// setCertificateFile points the CertificateFile of an existing alias at the account's certificate.
+func setCertificateFile(sshConfigPath, sshAlias, certificateFile string) {
+	config, err := sshconfig.Load(sshConfigPath)
+	if err != nil || certificateFile == "" || config.FindHost(sshAlias) == nil {
+		return
+	}
+
+	if current, _ := config.FindHost(sshAlias).Get("CertificateFile"); current == certificateFile {
+		return
+	}
+
+	if err := config.SetOption(sshAlias, "CertificateFile", certificateFile); err != nil {
+		fmt.Println(err)
+		return
+	}
+	if err := config.Save(); err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	fmt.Printf("Set CertificateFile '%s' for alias '%s'.\n", certificateFile, sshAlias)
+}
+
+// setTransport renders the account's transport into an existing alias. A direct transport leaves the alias untouched,
+// as it may use a transport set up by hand.
+func setTransport(sshConfigPath, sshAlias string, transport TransportConfig) {
+	config, err := sshconfig.Load(sshConfigPath)
+	if err != nil || transport.IsZero() || config.FindHost(sshAlias) == nil {
+		return
+	}
+
+	if err := ApplyTransport(config, sshAlias, transport); err != nil {
+		fmt.Println(err)
+		return
+	}
+	if err := config.Save(); err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	fmt.Printf("Set transport '%s' for alias '%s'.\n", transport, sshAlias)
+}
+
+// PrintIdentityConflicts warns about IdentityFiles that ssh may use instead of the account's key.
+func PrintIdentityConflicts(config *sshconfig.Config, sshAlias, sshKeyPath string) bool {
+	conflicts := config.IdentityConflicts(sshAlias, sshKeyPath)
+	if len(conflicts) == 0 {
+		return false
+	}
+
+	fmt.Printf("Warning: other keys may be used instead of '%s' for alias '%s':\n", sshKeyPath, sshAlias)
+	for _, conflict := range conflicts {
+		fmt.Printf("  - %s\n", conflict)
+	}
+
+	return true
+}
+
 // findExistingAlias is a Helper function to find if an alias already exists for the given ssh key path in the SSH config file.
 func findExistingAlias(configContent, sshKeyPath string) string {
 	lines := strings.Split(configContent, "\n")
@@ -227,45 +612,73 @@ func isValidEmail(email interface{}) error {
 	return nil
 }
 
-// validateAndPromptForName checks if the name is valid and prompts the user if necessary.
-func validateAndPromptForName(name *string, validateFunc func(string) error) (error, bool) {
-	if err := isNameValid(*name, validateFunc); err != nil {
-		var wantsToUseName bool
-		err := survey.AskOne(&survey.Confirm{Message: *name + " looks like a Github username, but GAS found out that it might be non-existent or invalid. Would you like to use this name anyway?", Default: true}, &wantsToUseName)
+// validateAndPromptForLogin checks if the login exists on the named forge and prompts the user if necessary.
+// It reports whether the login can be used to verify keys, which an empty login can't.
+func validateAndPromptForLogin(login *string, forgeName string, validateFunc func(string) error) (error, bool) {
+	if *login == "" {
+		return nil, false
+	}
+
+	if err := validateFunc(*login); err != nil {
+		var wantsToUseLogin bool
+		err := survey.AskOne(&survey.Confirm{Message: *login + " looks like a " + forgeName + " username, but GAS found out that it might be non-existent or invalid. Would you like to use this username anyway?", Default: true}, &wantsToUseLogin)
 		if err != nil {
 			return err, false
 		}
 
-		if !wantsToUseName {
-			// Prompt for a new name recursively
-			return promptForNewName(name, validateFunc), false
+		if !wantsToUseLogin {
+			// Prompt for a new login recursively
+			return promptForNewLogin(login, forgeName, validateFunc)
 		}
 	}
 	return nil, true
 }
 
-// promptForNewName prompts the user for a new name and checks validity recursively.
-func promptForNewName(name *string, validateFunc func(string) error) error {
-	err := survey.AskOne(&survey.Input{Message: "What is the GitHub name you wish to use? It might be your GitHub account username (\"johnDoe98\") or your real name (\"John Doe\")."}, name)
+// promptForNewLogin prompts the user for a new login and checks validity recursively.
+func promptForNewLogin(login *string, forgeName string, validateFunc func(string) error) (error, bool) {
+	err := survey.AskOne(loginPrompt(forgeName), login, survey.WithValidator(isValidLogin))
 	if err != nil {
-		return err
+		return err, false
 	}
 
-	// Check the new name validity
-	err, _ = validateAndPromptForName(name, validateFunc)
-	return err
+	// Check the new login validity
+	return validateAndPromptForLogin(login, forgeName, validateFunc)
 }
 
-// isNameValid checks if a username exists on github.
-func isNameValid(name string, validateFunc func(string) error) error {
-	if regexp.MustCompile(`^[a-zA-Z0-9._%+-]+$`).MatchString(name) {
-		return validateFunc(name)
+// isValidLogin checks that a forge login is either empty or looks like a username, not like a display name.
+func isValidLogin(login interface{}) error {
+	loginStr, _ := login.(string)
+	if loginStr != "" && !loginPattern.MatchString(loginStr) {
+		return fmt.Errorf("invalid username, enter the username you log in to the forge with, not your full name")
 	}
 
 	return nil
 }
 
-// isValidSSHKeyForGitHub checks if an ssh key is valid for a github account.
+// handlePattern matches account handles. Dots are not allowed, as they separate the keys of the configuration file.
+var handlePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// isValidHandle checks that a handle can be used as the key of an account in the configuration file.
+func isValidHandle(handle interface{}) error {
+	handleStr, _ := handle.(string)
+	if !handlePattern.MatchString(strings.ToLower(strings.TrimSpace(handleStr))) {
+		return fmt.Errorf("invalid handle, use letters, digits, '-' and '_', like \"work\"")
+	}
+
+	return nil
+}
+
+// suggestHandle derives a handle from the login of an account, or returns an empty string if it can't.
+func suggestHandle(login string) string {
+	handle := strings.ToLower(strings.ReplaceAll(login, ".", "-"))
+	if !handlePattern.MatchString(handle) {
+		return ""
+	}
+
+	return handle
+}
+
+// isValidSSHKeyForGitHub checks if an ssh key is registered for an account on the forge of client.
 func isValidSSHKeyForGitHub(filePath string, username string, client git.GitHubClient) (bool, error) {
 	keyData, err := os.ReadFile(filePath)
 	if err != nil {
@@ -24,3 +24,21 @@ func TestIsValidEmail(t *testing.T) {
 		}
 	}
 }
+
+func TestIsValidHandleAndLogin(t *testing.T) {
+	for handle, valid := range map[string]bool{"work": true, "Work": true, "john_doe-2": true, "john.doe": false, "": false, "-work": false, "jane doe": false} {
+		if err := isValidHandle(handle); (err == nil) != valid {
+			t.Errorf("isValidHandle(%q) = %v, want valid %v", handle, err, valid)
+		}
+	}
+
+	for login, valid := range map[string]bool{"johnDoe98": true, "john.doe": true, "": true, "John Doe": false} {
+		if err := isValidLogin(login); (err == nil) != valid {
+			t.Errorf("isValidLogin(%q) = %v, want valid %v", login, err, valid)
+		}
+	}
+
+	if got := suggestHandle("John.Doe"); got != "john-doe" {
+		t.Errorf("suggestHandle() = %q, want %q", got, "john-doe")
+	}
+}
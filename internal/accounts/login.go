@@ -0,0 +1,47 @@
+package accounts
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/style77/gas/internal/git"
+	"github.com/style77/gas/internal/oauth"
+)
+
+// LogIn authorizes gas for the account with the OAuth device flow and stores the token in the secret store. The
+// token must belong to the account's forge login, which is taken from the token and saved if it's unknown. The code
+// to enter is written to out. It returns the login the token belongs to.
+func (a *Account) LogIn(flow *oauth.DeviceFlow, client git.Forge, out io.Writer) (string, error) {
+	code, err := flow.RequestCode()
+	if err != nil {
+		return "", err
+	}
+	fmt.Fprintf(out, "Open %s and enter the code %s to authorize gas for account '%s'.\n", code.VerificationURI, code.UserCode, a.Handle)
+	fmt.Fprintln(out, "Waiting for the authorization...")
+
+	token, err := flow.PollToken(code)
+	if err != nil {
+		return "", err
+	}
+
+	login, err := client.AuthenticatedUser(token.AccessToken)
+	if err != nil {
+		return "", fmt.Errorf("could not verify the token: %w", err)
+	}
+	if a.Login != "" && !strings.EqualFold(login, a.Login) {
+		return login, fmt.Errorf("the authorization was granted by '%s', not by account '%s'. Log in to GitHub as '%s' and try again", login, a.Handle, a.Login)
+	}
+	if a.Login == "" {
+		a.Login = login
+		if err := a.Save(); err != nil {
+			return login, fmt.Errorf("failed to save the login of account '%s': %w", a.Handle, err)
+		}
+	}
+
+	if err := a.SaveToken(token.AccessToken); err != nil {
+		return login, fmt.Errorf("could not store the token: %w", err)
+	}
+
+	return login, nil
+}
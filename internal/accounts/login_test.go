@@ -0,0 +1,75 @@
+package accounts
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/style77/gas/internal/git"
+	"github.com/style77/gas/internal/git/gittest"
+	"github.com/style77/gas/internal/oauth"
+	"github.com/style77/gas/internal/secrets"
+)
+
+func TestLogIn(t *testing.T) {
+	// The token is stored in the default secrets file of the home directory.
+	t.Setenv("HOME", t.TempDir())
+	defer viper.Reset()
+	configPath := filepath.Join(os.Getenv("HOME"), ".gas.yaml")
+	config := "accounts:\n    work:\n        name: John Doe\n        login: \"\"\n        email: john@acme.com\n        sshkeypath: ~/.ssh/id_work\n        id: 1\n"
+	if err := os.WriteFile(configPath, []byte(config), 0600); err != nil {
+		t.Fatal(err)
+	}
+	viper.SetConfigFile(configPath)
+	if err := viper.ReadInConfig(); err != nil {
+		t.Fatal(err)
+	}
+
+	fake, server := gittest.NewGitHub(t, "john", "gho_device")
+	fake.ClientID = "client"
+	flow := &oauth.DeviceFlow{BaseURL: server.URL, ClientID: "client", Sleep: func(time.Duration) {}}
+	client := &git.RealGitHubClient{BaseURL: server.URL}
+
+	store, err := secrets.Default()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	account, err := GetAccount("work")
+	if err != nil {
+		t.Fatal(err)
+	}
+	account.Login = "jane"
+	if _, err := account.LogIn(flow, client, &bytes.Buffer{}); err == nil || !strings.Contains(err.Error(), "granted by 'john'") {
+		t.Errorf("Expected the token of another user to be rejected, but got: %v", err)
+	}
+	if _, err := store.Get("token/work"); !errors.Is(err, secrets.ErrNotFound) {
+		t.Errorf("Expected no token to be stored for another user, but got: %v", err)
+	}
+
+	account, _ = GetAccount("work")
+	var out bytes.Buffer
+	login, err := account.LogIn(flow, client, &out)
+	if err != nil || login != "john" {
+		t.Fatalf("LogIn() = '%s', %v, want 'john'", login, err)
+	}
+	if !strings.Contains(out.String(), "enter the code ABCD-1234") {
+		t.Errorf("Expected the code to be shown, but got %q", out.String())
+	}
+	if token, err := store.Get("token/work"); err != nil || token != "gho_device" {
+		t.Errorf("Expected the token to be stored under the account's secret name, but got '%s', %v", token, err)
+	}
+	if saved, _ := GetAccount("work"); saved.Login != "john" {
+		t.Errorf("Expected the unknown login to be saved, but got '%s'", saved.Login)
+	}
+
+	flow.ClientID = "other"
+	if _, err := account.LogIn(flow, client, &bytes.Buffer{}); err == nil {
+		t.Errorf("Expected an error for an unknown OAuth app, but got none")
+	}
+}
@@ -2,18 +2,59 @@ package accounts
 
 import (
 	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
 
 	"github.com/AlecAivazis/survey/v2"
 	"github.com/spf13/viper"
 	"github.com/style77/gas/internal/git"
+	"github.com/style77/gas/internal/secrets"
 )
 
 type Account struct {
-	Name       string
+	// Handle is the short name the account is referred to by on the command line, and its key in the configuration file.
+	Handle string
+	// Name is the git user.name of commits made with the account, like "Jane Doe".
+	Name string
+	// Login is the username of the account on the forge, used to verify its keys. Empty if it's unknown.
+	Login      string
 	Email      string
 	SSHKeyPath string
 	SSHAlias   string
 	Id         int
+	Signing    SigningConfig
+	// CertificateFile is the SSH certificate the account authenticates with instead of a key registered on the forge.
+	CertificateFile string
+	Transport       TransportConfig
+	// Provider is the forge the account is on, see git.Providers. Empty means GitHub.
+	Provider string
+	// Host is the host of the forge, empty for the provider's public instance.
+	Host string
+	// BaseURL is the address of the forge's web interface, for instances not served at https://<Host>.
+	BaseURL string
+
+	// legacy is set for accounts saved before the login was separate from the name, which are migrated in memory
+	// until MigrateAccounts or Save writes them back.
+	legacy bool
+}
+
+// SigningConfig holds the commit signing settings of an account.
+type SigningConfig struct {
+	// Format is the git signature format, "ssh" to sign with the account's SSH key
+	// or "openpgp" to sign with GPGKeyID. Empty disables signing.
+	Format      string
+	SignCommits bool
+	SignTags    bool
+	GPGKeyID    string
+	// GPGProgram is the gpg binary used for signing, git's default is used when empty.
+	GPGProgram string
+}
+
+// Enabled reports whether gas should configure signing for the account.
+func (s SigningConfig) Enabled() bool {
+	return s.Format != ""
 }
 
 // SaveAccountToConfig saves the account information to the configuration file.
@@ -21,7 +62,7 @@ func SaveAccountToConfig(account Account) {
 	accounts := viper.GetStringMap("accounts")
 
 	// check if account already exists
-	if _, ok := accounts[account.Name]; ok {
+	if _, ok := accounts[account.Handle]; ok {
 		overwrite := false
 		err := survey.AskOne(&survey.Confirm{
 			Message: "Do you want to overwrite the existing account?",
@@ -32,7 +73,7 @@ func SaveAccountToConfig(account Account) {
 		}
 
 		if !overwrite {
-			fmt.Printf("Account '%s' already exists. Exiting.\n", account.Name)
+			fmt.Printf("Account '%s' already exists. Exiting.\n", account.Handle)
 			return
 		}
 	}
@@ -52,94 +93,334 @@ func SaveAccountToConfig(account Account) {
 
 	account.Id = newAccountID
 
-	accounts[account.Name] = map[string]interface{}{
-		"name":       account.Name,
-		"email":      account.Email,
-		"sshkeypath": account.SSHKeyPath,
-		"sshalias":   account.SSHAlias,
-		"id":         account.Id,
-	}
+	accounts[account.Handle] = account.toMap()
 
 	viper.Set("accounts", accounts)
 
 	err := viper.WriteConfig()
 	if err != nil {
-		fmt.Printf("Failed to save account '%s'. Error: %s\n", account.Name, err)
+		fmt.Printf("Failed to save account '%s'. Error: %s\n", account.Handle, err)
 		return
 	}
 
-	fmt.Printf("Account '%s' added successfully.\n", account.Name)
+	fmt.Printf("Account '%s' added successfully.\n", account.Handle)
 }
 
-func GetAccount(name string) (Account, error) {
+// GetAccount returns the account with the provided handle. Handles are case-insensitive, like the keys of the
+// configuration file. Use ResolveAccount for accounts given on the command line.
+func GetAccount(handle string) (Account, error) {
+	handle = strings.ToLower(handle)
 	accounts := viper.GetStringMap("accounts")
-	account, ok := accounts[name]
+	account, ok := accounts[handle]
 	if !ok {
-		return Account{}, fmt.Errorf("account '%s' not found", name)
+		return Account{}, &NotFoundError{Query: handle}
 	}
 
 	accountMap, ok := account.(map[string]interface{})
 	if !ok {
-		return Account{}, fmt.Errorf("account '%s' has an invalid format", name)
+		return Account{}, fmt.Errorf("account '%s' has an invalid format", handle)
 	}
 
-	return Account{
-		Name:       accountMap["name"].(string),
-		Email:      accountMap["email"].(string),
-		SSHKeyPath: accountMap["sshkeypath"].(string),
-		SSHAlias:   accountMap["sshalias"].(string),
-		Id:         accountMap["id"].(int),
-	}, nil
+	return accountFromMap(handle, accountMap)
 }
 
 func GetAccounts() []Account {
+	result, errs := LoadAccounts()
+	for _, err := range errs {
+		fmt.Println(err)
+	}
+
+	return result
+}
+
+// LoadAccounts parses every account in the configuration, returning the entries that could not be parsed as errors.
+func LoadAccounts() ([]Account, []error) {
 	accounts := viper.GetStringMap("accounts")
 	var result []Account
+	var errs []error
 
 	for key, account := range accounts {
 		accountMap, ok := account.(map[string]interface{})
 		if !ok {
-			fmt.Printf("Account '%s' has an invalid format or type is incorrect\n", key)
+			errs = append(errs, fmt.Errorf("account '%s' has an invalid format or type is incorrect", key))
 			continue
 		}
 
-		name, nameOk := accountMap["name"].(string)
-		email, emailOk := accountMap["email"].(string)
-		sshKeyPath, sshKeyOk := accountMap["sshkeypath"].(string)
+		parsed, err := accountFromMap(key, accountMap)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
 
-		if !nameOk || !emailOk || !sshKeyOk {
-			fmt.Printf("Account '%s' is missing required fields or has invalid types\n", key)
+		result = append(result, parsed)
+	}
+
+	return result, errs
+}
+
+// loginPattern matches names that can be forge logins, unlike display names like "Jane Doe".
+var loginPattern = regexp.MustCompile(`^[a-zA-Z0-9._-]+$`)
+
+// MigrateAccounts adds the forge login to the accounts saved before it was separate from the git name, and moves
+// their secrets from their name to their handle in store. It returns the handles of the migrated accounts.
+func MigrateAccounts(store secrets.SecretStore) ([]string, error) {
+	accounts := viper.GetStringMap("accounts")
+	var migrated []string
+
+	for handle, account := range accounts {
+		accountMap, ok := account.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		parsed, err := accountFromMap(handle, accountMap)
+		if err != nil || !parsed.legacy {
 			continue
 		}
 
-		result = append(result, Account{
-			Name:       name,
-			Email:      email,
-			SSHKeyPath: sshKeyPath,
-		})
+		// The secrets are moved first, so that a failure leaves the account finding them under their old names.
+		if err := parsed.migrateSecrets(store); err != nil {
+			return nil, fmt.Errorf("could not migrate the secrets of account '%s': %w", handle, err)
+		}
+		migrateAccountMap(accountMap)
+		migrated = append(migrated, handle)
+	}
+	if len(migrated) == 0 {
+		return nil, nil
+	}
+	sort.Strings(migrated)
+
+	viper.Set("accounts", accounts)
+	return migrated, viper.WriteConfig()
+}
+
+// NeedsMigration reports whether the account was saved before the login was separate from the name, see
+// MigrateAccounts.
+func (a *Account) NeedsMigration() bool {
+	return a.legacy
+}
+
+// legacyLogin returns the login of an account saved without one. Such accounts used their name as both the git name
+// and the forge login, so the name is the login unless it's a display name.
+func legacyLogin(name string) string {
+	if loginPattern.MatchString(name) {
+		return name
 	}
 
-	return result
+	return ""
+}
+
+// migrateAccountMap adds the login to an account saved without one, reporting whether it did.
+func migrateAccountMap(accountMap map[string]interface{}) bool {
+	if _, ok := accountMap["login"]; ok {
+		return false
+	}
+
+	name, _ := accountMap["name"].(string)
+	accountMap["login"] = legacyLogin(name)
+	return true
+}
+
+// accountFromMap builds an account from its representation in the configuration file.
+func accountFromMap(key string, accountMap map[string]interface{}) (Account, error) {
+	name, nameOk := accountMap["name"].(string)
+	email, emailOk := accountMap["email"].(string)
+	sshKeyPath, sshKeyOk := accountMap["sshkeypath"].(string)
+
+	if !nameOk || !emailOk || !sshKeyOk {
+		return Account{}, fmt.Errorf("account '%s' is missing required fields or has invalid types", key)
+	}
+
+	account := Account{
+		Handle:     key,
+		Name:       name,
+		Email:      email,
+		SSHKeyPath: sshKeyPath,
+	}
+	if login, ok := accountMap["login"].(string); ok {
+		account.Login = login
+	} else {
+		account.legacy = true
+		account.Login = legacyLogin(name)
+	}
+	account.SSHAlias, _ = accountMap["sshalias"].(string)
+	account.Id, _ = accountMap["id"].(int)
+	account.CertificateFile, _ = accountMap["certificatefile"].(string)
+	account.Provider, _ = accountMap["provider"].(string)
+	if _, err := git.NormalizeProvider(account.Provider); err != nil {
+		return Account{}, fmt.Errorf("account '%s': %w", key, err)
+	}
+	account.Host, _ = accountMap["host"].(string)
+	account.BaseURL, _ = accountMap["baseurl"].(string)
+
+	if signingMap, ok := accountMap["signing"].(map[string]interface{}); ok {
+		account.Signing.Format, _ = signingMap["format"].(string)
+		account.Signing.SignCommits, _ = signingMap["commits"].(bool)
+		account.Signing.SignTags, _ = signingMap["tags"].(bool)
+		account.Signing.GPGKeyID, _ = signingMap["gpgkeyid"].(string)
+		account.Signing.GPGProgram, _ = signingMap["gpgprogram"].(string)
+	}
+
+	if transportMap, ok := accountMap["transport"].(map[string]interface{}); ok {
+		account.Transport = transportFromMap(transportMap)
+	}
+
+	return account, nil
+}
+
+// toMap returns the representation of the account in the configuration file.
+func (a *Account) toMap() map[string]interface{} {
+	accountMap := map[string]interface{}{
+		"name":       a.Name,
+		"login":      a.Login,
+		"email":      a.Email,
+		"sshkeypath": a.SSHKeyPath,
+		"sshalias":   a.SSHAlias,
+		"id":         a.Id,
+	}
+
+	if a.CertificateFile != "" {
+		accountMap["certificatefile"] = a.CertificateFile
+	}
+	if a.Provider != "" && a.Provider != git.GitHubProvider {
+		accountMap["provider"] = a.Provider
+	}
+	if a.Host != "" && a.Host != git.DefaultHost(a.Provider) {
+		accountMap["host"] = a.Host
+	}
+	if a.BaseURL != "" {
+		accountMap["baseurl"] = a.BaseURL
+	}
+
+	if a.Signing.Enabled() {
+		signingMap := map[string]interface{}{
+			"format":  a.Signing.Format,
+			"commits": a.Signing.SignCommits,
+			"tags":    a.Signing.SignTags,
+		}
+		if a.Signing.GPGKeyID != "" {
+			signingMap["gpgkeyid"] = a.Signing.GPGKeyID
+		}
+		if a.Signing.GPGProgram != "" {
+			signingMap["gpgprogram"] = a.Signing.GPGProgram
+		}
+		accountMap["signing"] = signingMap
+	}
+
+	if !a.Transport.IsZero() {
+		accountMap["transport"] = a.Transport.toMap()
+	}
+
+	return accountMap
+}
+
+// ForgeProvider returns the provider of the account's forge.
+func (a *Account) ForgeProvider() string {
+	provider, err := git.NormalizeProvider(a.Provider)
+	if err != nil {
+		return a.Provider
+	}
+
+	return provider
+}
+
+// ForgeHost returns the host of the account's forge as configured for the account, ignoring its SSH alias.
+func (a *Account) ForgeHost() string {
+	if a.Host != "" {
+		return a.Host
+	}
+	if parsed, err := url.Parse(a.BaseURL); err == nil && parsed.Hostname() != "" {
+		return parsed.Hostname()
+	}
+
+	return git.DefaultHost(a.ForgeProvider())
+}
+
+// Forge returns a client for the API of the account's forge at host, or at its base URL if it has one.
+func (a *Account) Forge(host string) (git.Forge, error) {
+	if a.ForgeProvider() == git.BitbucketProvider && a.BaseURL == "" {
+		// Bitbucket doesn't show users and their keys anonymously.
+		return git.NewBitbucketClient(a.Token()), nil
+	}
+	if a.BaseURL != "" {
+		return git.NewForgeAt(a.Provider, a.BaseURL)
+	}
+
+	return git.NewForge(a.Provider, host)
+}
+
+// Save updates the account in the configuration file, migrating it if it was saved before the login was separate.
+func (a *Account) Save() error {
+	if a.legacy {
+		store, err := secrets.Default()
+		if err != nil {
+			return err
+		}
+		if err := a.migrateSecrets(store); err != nil {
+			return fmt.Errorf("could not migrate the secrets of account '%s': %w", a.Handle, err)
+		}
+		a.legacy = false
+	}
+
+	accounts := viper.GetStringMap("accounts")
+	accounts[a.Handle] = a.toMap()
+	viper.Set("accounts", accounts)
+
+	return viper.WriteConfig()
+}
+
+// GetAccountBySSHAlias returns the account bound to the provided SSH alias.
+func GetAccountBySSHAlias(alias string) (Account, bool) {
+	for _, account := range GetAccounts() {
+		if account.SSHAlias != "" && account.SSHAlias == alias {
+			return account, true
+		}
+	}
+
+	return Account{}, false
+}
+
+// GetAccountByEmail returns the account using the provided email.
+func GetAccountByEmail(email string) (Account, bool) {
+	return FindAccountByEmail(GetAccounts(), email)
+}
+
+// FindAccountByEmail returns the account from accountList using the provided email, ignoring its case.
+func FindAccountByEmail(accountList []Account, email string) (Account, bool) {
+	for _, account := range accountList {
+		if strings.EqualFold(account.Email, email) {
+			return account, true
+		}
+	}
+
+	return Account{}, false
+}
+
+// Summary describes the account by its handle and git identity, like "work (Jane Doe <jane@acme.com>)".
+func (a *Account) Summary() string {
+	return fmt.Sprintf("%s (%s <%s>)", a.Handle, a.Name, a.Email)
 }
 
 func (a *Account) String() string {
-	return fmt.Sprintf("Name: %s, Email: %s", a.Name, a.Email)
+	return fmt.Sprintf("Handle: %s, Name: %s, Login: %s, Email: %s", a.Handle, a.Name, a.Login, a.Email)
 }
 
 func (a *Account) Delete() {
 	accounts := viper.GetStringMap("accounts")
-	delete(accounts, a.Name)
+	delete(accounts, a.Handle)
 	viper.Set("accounts", accounts)
 
 	err := viper.WriteConfig()
 	if err != nil {
-		fmt.Printf("Failed to delete account '%s'. Error: %s\n", a.Name, err)
+		fmt.Printf("Failed to delete account '%s'. Error: %s\n", a.Handle, err)
 		return
 	}
 
-	fmt.Printf("Account '%s' deleted successfully.\n", a.Name)
+	fmt.Printf("Account '%s' deleted successfully.\n", a.Handle)
 }
 
-func (a *Account) SetGlobal() {
+// SetGlobal makes the account the global git identity and configures its commit signing.
+func (a *Account) SetGlobal() error {
 	git.UpdateGlobalGitConfig(a.Name, a.Email)
+
+	return a.ConfigureSigning(git.GlobalScope)
 }
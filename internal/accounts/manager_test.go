@@ -0,0 +1,155 @@
+package accounts
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/style77/gas/internal/secrets"
+)
+
+func TestMigrateAccountMap(t *testing.T) {
+	tests := []struct {
+		accountMap map[string]interface{}
+		migrated   bool
+		wantLogin  interface{}
+	}{
+		{map[string]interface{}{"name": "johnDoe98"}, true, "johnDoe98"},
+		{map[string]interface{}{"name": "John Doe"}, true, ""},
+		{map[string]interface{}{"name": "John Doe", "login": "johnDoe98"}, false, "johnDoe98"},
+		{map[string]interface{}{"name": "john-work", "login": ""}, false, ""},
+	}
+
+	for _, tt := range tests {
+		if migrated := migrateAccountMap(tt.accountMap); migrated != tt.migrated {
+			t.Errorf("migrateAccountMap(%v) = %v, want %v", tt.accountMap, migrated, tt.migrated)
+		}
+		if login := tt.accountMap["login"]; login != tt.wantLogin {
+			t.Errorf("Expected login '%v', but got '%v'", tt.wantLogin, login)
+		}
+	}
+
+	parsed, err := accountFromMap("personal", map[string]interface{}{"name": "John Doe", "login": "johnDoe98", "email": "john@example.com", "sshkeypath": "~/.ssh/id_personal"})
+	if err != nil || parsed.Handle != "personal" || parsed.Name != "John Doe" || parsed.Login != "johnDoe98" {
+		t.Errorf("Expected the handle, name and login to be separate, but got %+v, %v", parsed, err)
+	}
+}
+
+// mapStore is a secret store keeping secrets in memory.
+type mapStore map[string]string
+
+func (s mapStore) Get(name string) (string, error) {
+	value, ok := s[name]
+	if !ok {
+		return "", secrets.ErrNotFound
+	}
+	return value, nil
+}
+
+func (s mapStore) Set(name, value string) error {
+	s[name] = value
+	return nil
+}
+
+func (s mapStore) Delete(name string) error {
+	if _, ok := s[name]; !ok {
+		return secrets.ErrNotFound
+	}
+	delete(s, name)
+	return nil
+}
+
+func (s mapStore) List() ([]string, error) {
+	var names []string
+	for name := range s {
+		names = append(names, name)
+	}
+	return names, nil
+}
+
+func TestMigrateAccounts(t *testing.T) {
+	defer viper.Reset()
+	configPath := filepath.Join(t.TempDir(), ".gas.yaml")
+	config := `accounts:
+    johndoe98:
+        name: johnDoe98
+        email: john@example.com
+        sshkeypath: ~/.ssh/id_personal
+        id: 1
+    work:
+        name: john-work
+        login: john-acme
+        email: john@acme.com
+        sshkeypath: ~/.ssh/id_work
+        id: 2
+`
+	if err := os.WriteFile(configPath, []byte(config), 0600); err != nil {
+		t.Fatal(err)
+	}
+	viper.SetConfigFile(configPath)
+	if err := viper.ReadInConfig(); err != nil {
+		t.Fatal(err)
+	}
+
+	legacy, err := GetAccount("johndoe98")
+	if err != nil || !legacy.NeedsMigration() || legacy.Login != "johnDoe98" || legacy.tokenSecretName() != "token/johnDoe98" {
+		t.Fatalf("Expected a legacy account reading its secrets by name, but got %+v, %v", legacy, err)
+	}
+
+	store := mapStore{"token/johnDoe98": "ghp_personal", "passphrase/johnDoe98": "hunter2", "token/work": "ghp_work"}
+	migrated, err := MigrateAccounts(store)
+	if err != nil || !reflect.DeepEqual(migrated, []string{"johndoe98"}) {
+		t.Fatalf("MigrateAccounts() = %v, %v, want [johndoe98]", migrated, err)
+	}
+
+	wantStore := mapStore{"token/johndoe98": "ghp_personal", "passphrase/johndoe98": "hunter2", "token/work": "ghp_work"}
+	if !reflect.DeepEqual(store, wantStore) {
+		t.Errorf("Expected secrets %v, but got %v", wantStore, store)
+	}
+
+	viper.Reset()
+	viper.SetConfigFile(configPath)
+	if err := viper.ReadInConfig(); err != nil {
+		t.Fatal(err)
+	}
+	account, err := GetAccount("johndoe98")
+	if err != nil || account.NeedsMigration() || account.Login != "johnDoe98" || account.tokenSecretName() != "token/johndoe98" {
+		t.Errorf("Expected the login to be written and the secrets read by handle, but got %+v, %v", account, err)
+	}
+
+	if migrated, err := MigrateAccounts(store); err != nil || migrated != nil {
+		t.Errorf("Expected nothing left to migrate, but got %v, %v", migrated, err)
+	}
+}
+
+func TestMigrateSecretsConflict(t *testing.T) {
+	account := Account{Handle: "work", Name: "Work", legacy: true}
+	store := mapStore{"token/Work": "old", "token/work": "new"}
+	if err := account.migrateSecrets(store); err == nil {
+		t.Error("Expected an error when both secrets exist")
+	}
+	if len(store) != 2 {
+		t.Errorf("Expected the secrets to be kept, but got %v", store)
+	}
+
+	store = mapStore{"token/Work": "same", "token/work": "same"}
+	if err := account.migrateSecrets(store); err != nil || !reflect.DeepEqual(store, mapStore{"token/work": "same"}) {
+		t.Errorf("Expected the duplicate to be removed, but got %v, %v", store, err)
+	}
+}
+
+func TestFindAccountByEmail(t *testing.T) {
+	accountList := []Account{
+		{Handle: "work", Email: "john@acme.com"},
+		{Handle: "personal", Email: "john@example.com"},
+	}
+
+	if account, ok := FindAccountByEmail(accountList, "John@Example.com"); !ok || account.Handle != "personal" {
+		t.Errorf("Expected the personal account, but got %+v, %v", account, ok)
+	}
+	if account, ok := FindAccountByEmail(accountList, "john@other.com"); ok {
+		t.Errorf("Expected no account for an unknown email, but got %+v", account)
+	}
+}
@@ -0,0 +1,182 @@
+package accounts
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// NotFoundError is returned when no account matches a query.
+type NotFoundError struct {
+	Query string
+}
+
+func (e *NotFoundError) Error() string {
+	return fmt.Sprintf("account '%s' not found", e.Query)
+}
+
+// AmbiguousError is returned when a query matches several accounts, sorted by handle.
+type AmbiguousError struct {
+	Query      string
+	Candidates []Account
+}
+
+func (e *AmbiguousError) Error() string {
+	var message strings.Builder
+	fmt.Fprintf(&message, "'%s' matches %d accounts, use one of their handles:", e.Query, len(e.Candidates))
+	for _, candidate := range e.Candidates {
+		fmt.Fprintf(&message, "\n  %s", candidate.Summary())
+	}
+
+	return message.String()
+}
+
+// ResolveAccount returns the account a command line argument refers to. The argument is matched against, in order:
+// the handle, the numeric ID, the email, the SSH alias or the login, then a prefix of those or of the name, then a
+// fuzzy match of them. The first kind of match finding accounts decides; if it finds several, an *AmbiguousError
+// lists them. A *NotFoundError is returned if nothing matches, joined with the errors reading the configuration.
+func ResolveAccount(query string) (Account, error) {
+	return resolveConfiguredAccount(query, true)
+}
+
+// ResolveAccountStrictly works like ResolveAccount without the fuzzy matches, for commands replacing or removing
+// something of the account, where a surprising match would do harm.
+func ResolveAccountStrictly(query string) (Account, error) {
+	return resolveConfiguredAccount(query, false)
+}
+
+// resolveConfiguredAccount resolves the query against the accounts in the configuration, see ResolveAccount.
+func resolveConfiguredAccount(query string, fuzzy bool) (Account, error) {
+	// An exact handle also reports accounts that can't be parsed.
+	account, err := GetAccount(query)
+	var notFound *NotFoundError
+	if !errors.As(err, &notFound) {
+		return account, err
+	}
+
+	accountList, errs := LoadAccounts()
+	account, err = resolveAccount(query, accountList, fuzzy)
+	if !errors.As(err, &notFound) {
+		return account, err
+	}
+
+	// The account may be the one that couldn't be read.
+	if configErr := readConfigError(); configErr != nil {
+		errs = append([]error{configErr}, errs...)
+	}
+	if len(errs) > 0 {
+		return Account{}, errors.Join(append([]error{err}, errs...)...)
+	}
+
+	return Account{}, err
+}
+
+// readConfigError returns the error reading the configuration file, which leaves no accounts to resolve.
+func readConfigError() error {
+	if viper.ConfigFileUsed() == "" {
+		return nil
+	}
+	if err := viper.ReadInConfig(); err != nil {
+		return fmt.Errorf("could not read '%s': %w", viper.ConfigFileUsed(), err)
+	}
+
+	return nil
+}
+
+// resolveAccount finds the account in accountList the query refers to, see ResolveAccount. Fuzzy matches are only
+// tried if fuzzy is set.
+func resolveAccount(query string, accountList []Account, fuzzy bool) (Account, error) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return Account{}, &NotFoundError{Query: query}
+	}
+	lowerQuery := strings.ToLower(query)
+
+	matchers := []func(Account) bool{
+		func(account Account) bool {
+			return strings.EqualFold(account.Handle, query)
+		},
+		// IDs come before the other fields, so that a login that looks like a number doesn't make them ambiguous.
+		func(account Account) bool {
+			id, err := strconv.Atoi(query)
+			return err == nil && id > 0 && account.Id == id
+		},
+		func(account Account) bool {
+			return strings.EqualFold(account.Email, query) ||
+				strings.EqualFold(account.SSHAlias, query) ||
+				strings.EqualFold(account.Login, query)
+		},
+		func(account Account) bool {
+			for _, field := range account.searchFields() {
+				if strings.HasPrefix(field, lowerQuery) {
+					return true
+				}
+			}
+			return false
+		},
+	}
+	if fuzzy {
+		matchers = append(matchers, func(account Account) bool {
+			for _, field := range account.searchFields() {
+				if isSubsequence(lowerQuery, field) {
+					return true
+				}
+			}
+			return false
+		})
+	}
+
+	for _, matches := range matchers {
+		var candidates []Account
+		for _, account := range accountList {
+			if matches(account) {
+				candidates = append(candidates, account)
+			}
+		}
+
+		switch len(candidates) {
+		case 0:
+			continue
+		case 1:
+			return candidates[0], nil
+		default:
+			sort.Slice(candidates, func(i, j int) bool {
+				return candidates[i].Handle < candidates[j].Handle
+			})
+			return Account{}, &AmbiguousError{Query: query, Candidates: candidates}
+		}
+	}
+
+	return Account{}, &NotFoundError{Query: query}
+}
+
+// searchFields returns the lowercased fields of the account matched by prefix and fuzzily.
+func (a *Account) searchFields() []string {
+	var fields []string
+	for _, field := range []string{a.Handle, a.Login, a.Name, a.Email, a.SSHAlias} {
+		if field != "" {
+			fields = append(fields, strings.ToLower(field))
+		}
+	}
+
+	return fields
+}
+
+// isSubsequence reports whether the characters of query appear in s in the same order, like "wrk" in "work".
+func isSubsequence(query, s string) bool {
+	remaining := []rune(query)
+	for _, r := range s {
+		if len(remaining) == 0 {
+			break
+		}
+		if r == remaining[0] {
+			remaining = remaining[1:]
+		}
+	}
+
+	return len(remaining) == 0
+}
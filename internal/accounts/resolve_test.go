@@ -0,0 +1,116 @@
+package accounts
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func TestResolveAccount(t *testing.T) {
+	accountList := []Account{
+		{Handle: "work", Name: "Jane Doe", Login: "jane-acme", Email: "jane@acme.com", SSHAlias: "github-work", Id: 1},
+		{Handle: "personal", Name: "Jane Doe", Login: "janedoe98", Email: "jane@example.com", Id: 2},
+		{Handle: "workshop", Name: "Jane Doe", Login: "jane-workshop", Email: "jane@workshop.dev", SSHAlias: "github-workshop", Id: 3},
+		{Handle: "oss", Name: "Jane Doe", Login: "jane-oss", Email: "jane@example.com", Id: 4},
+		{Handle: "numeric", Name: "Jane Doe", Login: "1", Email: "jane@numeric.dev", Id: 5},
+	}
+
+	tests := []struct {
+		query         string
+		want          string
+		wantAmbiguous []string
+	}{
+		{query: "work", want: "work"},
+		{query: "Personal", want: "personal"},
+		{query: "3", want: "workshop"},
+		{query: "1", want: "work"},
+		{query: "jane@acme.com", want: "work"},
+		{query: "github-work", want: "work"},
+		{query: "janedoe98", want: "personal"},
+		{query: "pers", want: "personal"},
+		{query: "works", want: "workshop"},
+		{query: "prsnl", want: "personal"},
+		{query: "wo", wantAmbiguous: []string{"work", "workshop"}},
+		{query: "jane@example.com", wantAmbiguous: []string{"oss", "personal"}},
+		{query: "zz"},
+		{query: "7"},
+		{query: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			account, err := resolveAccount(tt.query, accountList, true)
+
+			var ambiguous *AmbiguousError
+			var notFound *NotFoundError
+			switch {
+			case tt.want != "":
+				if err != nil || account.Handle != tt.want {
+					t.Errorf("resolveAccount(%q) = '%s', %v, want '%s'", tt.query, account.Handle, err, tt.want)
+				}
+			case tt.wantAmbiguous != nil:
+				if !errors.As(err, &ambiguous) {
+					t.Fatalf("resolveAccount(%q) = '%s', %v, want an ambiguous error", tt.query, account.Handle, err)
+				}
+				var handles []string
+				for _, candidate := range ambiguous.Candidates {
+					handles = append(handles, candidate.Handle)
+				}
+				if !reflect.DeepEqual(handles, tt.wantAmbiguous) {
+					t.Errorf("Expected candidates %v, but got %v", tt.wantAmbiguous, handles)
+				}
+				if !strings.Contains(err.Error(), "\n  "+ambiguous.Candidates[0].Summary()) {
+					t.Errorf("Expected the error to list the candidates, but got: %v", err)
+				}
+			default:
+				if !errors.As(err, &notFound) {
+					t.Errorf("resolveAccount(%q) = '%s', %v, want a not found error", tt.query, account.Handle, err)
+				}
+			}
+		})
+	}
+}
+
+func TestResolveAccountWithoutFuzzyMatches(t *testing.T) {
+	accountList := []Account{
+		{Handle: "work", Name: "Jane Doe", Login: "jane-acme", Email: "jane@acme.com", Id: 1},
+		{Handle: "personal", Name: "Jane Doe", Login: "janedoe98", Email: "jane@example.com", Id: 2},
+	}
+
+	if account, err := resolveAccount("pers", accountList, false); err != nil || account.Handle != "personal" {
+		t.Errorf("Expected a prefix to match, but got '%s', %v", account.Handle, err)
+	}
+
+	var notFound *NotFoundError
+	if account, err := resolveAccount("prsnl", accountList, false); !errors.As(err, &notFound) {
+		t.Errorf("Expected no fuzzy match, but got '%s', %v", account.Handle, err)
+	}
+}
+
+func TestResolveAccountReportsConfigErrors(t *testing.T) {
+	defer viper.Reset()
+	configPath := filepath.Join(t.TempDir(), ".gas.yaml")
+	if err := os.WriteFile(configPath, []byte("accounts:\n    work:\n        name: [\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	viper.SetConfigFile(configPath)
+	viper.ReadInConfig()
+
+	_, err := ResolveAccount("work")
+	var notFound *NotFoundError
+	if !errors.As(err, &notFound) || !strings.Contains(err.Error(), "could not read") {
+		t.Errorf("Expected the parse error along with not found, but got: %v", err)
+	}
+
+	viper.Reset()
+	viper.Set("accounts", map[string]interface{}{"work": map[string]interface{}{"name": "Jane Doe"}})
+	_, err = ResolveAccount("wo")
+	if !errors.As(err, &notFound) || !strings.Contains(err.Error(), "missing required fields") {
+		t.Errorf("Expected the invalid account to be reported, but got: %v", err)
+	}
+}
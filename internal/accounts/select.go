@@ -2,7 +2,7 @@ package accounts
 
 import (
 	"fmt"
-	"strings"
+	"time"
 
 	"github.com/AlecAivazis/survey/v2"
 	"github.com/style77/gas/internal/git"
@@ -17,10 +17,15 @@ func InteractiveSelectAccount() (Account, error) {
 
 	accountNames := []string{}
 	for _, account := range accounts {
-		accountName := account.Name
+		accountName := account.Summary()
 		if git.IsCurrentGlobal(account.Email) {
 			accountName += " (global)"
 		}
+		if description, warning := account.CertificateStatus(time.Now()); warning != "" {
+			accountName += fmt.Sprintf(" [%s]", warning)
+		} else if description != "" {
+			accountName += fmt.Sprintf(" [certificate: %s]", description)
+		}
 
 		accountNames = append(accountNames, accountName)
 	}
@@ -30,11 +35,10 @@ func InteractiveSelectAccount() (Account, error) {
 		Options: accountNames,
 	}
 
-	var selectedAccountName string
-	survey.AskOne(prompt, &selectedAccountName)
-
-	selectedAccountName = strings.Replace(selectedAccountName, " (global)", "", -1)
-	selectedAccount, _ := GetAccount(selectedAccountName)
+	var selected int
+	if err := survey.AskOne(prompt, &selected); err != nil {
+		return Account{}, err
+	}
 
-	return selectedAccount, nil
+	return accounts[selected], nil
 }
@@ -0,0 +1,211 @@
+package accounts
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/style77/gas/internal/git"
+	"github.com/style77/gas/internal/helpers"
+	"golang.org/x/crypto/ssh"
+)
+
+const (
+	// SSHSigningFormat signs commits and tags with the account's SSH key.
+	SSHSigningFormat = "ssh"
+	// GPGSigningFormat signs commits and tags with the account's OpenPGP key.
+	GPGSigningFormat = "openpgp"
+)
+
+// AllowedSignersPath returns the path of the gas managed allowed signers file.
+func AllowedSignersPath() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get user home directory: %w", err)
+	}
+
+	return filepath.Join(homeDir, ".gas", "allowed_signers"), nil
+}
+
+// WriteAllowedSigners writes the email and public key of every account into the allowed signers file at path,
+// so that git can verify SSH signatures made by any of the accounts.
+func WriteAllowedSigners(path string, accountList []Account) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create directory for allowed signers: %w", err)
+	}
+
+	content := allowedSignersContent(accountList, helpers.ReadPublicKey)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		return fmt.Errorf("failed to write allowed signers: %w", err)
+	}
+
+	return nil
+}
+
+// allowedSignersContent renders one "<email> namespaces="git" <public key>" line per account.
+func allowedSignersContent(accountList []Account, readPublicKey func(string) (ssh.PublicKey, error)) string {
+	var lines []string
+	for _, account := range accountList {
+		if account.SSHKeyPath == "" {
+			continue
+		}
+
+		publicKey, err := readPublicKey(account.SSHKeyPath)
+		if err != nil {
+			fmt.Printf("Skipping account '%s' in allowed signers: %v\n", account.Handle, err)
+			continue
+		}
+
+		authorizedKey := strings.TrimSpace(string(ssh.MarshalAuthorizedKey(publicKey)))
+		lines = append(lines, fmt.Sprintf("%s namespaces=\"git\" %s", account.Email, authorizedKey))
+	}
+	sort.Strings(lines)
+
+	if len(lines) == 0 {
+		return ""
+	}
+
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// ConfigureSigning writes the signing settings of the account into the provided git config scope.
+// Accounts without signing turn signing off, unless no account uses signing and gas doesn't manage it at all.
+func (a *Account) ConfigureSigning(scope git.ConfigScope) error {
+	accountList := GetAccounts()
+
+	if !a.Signing.Enabled() {
+		for _, account := range accountList {
+			if account.Signing.Enabled() {
+				return disableSigning(scope)
+			}
+		}
+		return nil
+	}
+
+	switch a.Signing.Format {
+	case SSHSigningFormat:
+		return a.configureSSHSigning(scope, accountList)
+	case GPGSigningFormat:
+		return a.configureGPGSigning(scope)
+	default:
+		return fmt.Errorf("unsupported signing format '%s'", a.Signing.Format)
+	}
+}
+
+// configureSSHSigning configures git to sign with the account's SSH key and to verify signatures of every account.
+func (a *Account) configureSSHSigning(scope git.ConfigScope, accountList []Account) error {
+	signingKey, err := sshSigningKey(a.SSHKeyPath)
+	if err != nil {
+		return err
+	}
+
+	allowedSignersPath, err := AllowedSignersPath()
+	if err != nil {
+		return err
+	}
+	if err := WriteAllowedSigners(allowedSignersPath, accountList); err != nil {
+		return err
+	}
+	if err := git.SetConfig(git.GlobalScope, "gpg.ssh.allowedSignersFile", allowedSignersPath); err != nil {
+		return err
+	}
+
+	settings := [][2]string{
+		{"gpg.format", SSHSigningFormat},
+		{"user.signingkey", signingKey},
+		{"commit.gpgsign", strconv.FormatBool(a.Signing.SignCommits)},
+		{"tag.gpgsign", strconv.FormatBool(a.Signing.SignTags)},
+	}
+	for _, setting := range settings {
+		if err := git.SetConfig(scope, setting[0], setting[1]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// configureGPGSigning configures git to sign with the account's OpenPGP key.
+// Problems with the key are reported as warnings, since gpg may still be able to sign with it.
+func (a *Account) configureGPGSigning(scope git.ConfigScope) error {
+	if a.Signing.GPGKeyID == "" {
+		return fmt.Errorf("account '%s' has no gpg key configured", a.Handle)
+	}
+
+	for _, warning := range a.GPGKeyWarnings(helpers.RealCommandExecutor{}, time.Now()) {
+		fmt.Println("Warning:", warning)
+	}
+
+	settings := [][2]string{
+		{"gpg.format", GPGSigningFormat},
+		{"user.signingkey", a.Signing.GPGKeyID},
+		{"commit.gpgsign", strconv.FormatBool(a.Signing.SignCommits)},
+		{"tag.gpgsign", strconv.FormatBool(a.Signing.SignTags)},
+	}
+	for _, setting := range settings {
+		if err := git.SetConfig(scope, setting[0], setting[1]); err != nil {
+			return err
+		}
+	}
+
+	if a.Signing.GPGProgram != "" {
+		return git.SetConfig(scope, "gpg.program", a.Signing.GPGProgram)
+	}
+
+	return git.UnsetConfig(scope, "gpg.program")
+}
+
+// GPGKeyWarnings checks the account's OpenPGP key, reporting when it is missing, expired
+// or none of its user IDs uses the account email.
+func (a *Account) GPGKeyWarnings(executor helpers.CommandExecutor, now time.Time) []string {
+	key, err := helpers.FindGPGSecretKey(a.Signing.GPGKeyID, a.Signing.GPGProgram, executor)
+	if err != nil {
+		return []string{fmt.Sprintf("could not find gpg key '%s': %v", a.Signing.GPGKeyID, err)}
+	}
+
+	var warnings []string
+	if key.IsExpired(now) {
+		warnings = append(warnings, fmt.Sprintf("gpg key '%s' is expired or revoked", a.Signing.GPGKeyID))
+	}
+	if !key.HasEmail(a.Email) {
+		warnings = append(warnings, fmt.Sprintf("gpg key '%s' has no user ID with email '%s', signatures won't be verified for it", a.Signing.GPGKeyID, a.Email))
+	}
+
+	return warnings
+}
+
+// sshSigningKey returns the value of user.signingkey for the provided private key:
+// the path of its public key if there is one, or the public key itself otherwise.
+func sshSigningKey(sshKeyPath string) (string, error) {
+	expandedPath, err := helpers.ExpandPath(sshKeyPath)
+	if err != nil {
+		return "", err
+	}
+
+	if _, err := os.Stat(expandedPath + ".pub"); err == nil {
+		return filepath.ToSlash(expandedPath + ".pub"), nil
+	}
+
+	publicKey, err := helpers.ReadPublicKey(expandedPath)
+	if err != nil {
+		return "", err
+	}
+
+	return "key::" + strings.TrimSpace(string(ssh.MarshalAuthorizedKey(publicKey))), nil
+}
+
+// disableSigning turns off signing in the provided git config scope.
+func disableSigning(scope git.ConfigScope) error {
+	if err := git.SetConfig(scope, "commit.gpgsign", "false"); err != nil {
+		return err
+	}
+	if err := git.SetConfig(scope, "tag.gpgsign", "false"); err != nil {
+		return err
+	}
+
+	return git.UnsetConfig(scope, "user.signingkey")
+}
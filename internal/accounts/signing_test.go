@@ -0,0 +1,76 @@
+package accounts
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/style77/gas/internal/sshtest"
+	"golang.org/x/crypto/ssh"
+)
+
+func TestAllowedSignersContent(t *testing.T) {
+	_, publicKey := sshtest.NewKey(t)
+
+	readPublicKey := func(path string) (ssh.PublicKey, error) {
+		if path == "~/.ssh/missing" {
+			return nil, errors.New("could not read key")
+		}
+		return publicKey, nil
+	}
+
+	accountList := []Account{
+		{Name: "work", Email: "john@work.com", SSHKeyPath: "~/.ssh/id_work"},
+		{Name: "personal", Email: "john@example.com", SSHKeyPath: "~/.ssh/id_personal"},
+		{Name: "broken", Email: "broken@example.com", SSHKeyPath: "~/.ssh/missing"},
+	}
+
+	authorizedKey := strings.TrimSpace(string(ssh.MarshalAuthorizedKey(publicKey)))
+	expected := "john@example.com namespaces=\"git\" " + authorizedKey + "\n" +
+		"john@work.com namespaces=\"git\" " + authorizedKey + "\n"
+
+	if got := allowedSignersContent(accountList, readPublicKey); got != expected {
+		t.Errorf("Expected:\n%s\nbut got:\n%s", expected, got)
+	}
+}
+
+func TestAccountMapRoundTrip(t *testing.T) {
+	account := Account{
+		Handle:     "work",
+		Name:       "John Doe",
+		Login:      "john-work",
+		Email:      "john@work.com",
+		SSHKeyPath: "~/.ssh/id_work",
+		SSHAlias:   "github-work",
+		Id:         2,
+		Signing:    SigningConfig{Format: SSHSigningFormat, SignCommits: true},
+		Transport:  TransportConfig{Port443: true, ControlMaster: true, ControlPersist: "30m"},
+		Provider:   "gitlab",
+		Host:       "gitlab.example.com",
+	}
+
+	parsed, err := accountFromMap("work", account.toMap())
+	if err != nil {
+		t.Fatalf("Did not expect an error, but got: %v", err)
+	}
+	if !reflect.DeepEqual(parsed, account) {
+		t.Errorf("Expected %+v, but got %+v", account, parsed)
+	}
+
+	if _, err := accountFromMap("broken", map[string]interface{}{"name": "broken"}); err == nil {
+		t.Errorf("Expected an error for an account missing required fields, but got none")
+	}
+
+	forgejo := Account{Name: "side", Email: "side@example.com", SSHKeyPath: "~/.ssh/id_side", Provider: "forgejo", BaseURL: "https://example.com/git"}
+	parsed, err = accountFromMap("side", forgejo.toMap())
+	if err != nil || parsed.ForgeProvider() != "gitea" || parsed.ForgeHost() != "example.com" {
+		t.Errorf("Expected a Gitea account on example.com, but got %+v, %v", parsed, err)
+	}
+
+	unsupported := account.toMap()
+	unsupported["provider"] = "svn"
+	if _, err := accountFromMap("work", unsupported); err == nil {
+		t.Errorf("Expected an error for an unsupported provider, but got none")
+	}
+}
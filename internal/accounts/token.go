@@ -0,0 +1,194 @@
+package accounts
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/style77/gas/internal/git"
+	"github.com/style77/gas/internal/secrets"
+)
+
+// TokenEnvVar returns the environment variable holding the API token of the account, e.g. GAS_TOKEN_WORK.
+func (a *Account) TokenEnvVar() string {
+	return secrets.EnvVar(a.tokenSecretName())
+}
+
+// tokenSecretName is the name the API token of the account is stored under.
+func (a *Account) tokenSecretName() string {
+	return "token/" + a.secretOwner()
+}
+
+// passphraseSecretName is the name the passphrase of the account's SSH key is stored under.
+func (a *Account) passphraseSecretName() string {
+	return "passphrase/" + a.secretOwner()
+}
+
+// secretOwner returns the part of the secret names identifying the account: its handle, or its name for accounts
+// saved before the login was separate, whose secrets are moved by MigrateAccounts.
+func (a *Account) secretOwner() string {
+	if a.legacy {
+		return a.Name
+	}
+
+	return a.Handle
+}
+
+// migrateSecrets moves the secrets of a legacy account from its name to its handle.
+func (a *Account) migrateSecrets(store secrets.SecretStore) error {
+	for _, prefix := range []string{"token/", "passphrase/"} {
+		if secrets.ValidateName(prefix+a.Name) != nil {
+			// Names like "Jane Doe" couldn't be stored as secret names.
+			continue
+		}
+		if err := moveSecret(store, prefix+a.Name, prefix+a.Handle); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// moveSecret renames a secret in store, doing nothing if it doesn't exist.
+func moveSecret(store secrets.SecretStore, from, to string) error {
+	if from == to {
+		return nil
+	}
+
+	value, err := store.Get(from)
+	if errors.Is(err, secrets.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	existing, err := store.Get(to)
+	switch {
+	case err == nil && existing == value:
+		// Both names hold the secret, e.g. environment variables differing in case only.
+	case err == nil:
+		return fmt.Errorf("secrets '%s' and '%s' both exist, remove the outdated one with 'gas secret rm'", from, to)
+	case !errors.Is(err, secrets.ErrNotFound):
+		return err
+	default:
+		if err := store.Set(to, value); err != nil {
+			return err
+		}
+	}
+
+	if err := store.Delete(from); err != nil && !errors.Is(err, secrets.ErrReadOnly) && !errors.Is(err, secrets.ErrNotFound) {
+		return err
+	}
+
+	return nil
+}
+
+// Token returns the API token of the account, or an empty string if none is configured.
+// The environment variable takes precedence over the token stored by 'gas login'.
+func (a *Account) Token() string {
+	token, err := a.LookupToken()
+	if err != nil {
+		fmt.Printf("Could not read the token of account '%s': %v\n", a.Handle, err)
+	}
+
+	return token
+}
+
+// LookupToken works like Token, but returns errors of the secret store instead of printing them.
+// It returns an empty token without an error if none is configured.
+func (a *Account) LookupToken() (string, error) {
+	if token := os.Getenv(a.TokenEnvVar()); token != "" {
+		return token, nil
+	}
+
+	store, err := secrets.Default()
+	if err != nil {
+		return "", err
+	}
+
+	token, err := store.Get(a.tokenSecretName())
+	if errors.Is(err, secrets.ErrNotFound) {
+		return "", nil
+	}
+
+	return token, err
+}
+
+// bitbucketTokenUser is the username Bitbucket access tokens authenticate git over HTTPS with.
+const bitbucketTokenUser = "x-token-auth"
+
+// HTTPSUser returns the user of the account in HTTPS remote URLs, its login or its handle if the login is unknown.
+func (a *Account) HTTPSUser() string {
+	if a.Login != "" {
+		return a.Login
+	}
+
+	return a.Handle
+}
+
+// HTTPSCredential returns the username and password git authenticates with over HTTPS using the account's token.
+// Bitbucket app passwords are stored as "<username>:<app password>", and its access tokens use a fixed username.
+func (a *Account) HTTPSCredential(token string) (string, string) {
+	if a.ForgeProvider() != git.BitbucketProvider {
+		return a.HTTPSUser(), token
+	}
+	if username, password, ok := strings.Cut(token, ":"); ok {
+		return username, password
+	}
+
+	return bitbucketTokenUser, token
+}
+
+// TokenFromHTTPSCredential returns the token of the account git authenticated with over HTTPS, the reverse of
+// HTTPSCredential.
+func (a *Account) TokenFromHTTPSCredential(username, password string) string {
+	if a.ForgeProvider() != git.BitbucketProvider || username == "" || username == bitbucketTokenUser {
+		return password
+	}
+
+	return username + ":" + password
+}
+
+// KeyPassphrase returns the passphrase of the account's SSH key stored with 'gas secret set passphrase/<account>',
+// or an empty string if none is stored.
+func (a *Account) KeyPassphrase() string {
+	return a.secret(a.passphraseSecretName())
+}
+
+// SaveToken stores the API token of the account in the configured secret store.
+func (a *Account) SaveToken(token string) error {
+	store, err := secrets.Default()
+	if err != nil {
+		return err
+	}
+
+	return store.Set(a.tokenSecretName(), token)
+}
+
+// DeleteToken removes the API token of the account from the configured secret store.
+func (a *Account) DeleteToken() error {
+	store, err := secrets.Default()
+	if err != nil {
+		return err
+	}
+
+	return store.Delete(a.tokenSecretName())
+}
+
+// secret reads a secret of the account from the configured secret store, reporting errors other than a missing secret.
+func (a *Account) secret(name string) string {
+	store, err := secrets.Default()
+	if err != nil {
+		fmt.Println(err)
+		return ""
+	}
+
+	value, err := store.Get(name)
+	if err != nil && !errors.Is(err, secrets.ErrNotFound) {
+		fmt.Printf("Could not read secret '%s' of account '%s': %v\n", name, a.Handle, err)
+	}
+
+	return value
+}
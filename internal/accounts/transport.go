@@ -0,0 +1,287 @@
+package accounts
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/style77/gas/internal/sshconfig"
+)
+
+// port443Hosts maps forges to the host serving SSH on port 443, for networks blocking port 22.
+var port443Hosts = map[string]string{
+	"github.com":    "ssh.github.com",
+	"gitlab.com":    "altssh.gitlab.com",
+	"bitbucket.org": "altssh.bitbucket.org",
+}
+
+const (
+	// DefaultControlPersist is how long a multiplexed master connection stays open after the last session.
+	DefaultControlPersist = "10m"
+	// controlPath is where ssh keeps the sockets of multiplexed connections, %C being a hash of the connection.
+	controlPath = "~/.ssh/gas-%C"
+)
+
+// TransportConfig holds how ssh reaches the forge for an account. The zero value connects directly on port 22.
+type TransportConfig struct {
+	// Port443 connects to the forge's SSH host on port 443, like ssh.github.com instead of github.com on port 22.
+	Port443 bool
+	// Port is a custom port of the forge's SSH server, 0 for the default.
+	Port int
+	// ProxyJump is the jump host, like ssh's -J.
+	ProxyJump string
+	// ProxyCommand is the command ssh connects through, with ssh's %h and %p tokens.
+	ProxyCommand string
+	// ControlMaster shares one connection between git operations, which is kept open for ControlPersist.
+	ControlMaster  bool
+	ControlPersist string
+}
+
+// transportKeys are the SSH config keywords gas manages for the transport of an alias.
+var transportKeys = []string{"Port", "ProxyJump", "ProxyCommand", "ControlMaster", "ControlPath", "ControlPersist"}
+
+// IsZero reports whether the transport is a direct connection without multiplexing.
+func (t TransportConfig) IsZero() bool {
+	return t == TransportConfig{}
+}
+
+// Validate checks that the settings of the transport don't contradict each other.
+func (t TransportConfig) Validate() error {
+	if t.Port443 && t.Port != 0 && t.Port != 443 {
+		return fmt.Errorf("port %d can't be used with port 443", t.Port)
+	}
+	if t.Port < 0 || t.Port > 65535 {
+		return fmt.Errorf("invalid port %d", t.Port)
+	}
+	if t.ProxyJump != "" && t.ProxyCommand != "" {
+		return fmt.Errorf("ProxyJump and ProxyCommand can't be used together")
+	}
+	if strings.ContainsAny(t.ProxyJump, " \t") {
+		return fmt.Errorf("invalid jump host '%s'", t.ProxyJump)
+	}
+	if strings.ContainsAny(t.ProxyJump+t.ProxyCommand+t.ControlPersist, "\r\n") {
+		return fmt.Errorf("transport settings can't contain line breaks")
+	}
+
+	return nil
+}
+
+// ForgeOfHost returns the forge a host name belongs to, which differs from the host name for port 443 hosts.
+func ForgeOfHost(hostName string) string {
+	for forge, port443Host := range port443Hosts {
+		if strings.EqualFold(hostName, port443Host) {
+			return forge
+		}
+	}
+
+	return hostName
+}
+
+// Port443Host returns the host serving SSH on port 443 for the forge at host, if it has one.
+func Port443Host(host string) (string, bool) {
+	port443Host, ok := port443Hosts[strings.ToLower(host)]
+	return port443Host, ok
+}
+
+// HostName returns the host ssh connects to for a forge reached at host.
+func (t TransportConfig) HostName(host string) string {
+	if port443Host, ok := Port443Host(host); ok && t.Port443 {
+		return port443Host
+	}
+
+	return host
+}
+
+// Options returns the SSH config options of the transport for the forge at host, except for HostName.
+func (t TransportConfig) Options(host string) []sshconfig.Option {
+	var options []sshconfig.Option
+
+	if t.Port443 {
+		// The port 443 host serves the host key of the forge, which known_hosts lists under the forge's name.
+		options = append(options, sshconfig.Option{Key: "Port", Value: "443"}, sshconfig.Option{Key: "HostKeyAlias", Value: host})
+	} else if t.Port != 0 {
+		options = append(options, sshconfig.Option{Key: "Port", Value: strconv.Itoa(t.Port)})
+	}
+
+	if t.ProxyJump != "" {
+		options = append(options, sshconfig.Option{Key: "ProxyJump", Value: t.ProxyJump})
+	}
+	if t.ProxyCommand != "" {
+		options = append(options, sshconfig.Option{Key: "ProxyCommand", Value: t.ProxyCommand})
+	}
+
+	if t.ControlMaster {
+		persist := t.ControlPersist
+		if persist == "" {
+			persist = DefaultControlPersist
+		}
+		options = append(options,
+			sshconfig.Option{Key: "ControlMaster", Value: "auto"},
+			sshconfig.Option{Key: "ControlPath", Value: controlPath},
+			sshconfig.Option{Key: "ControlPersist", Value: persist},
+		)
+	}
+
+	return options
+}
+
+// String describes the transport, like "port 443 with multiplexing".
+func (t TransportConfig) String() string {
+	var parts []string
+	switch {
+	case t.Port443:
+		parts = append(parts, "port 443")
+	case t.Port != 0:
+		parts = append(parts, fmt.Sprintf("port %d", t.Port))
+	}
+
+	if t.ProxyJump != "" {
+		parts = append(parts, "via jump host "+t.ProxyJump)
+	}
+	if t.ProxyCommand != "" {
+		parts = append(parts, "via proxy command '"+t.ProxyCommand+"'")
+	}
+	if len(parts) == 0 {
+		parts = append(parts, "direct")
+	}
+	if t.ControlMaster {
+		parts = append(parts, "with multiplexing")
+	}
+
+	return strings.Join(parts, " ")
+}
+
+// ApplyTransport renders the transport into the block of alias, replacing the transport options gas manages.
+// HostName is only changed between the forge and its port 443 host, and HostKeyAlias is only removed if gas set it.
+func ApplyTransport(config *sshconfig.Config, alias string, transport TransportConfig) error {
+	block := config.FindHost(alias)
+	if block == nil {
+		return fmt.Errorf("host '%s' not found in SSH config", alias)
+	}
+
+	hostName, _ := block.Get("HostName")
+	if hostName == "" {
+		hostName = alias
+	}
+	host := ForgeOfHost(hostName)
+
+	if transport.Port443 {
+		port443Host, ok := Port443Host(host)
+		if !ok {
+			return fmt.Errorf("alias '%s' connects to '%s', which doesn't serve SSH on port 443", alias, host)
+		}
+		if err := config.SetOption(alias, "HostName", port443Host); err != nil {
+			return err
+		}
+	} else if host != hostName {
+		if err := config.SetOption(alias, "HostName", host); err != nil {
+			return err
+		}
+	}
+
+	if hostKeyAlias, _ := block.Get("HostKeyAlias"); !transport.Port443 && strings.EqualFold(hostKeyAlias, host) {
+		if err := config.RemoveOption(alias, "HostKeyAlias"); err != nil {
+			return err
+		}
+	}
+
+	for _, key := range transportKeys {
+		if err := config.RemoveOption(alias, key); err != nil {
+			return err
+		}
+	}
+	for _, option := range transport.Options(host) {
+		if err := config.SetOption(alias, option.Key, option.Value); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// transportFromMap reads the transport of an account from its representation in the configuration file.
+func transportFromMap(transportMap map[string]interface{}) TransportConfig {
+	var transport TransportConfig
+	transport.Port443, _ = transportMap["port443"].(bool)
+	transport.Port, _ = transportMap["port"].(int)
+	transport.ProxyJump, _ = transportMap["proxyjump"].(string)
+	transport.ProxyCommand, _ = transportMap["proxycommand"].(string)
+	transport.ControlMaster, _ = transportMap["controlmaster"].(bool)
+	transport.ControlPersist, _ = transportMap["controlpersist"].(string)
+
+	return transport
+}
+
+// toMap returns the representation of the transport in the configuration file.
+func (t TransportConfig) toMap() map[string]interface{} {
+	transportMap := map[string]interface{}{}
+	if t.Port443 {
+		transportMap["port443"] = true
+	}
+	if t.Port != 0 {
+		transportMap["port"] = t.Port
+	}
+	if t.ProxyJump != "" {
+		transportMap["proxyjump"] = t.ProxyJump
+	}
+	if t.ProxyCommand != "" {
+		transportMap["proxycommand"] = t.ProxyCommand
+	}
+	if t.ControlMaster {
+		transportMap["controlmaster"] = true
+	}
+	if t.ControlPersist != "" {
+		transportMap["controlpersist"] = t.ControlPersist
+	}
+
+	return transportMap
+}
+
+// promptForTransport asks how ssh should reach the forge at host for the account.
+func promptForTransport(host string) (TransportConfig, error) {
+	var transport TransportConfig
+
+	const (
+		proxyJump    = "Through a jump host (ProxyJump)"
+		proxyCommand = "Through a proxy command (ProxyCommand)"
+	)
+	direct := fmt.Sprintf("Directly (%s:22)", host)
+	port443 := ""
+	options := []string{direct}
+	if port443Host, ok := Port443Host(host); ok {
+		port443 = fmt.Sprintf("Over port 443 (%s:443), for networks blocking port 22", port443Host)
+		options = append(options, port443)
+	}
+	options = append(options, proxyJump, proxyCommand)
+
+	var choice string
+	err := survey.AskOne(&survey.Select{
+		Message: "How should ssh connect to the forge for this account?",
+		Options: options,
+	}, &choice)
+	if err != nil {
+		return transport, err
+	}
+
+	switch choice {
+	case port443:
+		transport.Port443 = true
+	case proxyJump:
+		err = survey.AskOne(&survey.Input{Message: "What is the jump host (e.g., user@bastion.example.com:22)?"}, &transport.ProxyJump, survey.WithValidator(survey.Required))
+	case proxyCommand:
+		err = survey.AskOne(&survey.Input{Message: "What is the proxy command (e.g., nc -X connect -x proxy.example.com:8080 %h %p)?"}, &transport.ProxyCommand, survey.WithValidator(survey.Required))
+	}
+	if err != nil {
+		return transport, err
+	}
+
+	err = survey.AskOne(&survey.Confirm{
+		Message: "Do you want to share one connection between git operations (ControlMaster)? This speeds up repeated fetches and pushes.",
+	}, &transport.ControlMaster)
+	if err != nil {
+		return transport, err
+	}
+
+	return transport, transport.Validate()
+}
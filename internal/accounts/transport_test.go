@@ -0,0 +1,113 @@
+package accounts
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/style77/gas/internal/sshconfig"
+)
+
+const transportTestConfig = `Host github-work
+    HostName github.com
+    User git
+    IdentityFile ~/.ssh/id_work
+
+Host ghe
+    HostName ghe.example.com
+
+Host gitlab
+    HostName gitlab.com
+`
+
+func TestApplyTransport(t *testing.T) {
+	config := sshconfig.Parse(transportTestConfig)
+
+	transport := TransportConfig{Port443: true, ControlMaster: true}
+	if err := ApplyTransport(config, "github-work", transport); err != nil {
+		t.Fatalf("Did not expect an error, but got: %v", err)
+	}
+
+	resolved := config.Resolve("github-work")
+	want := map[string]string{
+		"hostname":       "ssh.github.com",
+		"port":           "443",
+		"hostkeyalias":   "github.com",
+		"controlmaster":  "auto",
+		"controlpath":    "~/.ssh/gas-%C",
+		"controlpersist": DefaultControlPersist,
+	}
+	for key, value := range want {
+		if resolved.Options[key] != value {
+			t.Errorf("Expected %s '%s', but got '%s'", key, value, resolved.Options[key])
+		}
+	}
+
+	transport = TransportConfig{ProxyCommand: "nc -X connect -x proxy:8080 %h %p"}
+	if err := ApplyTransport(config, "github-work", transport); err != nil {
+		t.Fatalf("Did not expect an error, but got: %v", err)
+	}
+
+	expected := `Host github-work
+    HostName github.com
+    User git
+    IdentityFile ~/.ssh/id_work
+    ProxyCommand nc -X connect -x proxy:8080 %h %p
+
+Host ghe
+    HostName ghe.example.com
+
+Host gitlab
+    HostName gitlab.com
+`
+	if config.String() != expected {
+		t.Errorf("Expected:\n%s\nbut got:\n%s", expected, config.String())
+	}
+
+	if err := ApplyTransport(config, "gitlab", TransportConfig{Port443: true}); err != nil {
+		t.Fatalf("Did not expect an error, but got: %v", err)
+	}
+	if resolved := config.Resolve("gitlab"); resolved.HostName != "altssh.gitlab.com" || resolved.Options["hostkeyalias"] != "gitlab.com" {
+		t.Errorf("Expected GitLab's port 443 host, but got '%s' with HostKeyAlias '%s'", resolved.HostName, resolved.Options["hostkeyalias"])
+	}
+
+	if err := ApplyTransport(config, "ghe", TransportConfig{Port443: true}); err == nil {
+		t.Errorf("Expected an error for port 443 on another forge, but got none")
+	}
+	if err := ApplyTransport(config, "missing", TransportConfig{}); err == nil {
+		t.Errorf("Expected an error for a missing alias, but got none")
+	}
+}
+
+func TestTransportValidate(t *testing.T) {
+	tests := []struct {
+		name      string
+		transport TransportConfig
+		valid     bool
+	}{
+		{"Direct", TransportConfig{}, true},
+		{"Port 443 with jump host", TransportConfig{Port443: true, ProxyJump: "bastion"}, true},
+		{"Port 443 with another port", TransportConfig{Port443: true, Port: 2222}, false},
+		{"Invalid port", TransportConfig{Port: 70000}, false},
+		{"Jump host and proxy command", TransportConfig{ProxyJump: "bastion", ProxyCommand: "nc %h %p"}, false},
+		{"Jump host with spaces", TransportConfig{ProxyJump: "bastion -v"}, false},
+		{"Line break", TransportConfig{ProxyCommand: "nc %h %p\nHost *"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.transport.Validate(); (err == nil) != tt.valid {
+				t.Errorf("Validate() = %v, want valid %v", err, tt.valid)
+			}
+		})
+	}
+}
+
+func TestTransportString(t *testing.T) {
+	transport := TransportConfig{Port443: true, ProxyJump: "bastion", ControlMaster: true}
+	if got := transport.String(); !strings.HasPrefix(got, "port 443 via jump host bastion") || !strings.HasSuffix(got, "with multiplexing") {
+		t.Errorf("Unexpected description '%s'", got)
+	}
+	if got := (TransportConfig{}).String(); got != "direct" {
+		t.Errorf("Expected 'direct', but got '%s'", got)
+	}
+}
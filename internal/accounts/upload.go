@@ -0,0 +1,90 @@
+package accounts
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/style77/gas/internal/git"
+	"github.com/style77/gas/internal/helpers"
+)
+
+// Uploaded keys can take a moment to show up in the public key listing of the user.
+var (
+	uploadPollInterval = 2 * time.Second
+	uploadPollTimeout  = 30 * time.Second
+)
+
+// uploadPublicKey registers the public key of sshKeyPath as an authentication key of the user the token belongs to,
+// then waits until the key is listed for username, so that the ownership check passes.
+func uploadPublicKey(uploader git.KeyUploader, client git.GitHubClient, token, username, sshKeyPath string) error {
+	publicKey, err := helpers.AuthorizedKey(sshKeyPath)
+	if err != nil {
+		return err
+	}
+
+	if _, err := uploader.AddPublicKey(token, git.KeyTitle(), publicKey); err != nil {
+		return fmt.Errorf("could not upload the key: %w", err)
+	}
+
+	deadline := time.Now().Add(uploadPollTimeout)
+	for {
+		isValid, err := isValidSSHKeyForGitHub(sshKeyPath, username, client)
+		if err == nil && isValid {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			if err != nil {
+				return fmt.Errorf("the key was uploaded, but could not be confirmed: %w", err)
+			}
+			return fmt.Errorf("the key was uploaded, but is not listed for '%s'. Does the token belong to another user?", username)
+		}
+		time.Sleep(uploadPollInterval)
+	}
+}
+
+// uploadSigningKey registers the public key of sshKeyPath as a signing key of the user the token belongs to.
+func uploadSigningKey(uploader git.KeyUploader, token, sshKeyPath string) error {
+	publicKey, err := helpers.AuthorizedKey(sshKeyPath)
+	if err != nil {
+		return err
+	}
+
+	if _, err := uploader.AddSigningKey(token, git.KeyTitle(), publicKey); err != nil {
+		return fmt.Errorf("could not upload the signing key: %w", err)
+	}
+
+	return nil
+}
+
+// uploadTokenScopes describes the token scopes the forges need to upload keys.
+var uploadTokenScopes = map[string]string{
+	git.GitHubProvider:    "the 'admin:public_key' scope, and 'admin:ssh_signing_key' for signing keys",
+	git.GitLabProvider:    "the 'api' scope",
+	git.GiteaProvider:     "the 'write:user' scope",
+	git.BitbucketProvider: "the 'account:write' scope, or an app password with the 'Account: Write' permission entered as '<username>:<app password>'",
+}
+
+// promptForUploadToken asks whether to upload a key of a new account on the provider's forge, described by what,
+// and returns the API token to upload it with. A token in the GAS_TOKEN_<HANDLE> environment variable is used
+// without asking for it. An empty token means no upload.
+func promptForUploadToken(handle, provider, what string) (string, error) {
+	forgeName := git.ProviderName(provider)
+
+	var upload bool
+	err := survey.AskOne(&survey.Confirm{Message: fmt.Sprintf("Do you want GAS to upload %s to %s? This needs a token with %s.", what, forgeName, uploadTokenScopes[provider]), Default: true}, &upload)
+	if err != nil || !upload {
+		return "", err
+	}
+
+	account := Account{Handle: handle}
+	if token := account.Token(); token != "" {
+		fmt.Printf("Using the token in %s.\n", account.TokenEnvVar())
+		return token, nil
+	}
+
+	var token string
+	err = survey.AskOne(&survey.Password{Message: fmt.Sprintf("Paste the %s token (it is not stored):", forgeName)}, &token)
+	return strings.TrimSpace(token), err
+}
@@ -0,0 +1,50 @@
+package accounts
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/style77/gas/internal/git"
+	"github.com/style77/gas/internal/git/gittest"
+	"github.com/style77/gas/internal/sshtest"
+)
+
+func TestUploadPublicKey(t *testing.T) {
+	uploadPollInterval, uploadPollTimeout = time.Millisecond, 100*time.Millisecond
+	t.Cleanup(func() { uploadPollInterval, uploadPollTimeout = 2*time.Second, 30*time.Second })
+
+	fake, server := gittest.NewGitHub(t, "john", "secret")
+	fake.ListingDelay = 2
+	client := &git.RealGitHubClient{BaseURL: server.URL}
+	keyPath := filepath.Join(t.TempDir(), "id_ed25519")
+	sshtest.WriteKey(t, keyPath, 0600)
+
+	if err := uploadPublicKey(client, client, "secret", "john", keyPath); err != nil {
+		t.Fatalf("Did not expect an error, but got: %v", err)
+	}
+	if len(fake.Keys) != 1 || !strings.HasPrefix(fake.Keys[0].Key, "ssh-ed25519 ") || !strings.HasPrefix(fake.Keys[0].Title, "gas@") {
+		t.Errorf("Expected the public key to be uploaded, but got %v", fake.Keys)
+	}
+	if fake.ListingDelay != 0 {
+		t.Errorf("Expected the listing to be polled until the key shows up")
+	}
+
+	if err := uploadSigningKey(client, "secret", keyPath); err != nil {
+		t.Fatalf("Did not expect an error, but got: %v", err)
+	}
+	if len(fake.SigningKeys) != 1 || fake.SigningKeys[0].Key != fake.Keys[0].Key || !strings.HasPrefix(fake.SigningKeys[0].Title, "gas@") {
+		t.Errorf("Expected the signing key to be uploaded, but got %v", fake.SigningKeys)
+	}
+
+	err := uploadPublicKey(client, client, "wrong", "john", keyPath)
+	if err == nil || !strings.Contains(err.Error(), "Bad credentials") {
+		t.Errorf("Expected a bad credentials error, but got: %v", err)
+	}
+
+	err = uploadPublicKey(client, client, "secret", "jane", keyPath)
+	if err == nil || !strings.Contains(err.Error(), "not listed for 'jane'") {
+		t.Errorf("Expected the upload not to be confirmed for another user, but got: %v", err)
+	}
+}
@@ -0,0 +1,169 @@
+package audit
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/style77/gas/internal/accounts"
+	"github.com/style77/gas/internal/git"
+)
+
+// Role tells which identity of a commit mismatched.
+type Role string
+
+const (
+	AuthorRole    Role = "author"
+	CommitterRole Role = "committer"
+)
+
+// CommitRef is a commit made with a mismatched identity.
+type CommitRef struct {
+	Hash  string `json:"sha"`
+	Roles []Role `json:"roles"`
+}
+
+// Group collects the mismatched commits made with the identity of one account.
+type Group struct {
+	Account string      `json:"account"`
+	Emails  []string    `json:"emails"`
+	Count   int         `json:"count"`
+	Commits []CommitRef `json:"commits"`
+}
+
+// UnknownIdentity is an email that doesn't belong to any configured account.
+type UnknownIdentity struct {
+	Email string `json:"email"`
+	Count int    `json:"count"`
+}
+
+// Report is the result of an audit.
+type Report struct {
+	ExpectedAccount string            `json:"expected_account"`
+	ExpectedEmail   string            `json:"expected_email"`
+	Scanned         int               `json:"scanned"`
+	Mismatched      int               `json:"mismatched"`
+	Groups          []Group           `json:"groups"`
+	Unknown         []UnknownIdentity `json:"unknown"`
+}
+
+// Audit classifies the author and committer of every commit against the configured accounts.
+// Commits made with the email of an account other than expected are reported as mismatches, grouped by account.
+func Audit(commits []git.Commit, expected accounts.Account, accountList []accounts.Account) Report {
+	report := Report{
+		ExpectedAccount: expected.Handle,
+		ExpectedEmail:   expected.Email,
+		Scanned:         len(commits),
+		Groups:          []Group{},
+		Unknown:         []UnknownIdentity{},
+	}
+
+	groups := map[string]*Group{}
+	unknown := map[string]int{}
+
+	for _, commit := range commits {
+		// roles of this commit mismatching each account
+		mismatches := map[string][]Role{}
+		seenUnknown := map[string]bool{}
+
+		for _, identity := range []struct {
+			role  Role
+			email string
+		}{
+			{AuthorRole, commit.AuthorEmail},
+			{CommitterRole, commit.CommitterEmail},
+		} {
+			if strings.EqualFold(identity.email, expected.Email) {
+				continue
+			}
+
+			account, ok := accounts.FindAccountByEmail(accountList, identity.email)
+			if !ok {
+				email := strings.ToLower(identity.email)
+				if !seenUnknown[email] {
+					unknown[email]++
+					seenUnknown[email] = true
+				}
+				continue
+			}
+
+			group, ok := groups[account.Handle]
+			if !ok {
+				group = &Group{Account: account.Handle}
+				groups[account.Handle] = group
+			}
+			group.Emails = appendUnique(group.Emails, strings.ToLower(identity.email))
+			mismatches[account.Handle] = append(mismatches[account.Handle], identity.role)
+		}
+
+		for name, roles := range mismatches {
+			group := groups[name]
+			group.Count++
+			group.Commits = append(group.Commits, CommitRef{Hash: commit.Hash, Roles: roles})
+		}
+		if len(mismatches) > 0 {
+			report.Mismatched++
+		}
+	}
+
+	for _, group := range groups {
+		report.Groups = append(report.Groups, *group)
+	}
+	sort.Slice(report.Groups, func(i, j int) bool {
+		if report.Groups[i].Count != report.Groups[j].Count {
+			return report.Groups[i].Count > report.Groups[j].Count
+		}
+		return report.Groups[i].Account < report.Groups[j].Account
+	})
+
+	for email, count := range unknown {
+		report.Unknown = append(report.Unknown, UnknownIdentity{Email: email, Count: count})
+	}
+	sort.Slice(report.Unknown, func(i, j int) bool {
+		if report.Unknown[i].Count != report.Unknown[j].Count {
+			return report.Unknown[i].Count > report.Unknown[j].Count
+		}
+		return report.Unknown[i].Email < report.Unknown[j].Email
+	})
+
+	return report
+}
+
+// Print writes a human readable version of the report to w.
+func (r Report) Print(w io.Writer) {
+	fmt.Fprintf(w, "Scanned %d commits, expected account '%s' <%s>.\n", r.Scanned, r.ExpectedAccount, r.ExpectedEmail)
+
+	if len(r.Groups) == 0 {
+		fmt.Fprintln(w, "No commits made with the identity of another account.")
+	} else {
+		fmt.Fprintf(w, "\n%d commits made with the identity of another account:\n", r.Mismatched)
+		for _, group := range r.Groups {
+			fmt.Fprintf(w, "\n  %s <%s>: %d commits\n", group.Account, strings.Join(group.Emails, ", "), group.Count)
+			for _, commit := range group.Commits {
+				roles := make([]string, len(commit.Roles))
+				for i, role := range commit.Roles {
+					roles[i] = string(role)
+				}
+				fmt.Fprintf(w, "    %s (%s)\n", commit.Hash, strings.Join(roles, ", "))
+			}
+		}
+	}
+
+	if len(r.Unknown) > 0 {
+		fmt.Fprintln(w, "\nIdentities not belonging to any account:")
+		for _, identity := range r.Unknown {
+			fmt.Fprintf(w, "  %s: %d commits\n", identity.Email, identity.Count)
+		}
+	}
+}
+
+func appendUnique(values []string, value string) []string {
+	for _, existing := range values {
+		if existing == value {
+			return values
+		}
+	}
+
+	return append(values, value)
+}
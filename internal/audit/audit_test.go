@@ -0,0 +1,63 @@
+package audit
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/style77/gas/internal/accounts"
+	"github.com/style77/gas/internal/git"
+)
+
+func TestAudit(t *testing.T) {
+	work := accounts.Account{Handle: "work", Name: "John Doe", Login: "john-work", Email: "john@work.com"}
+	personal := accounts.Account{Handle: "personal", Name: "John Doe", Login: "johnDoe98", Email: "john@example.com"}
+	accountList := []accounts.Account{work, personal}
+
+	commits := []git.Commit{
+		{Hash: "aaaa", AuthorEmail: "john@work.com", CommitterEmail: "john@work.com"},
+		{Hash: "bbbb", AuthorEmail: "John@Example.com", CommitterEmail: "john@example.com"},
+		{Hash: "cccc", AuthorEmail: "john@work.com", CommitterEmail: "john@example.com"},
+		{Hash: "dddd", AuthorEmail: "jane@acme.com", CommitterEmail: "noreply@github.com"},
+		{Hash: "eeee", AuthorEmail: "jane@acme.com", CommitterEmail: "jane@acme.com"},
+	}
+
+	report := Audit(commits, work, accountList)
+
+	if report.Scanned != 5 {
+		t.Errorf("Expected 5 scanned commits, but got %d", report.Scanned)
+	}
+	if report.Mismatched != 2 {
+		t.Errorf("Expected 2 mismatched commits, but got %d", report.Mismatched)
+	}
+
+	expectedGroups := []Group{
+		{
+			Account: "personal",
+			Emails:  []string{"john@example.com"},
+			Count:   2,
+			Commits: []CommitRef{
+				{Hash: "bbbb", Roles: []Role{AuthorRole, CommitterRole}},
+				{Hash: "cccc", Roles: []Role{CommitterRole}},
+			},
+		},
+	}
+	if !reflect.DeepEqual(report.Groups, expectedGroups) {
+		t.Errorf("Expected groups %+v, but got %+v", expectedGroups, report.Groups)
+	}
+
+	expectedUnknown := []UnknownIdentity{
+		{Email: "jane@acme.com", Count: 2},
+		{Email: "noreply@github.com", Count: 1},
+	}
+	if !reflect.DeepEqual(report.Unknown, expectedUnknown) {
+		t.Errorf("Expected unknown identities %+v, but got %+v", expectedUnknown, report.Unknown)
+	}
+
+	var output bytes.Buffer
+	report.Print(&output)
+	if !strings.Contains(output.String(), "personal <john@example.com>: 2 commits") {
+		t.Errorf("Expected report to list the mismatched account, but got:\n%s", output.String())
+	}
+}
@@ -0,0 +1,129 @@
+package credential
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/style77/gas/internal/accounts"
+)
+
+// Credential holds the attributes of git's credential helper protocol gas uses.
+// See gitcredentials(7) and the INPUT/OUTPUT FORMAT section of git-credential(1).
+type Credential struct {
+	Protocol string
+	Host     string
+	Path     string
+	Username string
+	Password string
+}
+
+// Read parses the attributes git writes to a credential helper, up to a blank line or the end of input.
+// Unknown attributes are ignored.
+func Read(r io.Reader) (Credential, error) {
+	var credential Credential
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSuffix(scanner.Text(), "\r")
+		if line == "" {
+			break
+		}
+
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return credential, fmt.Errorf("invalid credential line '%s'", line)
+		}
+
+		switch key {
+		case "protocol":
+			credential.Protocol = value
+		case "host":
+			credential.Host = value
+		case "path":
+			credential.Path = value
+		case "username":
+			credential.Username = value
+		case "password":
+			credential.Password = value
+		case "url":
+			credential.fromURL(value)
+		}
+	}
+
+	return credential, scanner.Err()
+}
+
+// fromURL fills the attributes from a url attribute, which git sends instead of the separate attributes.
+func (c *Credential) fromURL(rawURL string) {
+	protocol, rest, ok := strings.Cut(rawURL, "://")
+	if !ok {
+		return
+	}
+	c.Protocol = protocol
+
+	hostPart, path, _ := strings.Cut(rest, "/")
+	c.Path = path
+	if userinfo, host, ok := strings.Cut(hostPart, "@"); ok {
+		c.Username, _, _ = strings.Cut(userinfo, ":")
+		hostPart = host
+	}
+	c.Host = hostPart
+}
+
+// Write writes the non-empty attributes of the credential in the format git reads from a credential helper.
+func (c Credential) Write(w io.Writer) error {
+	attributes := [][2]string{
+		{"protocol", c.Protocol},
+		{"host", c.Host},
+		{"path", c.Path},
+		{"username", c.Username},
+		{"password", c.Password},
+	}
+
+	for _, attribute := range attributes {
+		if attribute[1] == "" {
+			continue
+		}
+		if strings.ContainsAny(attribute[1], "\n\x00") {
+			return fmt.Errorf("invalid %s in credential", attribute[0])
+		}
+		if _, err := fmt.Fprintf(w, "%s=%s\n", attribute[0], attribute[1]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// SelectAccount picks the account a credential request is for. hosts maps account handles to the host of their
+// forge. The account must use the requested host; the username of the request selects it by login, or else the
+// owner of the requested path, which git only sends with credential.useHttpPath.
+func SelectAccount(credential Credential, accountList []accounts.Account, hosts map[string]string) (accounts.Account, bool) {
+	var candidates []accounts.Account
+	for _, account := range accountList {
+		if strings.EqualFold(hosts[account.Handle], credential.Host) {
+			candidates = append(candidates, account)
+		}
+	}
+
+	if credential.Username != "" {
+		for _, account := range candidates {
+			if strings.EqualFold(account.HTTPSUser(), credential.Username) {
+				return account, true
+			}
+		}
+		return accounts.Account{}, false
+	}
+
+	if owner, _, ok := strings.Cut(strings.TrimPrefix(credential.Path, "/"), "/"); ok {
+		for _, account := range candidates {
+			if account.Login != "" && strings.EqualFold(account.Login, owner) {
+				return account, true
+			}
+		}
+	}
+
+	return accounts.Account{}, false
+}
@@ -0,0 +1,88 @@
+package credential
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/style77/gas/internal/accounts"
+)
+
+func TestReadAndWrite(t *testing.T) {
+	input := "protocol=https\nhost=github.com\npath=acme/repo.git\nusername=john-work\ncapability[]=authtype\n\nignored=after blank line\n"
+	credential, err := Read(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("Did not expect an error, but got: %v", err)
+	}
+
+	want := Credential{Protocol: "https", Host: "github.com", Path: "acme/repo.git", Username: "john-work"}
+	if credential != want {
+		t.Errorf("Read() = %+v, want %+v", credential, want)
+	}
+
+	credential.Path = ""
+	credential.Password = "gho_secret"
+	var output bytes.Buffer
+	if err := credential.Write(&output); err != nil {
+		t.Fatalf("Did not expect an error, but got: %v", err)
+	}
+	if got := output.String(); got != "protocol=https\nhost=github.com\nusername=john-work\npassword=gho_secret\n" {
+		t.Errorf("Write() wrote %q", got)
+	}
+
+	credential.Password = "injected\nusername=other"
+	if err := credential.Write(&bytes.Buffer{}); err == nil {
+		t.Errorf("Expected an error for a value with a newline, but got none")
+	}
+
+	if _, err := Read(strings.NewReader("no equals sign\n")); err == nil {
+		t.Errorf("Expected an error for an invalid line, but got none")
+	}
+}
+
+func TestReadURL(t *testing.T) {
+	credential, err := Read(strings.NewReader("url=https://john-work@ghe.example.com/acme/repo.git\n"))
+	if err != nil {
+		t.Fatalf("Did not expect an error, but got: %v", err)
+	}
+
+	want := Credential{Protocol: "https", Host: "ghe.example.com", Path: "acme/repo.git", Username: "john-work"}
+	if credential != want {
+		t.Errorf("Read() = %+v, want %+v", credential, want)
+	}
+}
+
+func TestSelectAccount(t *testing.T) {
+	accountList := []accounts.Account{
+		{Handle: "personal", Name: "John Doe", Login: "johnDoe98", Email: "john@example.com"},
+		{Handle: "work", Name: "John Doe", Login: "john-work", Email: "john@work.com", SSHAlias: "github-work"},
+		{Handle: "ghe", Name: "John Doe", Login: "john-ghe", Email: "john@corp.com", SSHAlias: "ghe"},
+		{Handle: "side", Name: "John Doe", Email: "john@side.dev"},
+	}
+	hosts := map[string]string{"personal": "github.com", "work": "github.com", "ghe": "ghe.example.com", "side": "github.com"}
+
+	tests := []struct {
+		name       string
+		credential Credential
+		want       string
+	}{
+		{"Username", Credential{Host: "github.com", Username: "john-work"}, "work"},
+		{"Username is case insensitive", Credential{Host: "github.com", Username: "JohnDoe98"}, "personal"},
+		{"Username of an account on another host", Credential{Host: "github.com", Username: "john-ghe"}, ""},
+		{"Handle of an account without a login", Credential{Host: "github.com", Username: "side"}, "side"},
+		{"Unknown username is not overridden by the path", Credential{Host: "github.com", Username: "jane", Path: "john-work/repo.git"}, ""},
+		{"Path owner", Credential{Host: "github.com", Path: "john-work/repo.git"}, "work"},
+		{"Path owner on another host", Credential{Host: "ghe.example.com", Path: "john-ghe/repo.git"}, "ghe"},
+		{"Unknown owner", Credential{Host: "github.com", Path: "acme/repo.git"}, ""},
+		{"Host only", Credential{Host: "github.com"}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			account, ok := SelectAccount(tt.credential, accountList, hosts)
+			if ok != (tt.want != "") || account.Handle != tt.want {
+				t.Errorf("SelectAccount() = '%s', %v, want '%s'", account.Handle, ok, tt.want)
+			}
+		})
+	}
+}
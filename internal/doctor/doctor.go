@@ -0,0 +1,550 @@
+package doctor
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/style77/gas/internal/accounts"
+	"github.com/style77/gas/internal/helpers"
+	"github.com/style77/gas/internal/secrets"
+	"github.com/style77/gas/internal/sshauth"
+	"github.com/style77/gas/internal/sshconfig"
+	"golang.org/x/crypto/ssh"
+)
+
+// Severity tells how urgent a finding is.
+type Severity string
+
+const (
+	// SeverityError findings break pushing or authenticating as the right user.
+	SeverityError Severity = "error"
+	// SeverityWarning findings can make gas or ssh pick the wrong identity.
+	SeverityWarning Severity = "warning"
+)
+
+// Finding is a problem found by a check, with a suggested fix.
+type Finding struct {
+	Check      string   `json:"check"`
+	Severity   Severity `json:"severity"`
+	Account    string   `json:"account,omitempty"`
+	Message    string   `json:"message"`
+	Suggestion string   `json:"suggestion"`
+	// Fix repairs the problem automatically. It is nil when the fix needs a decision from the user.
+	Fix func() error `json:"-"`
+}
+
+// MarshalJSON adds whether the finding can be fixed automatically.
+func (f Finding) MarshalJSON() ([]byte, error) {
+	type finding Finding
+	return json.Marshal(struct {
+		finding
+		Fixable bool `json:"fixable"`
+	}{finding(f), f.Fix != nil})
+}
+
+// Environment is everything the checks look at, gathered up front so the checks can be tested.
+type Environment struct {
+	// ConfigErr is the error reading ~/.gas.yaml, if any.
+	ConfigErr   error
+	Accounts    []accounts.Account
+	AccountErrs []error
+	SSHConfig   *sshconfig.Config
+	GlobalEmail string
+	// GitVersion and SSHVersion are the outputs of 'git --version' and 'ssh -V', empty if not installed.
+	GitVersion      string
+	SSHVersion      string
+	KnownHostsFiles []string
+	// Now is the time certificates are checked against.
+	Now time.Time
+}
+
+// Minimum versions of git and OpenSSH.
+var (
+	// MinSSHVersion supports IdentityAgent, used by 'gas agent'.
+	MinSSHVersion = []int{7, 3}
+	// MinGitSigningVersion supports signing commits with SSH keys.
+	MinGitSigningVersion = []int{2, 34}
+	// MinSSHSigningVersion supports 'ssh-keygen -Y find-principals', used to verify SSH signatures.
+	MinSSHSigningVersion = []int{8, 2}
+)
+
+// Run runs every check and returns the findings, errors first.
+func Run(env Environment) []Finding {
+	var findings []Finding
+	findings = append(findings, checkConfig(env)...)
+	findings = append(findings, checkKeys(env)...)
+	findings = append(findings, checkCertificates(env)...)
+	findings = append(findings, checkAliases(env)...)
+	findings = append(findings, checkSharedKeys(env)...)
+	findings = append(findings, checkGlobalIdentity(env)...)
+	findings = append(findings, checkVersions(env)...)
+	findings = append(findings, checkKnownHosts(env)...)
+
+	var sorted []Finding
+	for _, severity := range []Severity{SeverityError, SeverityWarning} {
+		for _, finding := range findings {
+			if finding.Severity == severity {
+				sorted = append(sorted, finding)
+			}
+		}
+	}
+
+	return sorted
+}
+
+// Print writes the findings in a human readable form.
+func Print(w io.Writer, findings []Finding) {
+	if len(findings) == 0 {
+		fmt.Fprintln(w, "No problems found.")
+		return
+	}
+
+	for _, finding := range findings {
+		fmt.Fprintf(w, "[%s] %s: %s\n", finding.Severity, finding.Check, finding.Message)
+		if finding.Suggestion != "" {
+			automatic := ""
+			if finding.Fix != nil {
+				automatic = " (automatic with --fix)"
+			}
+			fmt.Fprintf(w, "    fix%s: %s\n", automatic, finding.Suggestion)
+		}
+	}
+}
+
+func checkConfig(env Environment) []Finding {
+	var findings []Finding
+	if env.ConfigErr != nil {
+		findings = append(findings, Finding{
+			Check:      "config",
+			Severity:   SeverityError,
+			Message:    fmt.Sprintf("~/.gas.yaml could not be read: %v", env.ConfigErr),
+			Suggestion: "fix the YAML syntax of ~/.gas.yaml",
+		})
+	}
+
+	for _, err := range env.AccountErrs {
+		findings = append(findings, Finding{
+			Check:      "config",
+			Severity:   SeverityError,
+			Message:    err.Error(),
+			Suggestion: "fix or remove the account in ~/.gas.yaml, every account needs a name, email, sshkeypath and id",
+		})
+	}
+
+	for _, account := range env.Accounts {
+		if !account.NeedsMigration() {
+			continue
+		}
+		findings = append(findings, Finding{
+			Check:      "config",
+			Severity:   SeverityWarning,
+			Account:    account.Handle,
+			Message:    "account was saved before its forge login was separate from its git name",
+			Suggestion: fmt.Sprintf("add 'login: %s' to the account in ~/.gas.yaml and move its secrets from 'token/%s' and 'passphrase/%s' to its handle", account.Login, account.Name, account.Name),
+			Fix: func() error {
+				store, err := secrets.Default()
+				if err != nil {
+					return err
+				}
+				_, err = accounts.MigrateAccounts(store)
+				return err
+			},
+		})
+	}
+
+	return findings
+}
+
+func checkKeys(env Environment) []Finding {
+	var findings []Finding
+	for _, account := range env.Accounts {
+		keyPath, err := helpers.ExpandPath(account.SSHKeyPath)
+		if err != nil {
+			continue
+		}
+
+		info, err := os.Stat(keyPath)
+		if err != nil {
+			findings = append(findings, Finding{
+				Check:      "key",
+				Severity:   SeverityError,
+				Account:    account.Handle,
+				Message:    fmt.Sprintf("key '%s' does not exist", account.SSHKeyPath),
+				Suggestion: "restore the key or point sshkeypath of the account at the right key in ~/.gas.yaml",
+			})
+			continue
+		}
+
+		if runtime.GOOS != "windows" && info.Mode().Perm()&0077 != 0 {
+			findings = append(findings, Finding{
+				Check:      "key",
+				Severity:   SeverityError,
+				Account:    account.Handle,
+				Message:    fmt.Sprintf("key '%s' is accessible by other users (%s), ssh refuses to use it", account.SSHKeyPath, info.Mode().Perm()),
+				Suggestion: fmt.Sprintf("chmod 600 %s", account.SSHKeyPath),
+				Fix: func() error {
+					return os.Chmod(keyPath, 0600)
+				},
+			})
+		}
+
+		content, err := os.ReadFile(keyPath)
+		if err != nil {
+			continue
+		}
+		var missingErr *ssh.PassphraseMissingError
+		if _, err := ssh.ParsePrivateKey(content); err != nil && !errors.As(err, &missingErr) {
+			findings = append(findings, Finding{
+				Check:      "key",
+				Severity:   SeverityError,
+				Account:    account.Handle,
+				Message:    fmt.Sprintf("key '%s' is not a valid private key: %v", account.SSHKeyPath, err),
+				Suggestion: "point sshkeypath of the account at the private key, not the .pub file",
+			})
+		}
+	}
+
+	return findings
+}
+
+func checkCertificates(env Environment) []Finding {
+	var findings []Finding
+	for _, account := range env.Accounts {
+		if account.CertificateFile == "" {
+			continue
+		}
+
+		cert, err := helpers.ReadCertificate(account.CertificateFile)
+		if err != nil {
+			findings = append(findings, Finding{
+				Check:      "certificate",
+				Severity:   SeverityError,
+				Account:    account.Handle,
+				Message:    err.Error(),
+				Suggestion: "request a certificate from your certificate authority or fix certificatefile of the account in ~/.gas.yaml",
+			})
+			continue
+		}
+
+		if publicKey, err := helpers.ReadPublicKey(account.SSHKeyPath); err == nil && !bytes.Equal(cert.Key.Marshal(), publicKey.Marshal()) {
+			findings = append(findings, Finding{
+				Check:      "certificate",
+				Severity:   SeverityError,
+				Account:    account.Handle,
+				Message:    fmt.Sprintf("certificate '%s' was not issued for key '%s'", account.CertificateFile, account.SSHKeyPath),
+				Suggestion: "request a certificate for the account's key from your certificate authority",
+			})
+		}
+
+		if warning := helpers.CertificateWarning(cert, env.Now); warning != "" {
+			severity := SeverityWarning
+			if validBefore, expires := helpers.CertificateValidBefore(cert); expires && env.Now.After(validBefore) {
+				severity = SeverityError
+			}
+			findings = append(findings, Finding{
+				Check:      "certificate",
+				Severity:   severity,
+				Account:    account.Handle,
+				Message:    fmt.Sprintf("%s (%s)", warning, account.CertificateFile),
+				Suggestion: "request a new certificate from your certificate authority",
+			})
+		}
+	}
+
+	return findings
+}
+
+func checkAliases(env Environment) []Finding {
+	var findings []Finding
+	for _, account := range env.Accounts {
+		if account.SSHAlias == "" || env.SSHConfig == nil {
+			continue
+		}
+
+		account := account
+		if env.SSHConfig.FindHost(account.SSHAlias) == nil {
+			findings = append(findings, Finding{
+				Check:      "alias",
+				Severity:   SeverityError,
+				Account:    account.Handle,
+				Message:    fmt.Sprintf("alias '%s' is missing from the SSH config", account.SSHAlias),
+				Suggestion: fmt.Sprintf("add a 'Host %s' block using HostName %s and IdentityFile %s", account.SSHAlias, account.ForgeHost(), account.SSHKeyPath),
+				Fix: func() error {
+					options := []sshconfig.Option{
+						{Key: "HostName", Value: account.Transport.HostName(account.ForgeHost())},
+						{Key: "User", Value: "git"},
+						{Key: "IdentityFile", Value: account.SSHKeyPath},
+						{Key: "IdentitiesOnly", Value: "yes"},
+					}
+					env.SSHConfig.AddHost(account.SSHAlias, append(options, account.Transport.Options(account.ForgeHost())...))
+					return nil
+				},
+			})
+			continue
+		}
+
+		resolved := env.SSHConfig.Resolve(account.SSHAlias)
+		usesKey := resolved.HasIdentityFile(account.SSHKeyPath)
+		if !usesKey {
+			findings = append(findings, Finding{
+				Check:      "alias",
+				Severity:   SeverityError,
+				Account:    account.Handle,
+				Message:    fmt.Sprintf("alias '%s' does not use the account's key '%s'", account.SSHAlias, account.SSHKeyPath),
+				Suggestion: fmt.Sprintf("set IdentityFile %s in 'Host %s'", account.SSHKeyPath, account.SSHAlias),
+				Fix: func() error {
+					return env.SSHConfig.SetOption(account.SSHAlias, "IdentityFile", account.SSHKeyPath)
+				},
+			})
+		}
+
+		if account.CertificateFile != "" && !sshconfig.SamePath(resolved.Options["certificatefile"], account.CertificateFile) {
+			findings = append(findings, Finding{
+				Check:      "alias",
+				Severity:   SeverityError,
+				Account:    account.Handle,
+				Message:    fmt.Sprintf("alias '%s' does not use the account's certificate '%s'", account.SSHAlias, account.CertificateFile),
+				Suggestion: fmt.Sprintf("set CertificateFile %s in 'Host %s'", account.CertificateFile, account.SSHAlias),
+				Fix: func() error {
+					return env.SSHConfig.SetOption(account.SSHAlias, "CertificateFile", account.CertificateFile)
+				},
+			})
+		}
+
+		if !resolved.IdentitiesOnly {
+			findings = append(findings, Finding{
+				Check:      "alias",
+				Severity:   SeverityWarning,
+				Account:    account.Handle,
+				Message:    fmt.Sprintf("alias '%s' doesn't set 'IdentitiesOnly yes', so keys in ssh-agent are offered first", account.SSHAlias),
+				Suggestion: fmt.Sprintf("set IdentitiesOnly yes in 'Host %s'", account.SSHAlias),
+				Fix: func() error {
+					return env.SSHConfig.SetOption(account.SSHAlias, "IdentitiesOnly", "yes")
+				},
+			})
+		}
+
+		if !usesKey {
+			continue
+		}
+		for _, conflict := range env.SSHConfig.IdentityConflicts(account.SSHAlias, account.SSHKeyPath) {
+			if !conflict.Precedes || conflict.Target != account.SSHAlias {
+				continue
+			}
+			findings = append(findings, Finding{
+				Check:      "alias",
+				Severity:   SeverityWarning,
+				Account:    account.Handle,
+				Message:    conflict.String(),
+				Suggestion: "move the IdentityFile into the blocks of the hosts using it, run 'gas ssh-check' for details",
+			})
+		}
+	}
+
+	return findings
+}
+
+func checkSharedKeys(env Environment) []Finding {
+	owners := map[string][]string{}
+	var order []string
+	for _, account := range env.Accounts {
+		id, err := keyIdentity(account.SSHKeyPath)
+		if err != nil {
+			continue
+		}
+		if _, ok := owners[id]; !ok {
+			order = append(order, id)
+		}
+		owners[id] = append(owners[id], account.Handle)
+	}
+
+	var findings []Finding
+	for _, id := range order {
+		if len(owners[id]) < 2 {
+			continue
+		}
+		findings = append(findings, Finding{
+			Check:      "shared-key",
+			Severity:   SeverityError,
+			Message:    fmt.Sprintf("accounts %s use the same key, a forge only accepts a key for one user", quoteJoin(owners[id])),
+			Suggestion: "generate a separate key for each account with 'gas new'",
+		})
+	}
+
+	return findings
+}
+
+// keyIdentity identifies a key by its public key, falling back to its path.
+func keyIdentity(keyPath string) (string, error) {
+	if publicKey, err := helpers.ReadPublicKey(keyPath); err == nil {
+		return ssh.FingerprintSHA256(publicKey), nil
+	}
+
+	return helpers.ExpandPath(keyPath)
+}
+
+func checkGlobalIdentity(env Environment) []Finding {
+	if env.GlobalEmail == "" {
+		return []Finding{{
+			Check:      "global-identity",
+			Severity:   SeverityWarning,
+			Message:    "no global user.email is configured",
+			Suggestion: "run 'gas switch' to select an account",
+		}}
+	}
+
+	for _, account := range env.Accounts {
+		if strings.EqualFold(account.Email, env.GlobalEmail) {
+			return nil
+		}
+	}
+
+	return []Finding{{
+		Check:      "global-identity",
+		Severity:   SeverityWarning,
+		Message:    fmt.Sprintf("the global user.email '%s' does not belong to any account", env.GlobalEmail),
+		Suggestion: "run 'gas switch' to select an account, or 'gas new' to add one",
+	}}
+}
+
+func checkVersions(env Environment) []Finding {
+	var findings []Finding
+	sshSigning := false
+	for _, account := range env.Accounts {
+		if account.Signing.Enabled() && account.Signing.Format == accounts.SSHSigningFormat {
+			sshSigning = true
+		}
+	}
+
+	gitVersion := ParseVersion(env.GitVersion)
+	if gitVersion == nil {
+		findings = append(findings, Finding{
+			Check:      "version",
+			Severity:   SeverityError,
+			Message:    "git is not installed or not on PATH",
+			Suggestion: "install git",
+		})
+	} else if sshSigning && compareVersions(gitVersion, MinGitSigningVersion) < 0 {
+		findings = append(findings, Finding{
+			Check:      "version",
+			Severity:   SeverityError,
+			Message:    fmt.Sprintf("git %s does not support SSH signing, which needs %s", formatVersion(gitVersion), formatVersion(MinGitSigningVersion)),
+			Suggestion: "upgrade git or sign with GPG using 'gas signing --format gpg'",
+		})
+	}
+
+	sshVersion := ParseVersion(env.SSHVersion)
+	if sshVersion == nil {
+		findings = append(findings, Finding{
+			Check:      "version",
+			Severity:   SeverityError,
+			Message:    "OpenSSH is not installed or not on PATH",
+			Suggestion: "install OpenSSH",
+		})
+	} else if compareVersions(sshVersion, MinSSHVersion) < 0 {
+		findings = append(findings, Finding{
+			Check:      "version",
+			Severity:   SeverityWarning,
+			Message:    fmt.Sprintf("OpenSSH %s is older than %s and does not support IdentityAgent", formatVersion(sshVersion), formatVersion(MinSSHVersion)),
+			Suggestion: "upgrade OpenSSH",
+		})
+	} else if sshSigning && compareVersions(sshVersion, MinSSHSigningVersion) < 0 {
+		findings = append(findings, Finding{
+			Check:      "version",
+			Severity:   SeverityWarning,
+			Message:    fmt.Sprintf("OpenSSH %s cannot verify SSH signatures, which needs %s", formatVersion(sshVersion), formatVersion(MinSSHSigningVersion)),
+			Suggestion: "upgrade OpenSSH",
+		})
+	}
+
+	return findings
+}
+
+func checkKnownHosts(env Environment) []Finding {
+	var findings []Finding
+	checked := map[string]bool{}
+
+	for _, account := range env.Accounts {
+		host, port := account.ForgeHost(), 22
+		if account.SSHAlias != "" && env.SSHConfig != nil {
+			resolved := env.SSHConfig.Resolve(account.SSHAlias)
+			host, port = resolved.HostName, resolved.Port
+			if alias := resolved.Options["hostkeyalias"]; alias != "" {
+				// ssh looks up the alias without the port
+				host, port = alias, 22
+			}
+		}
+
+		key := fmt.Sprintf("%s:%d", host, port)
+		if checked[key] {
+			continue
+		}
+		checked[key] = true
+
+		found, err := sshauth.KnownHostsContains(env.KnownHostsFiles, host, port)
+		if err != nil || found {
+			continue
+		}
+
+		findings = append(findings, Finding{
+			Check:      "known-hosts",
+			Severity:   SeverityWarning,
+			Account:    account.Handle,
+			Message:    fmt.Sprintf("known_hosts has no key for '%s' port %d, the first connection asks to trust it", host, port),
+			Suggestion: "run 'gas known-hosts sync' to add the keys the forge publishes",
+		})
+	}
+
+	return findings
+}
+
+var versionPattern = regexp.MustCompile(`(\d+)\.(\d+)`)
+
+// ParseVersion extracts the major and minor version from the output of 'git --version' or 'ssh -V'.
+func ParseVersion(output string) []int {
+	match := versionPattern.FindStringSubmatch(output)
+	if match == nil {
+		return nil
+	}
+
+	major, _ := strconv.Atoi(match[1])
+	minor, _ := strconv.Atoi(match[2])
+	return []int{major, minor}
+}
+
+func compareVersions(a, b []int) int {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if a[i] != b[i] {
+			return a[i] - b[i]
+		}
+	}
+
+	return len(a) - len(b)
+}
+
+func formatVersion(version []int) string {
+	parts := make([]string, len(version))
+	for i, part := range version {
+		parts[i] = strconv.Itoa(part)
+	}
+
+	return strings.Join(parts, ".")
+}
+
+func quoteJoin(names []string) string {
+	quoted := make([]string, len(names))
+	for i, name := range names {
+		quoted[i] = "'" + name + "'"
+	}
+
+	return strings.Join(quoted, ", ")
+}
@@ -0,0 +1,193 @@
+package doctor
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/style77/gas/internal/accounts"
+	"github.com/style77/gas/internal/helpers"
+	"github.com/style77/gas/internal/sshconfig"
+	"github.com/style77/gas/internal/sshtest"
+	"golang.org/x/crypto/ssh"
+)
+
+// findingsOf returns the messages of the findings of a check.
+func findingsOf(findings []Finding, check string) []Finding {
+	var result []Finding
+	for _, finding := range findings {
+		if finding.Check == check {
+			result = append(result, finding)
+		}
+	}
+	return result
+}
+
+func healthyEnvironment(t *testing.T) Environment {
+	t.Helper()
+	dir := t.TempDir()
+	workKey, personalKey := filepath.Join(dir, "id_work"), filepath.Join(dir, "id_personal")
+	sshtest.WriteKey(t, workKey, 0600)
+	sshtest.WriteKey(t, personalKey, 0600)
+
+	return Environment{
+		Accounts: []accounts.Account{
+			{Handle: "work", Name: "John Doe", Login: "john-work", Email: "john@work.com", SSHKeyPath: workKey, SSHAlias: "github-work"},
+			{Handle: "personal", Name: "John Doe", Login: "johnDoe98", Email: "john@home.com", SSHKeyPath: personalKey},
+		},
+		SSHConfig:   sshconfig.Parse("Host github-work\n    HostName github.com\n    IdentityFile " + workKey + "\n    IdentitiesOnly yes\n"),
+		GlobalEmail: "john@work.com",
+		GitVersion:  "git version 2.43.0",
+		SSHVersion:  "OpenSSH_9.6p1 Ubuntu-3ubuntu13.5, OpenSSL 3.0.13 30 Jan 2024",
+	}
+}
+
+func TestRunHealthy(t *testing.T) {
+	env := healthyEnvironment(t)
+	env.KnownHostsFiles = []string{filepath.Join(t.TempDir(), "known_hosts")}
+	os.WriteFile(env.KnownHostsFiles[0], []byte("github.com ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIOMqqnkVzrm0SdG6UOoqKLsabgH5C9okWi0dh2l9GKJl\n"), 0600)
+
+	if findings := Run(env); len(findings) != 0 {
+		t.Errorf("Expected no findings, but got %+v", findings)
+	}
+}
+
+func TestCheckKeys(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("file permissions are not checked on windows")
+	}
+
+	env := healthyEnvironment(t)
+	dir := t.TempDir()
+	env.Accounts[0].SSHKeyPath = filepath.Join(dir, "id_open")
+	sshtest.WriteKey(t, env.Accounts[0].SSHKeyPath, 0644)
+	env.Accounts[1].SSHKeyPath = filepath.Join(dir, "id_missing")
+
+	findings := checkKeys(env)
+	if len(findings) != 2 {
+		t.Fatalf("Expected 2 findings, but got %+v", findings)
+	}
+	if !strings.Contains(findings[0].Message, "accessible by other users") || findings[0].Fix == nil {
+		t.Errorf("Expected a fixable permission finding, but got %+v", findings[0])
+	}
+	if !strings.Contains(findings[1].Message, "does not exist") || findings[1].Fix != nil {
+		t.Errorf("Expected a missing key finding, but got %+v", findings[1])
+	}
+
+	if err := findings[0].Fix(); err != nil {
+		t.Fatalf("Did not expect an error, but got: %v", err)
+	}
+	if info, _ := os.Stat(env.Accounts[0].SSHKeyPath); info.Mode().Perm() != 0600 {
+		t.Errorf("Expected the key to be chmod 600, but got %s", info.Mode().Perm())
+	}
+}
+
+func TestCheckAliases(t *testing.T) {
+	env := healthyEnvironment(t)
+	env.Accounts[1].SSHAlias = "github-personal"
+	env.SSHConfig = sshconfig.Parse("Host github-work\n    HostName github.com\n    IdentityFile ~/.ssh/id_other\n")
+
+	findings := checkAliases(env)
+	if len(findings) != 3 {
+		t.Fatalf("Expected 3 findings, but got %+v", findings)
+	}
+	for _, finding := range findings {
+		if err := finding.Fix(); err != nil {
+			t.Fatalf("Did not expect an error, but got: %v", err)
+		}
+	}
+
+	if findings := checkAliases(env); len(findings) != 0 {
+		t.Errorf("Expected the fixes to resolve every finding, but got %+v", findings)
+	}
+	if !env.SSHConfig.Resolve("github-personal").HasIdentityFile(env.Accounts[1].SSHKeyPath) {
+		t.Errorf("Expected the missing alias to be added with the account's key")
+	}
+}
+
+func TestCheckSharedKeysAndIdentity(t *testing.T) {
+	env := healthyEnvironment(t)
+	env.Accounts[1].SSHKeyPath = env.Accounts[0].SSHKeyPath
+	env.GlobalEmail = "john@elsewhere.com"
+
+	shared := checkSharedKeys(env)
+	if len(shared) != 1 || !strings.Contains(shared[0].Message, "'work', 'personal'") {
+		t.Errorf("Expected a shared key finding, but got %+v", shared)
+	}
+
+	if identity := checkGlobalIdentity(env); len(identity) != 1 || identity[0].Severity != SeverityWarning {
+		t.Errorf("Expected an unknown identity warning, but got %+v", identity)
+	}
+}
+
+func TestCheckVersions(t *testing.T) {
+	env := healthyEnvironment(t)
+	env.Accounts[0].Signing = accounts.SigningConfig{Format: accounts.SSHSigningFormat, SignCommits: true}
+	env.GitVersion = "git version 2.30.1"
+	env.SSHVersion = ""
+
+	findings := checkVersions(env)
+	if len(findings) != 2 {
+		t.Fatalf("Expected 2 findings, but got %+v", findings)
+	}
+	if !strings.Contains(findings[0].Message, "git 2.30 does not support SSH signing") {
+		t.Errorf("Unexpected git finding: %s", findings[0].Message)
+	}
+	if !strings.Contains(findings[1].Message, "OpenSSH is not installed") {
+		t.Errorf("Unexpected ssh finding: %s", findings[1].Message)
+	}
+}
+
+func TestFindingJSON(t *testing.T) {
+	env := healthyEnvironment(t)
+	env.GlobalEmail = ""
+	findings := findingsOf(Run(env), "global-identity")
+
+	content, err := json.Marshal(findings)
+	if err != nil {
+		t.Fatalf("Did not expect an error, but got: %v", err)
+	}
+	want := `[{"check":"global-identity","severity":"warning","message":"no global user.email is configured","suggestion":"run 'gas switch' to select an account","fixable":false}]`
+	if string(content) != want {
+		t.Errorf("Expected %s, but got %s", want, content)
+	}
+}
+
+func TestCheckCertificates(t *testing.T) {
+	env := healthyEnvironment(t)
+	issued := time.Date(2026, 10, 19, 9, 0, 0, 0, time.UTC)
+	// both accounts use a certificate of the work key
+	workKey, err := helpers.ReadPublicKey(env.Accounts[0].SSHKeyPath)
+	if err != nil {
+		t.Fatalf("Failed to read key: %v", err)
+	}
+	certificate := env.Accounts[0].SSHKeyPath + "-cert.pub"
+	sshtest.WriteCertificate(t, certificate, workKey, ssh.UserCert, []string{"john"}, issued)
+	env.Accounts[0].CertificateFile = certificate
+	env.Accounts[1].CertificateFile = certificate
+
+	env.Now = issued.Add(time.Hour)
+	findings := checkCertificates(env)
+	if len(findings) != 1 || findings[0].Account != "personal" || !strings.Contains(findings[0].Message, "was not issued for key") {
+		t.Fatalf("Expected a key mismatch for the personal account, but got %+v", findings)
+	}
+
+	env.Accounts = env.Accounts[:1]
+	env.Now = issued.Add(10 * time.Hour)
+	if findings := checkCertificates(env); len(findings) != 1 || findings[0].Severity != SeverityError {
+		t.Errorf("Expected an expired certificate error, but got %+v", findings)
+	}
+
+	aliasFindings := findingsOf(checkAliases(env), "alias")
+	if len(aliasFindings) != 1 || aliasFindings[0].Fix == nil {
+		t.Fatalf("Expected a fixable missing CertificateFile finding, but got %+v", aliasFindings)
+	}
+	aliasFindings[0].Fix()
+	if findings := checkAliases(env); len(findings) != 0 {
+		t.Errorf("Expected the fix to set CertificateFile, but got %+v", findings)
+	}
+}
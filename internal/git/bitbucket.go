@@ -0,0 +1,252 @@
+package git
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// DefaultBitbucketAPIURL is the API of Bitbucket Cloud.
+const DefaultBitbucketAPIURL = "https://api.bitbucket.org/2.0"
+
+// errBitbucketNoCredentials is returned when users are looked up without credentials, which Bitbucket doesn't allow.
+var errBitbucketNoCredentials = errors.New("Bitbucket only shows users and their keys with an app password or access token")
+
+// BitbucketClient is the client of the REST API of Bitbucket Cloud.
+//
+// Credentials are either an app password, written as "<username>:<app password>", or an access token.
+type BitbucketClient struct {
+	// BaseURL of the API, defaults to DefaultBitbucketAPIURL.
+	BaseURL string
+	// Token authenticates looking up users and their keys, which Bitbucket doesn't allow anonymously.
+	Token string
+
+	// keyUUIDs maps the IDs of the keys listed so far to their UUIDs, as Bitbucket identifies keys by UUID only.
+	keyUUIDs map[int64]string
+}
+
+// NewBitbucketClient returns a client for the API of Bitbucket Cloud, looking up users with the provided credentials.
+func NewBitbucketClient(token string) *BitbucketClient {
+	return &BitbucketClient{Token: token}
+}
+
+func (c *BitbucketClient) baseURL() string {
+	if c.BaseURL == "" {
+		return DefaultBitbucketAPIURL
+	}
+
+	return strings.TrimSuffix(c.BaseURL, "/")
+}
+
+// bitbucketUser is a user as returned by the users API.
+type bitbucketUser struct {
+	UUID      string `json:"uuid"`
+	Username  string `json:"username"`
+	AccountID string `json:"account_id"`
+}
+
+// bitbucketKey is an SSH key as returned by the SSH keys API.
+type bitbucketKey struct {
+	UUID  string `json:"uuid"`
+	Key   string `json:"key"`
+	Label string `json:"label"`
+}
+
+// do sends an API request authenticated with token, decoding the JSON response into out when it's not nil.
+func (c *BitbucketClient) do(method, path, token string, body, out interface{}) error {
+	header := http.Header{}
+	if username, password, ok := strings.Cut(token, ":"); ok {
+		header.Set("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(username+":"+password)))
+	} else {
+		header.Set("Authorization", "Bearer "+token)
+	}
+
+	return sendJSON(method, c.baseURL(), path, header, body, out)
+}
+
+// currentUser returns the user the token belongs to.
+func (c *BitbucketClient) currentUser(token string) (bitbucketUser, error) {
+	var user bitbucketUser
+	err := c.do(http.MethodGet, "/user", token, nil, &user)
+	return user, err
+}
+
+// findUser looks up a user by UUID or account ID. Bitbucket no longer looks up users by username,
+// so a username is only found if it's the one of the user the client's token belongs to.
+func (c *BitbucketClient) findUser(username string) (bitbucketUser, error) {
+	if c.Token == "" {
+		return bitbucketUser{}, errBitbucketNoCredentials
+	}
+
+	if !strings.HasPrefix(username, "{") {
+		current, err := c.currentUser(c.Token)
+		if err != nil {
+			return bitbucketUser{}, err
+		}
+		if strings.EqualFold(current.Username, username) || current.AccountID == username {
+			return current, nil
+		}
+	}
+
+	var user bitbucketUser
+	err := c.do(http.MethodGet, "/users/"+url.PathEscape(username), c.Token, nil, &user)
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusNotFound {
+		return bitbucketUser{}, errors.New("username not found")
+	}
+
+	return user, err
+}
+
+// listKeys lists the SSH keys of the user with the provided UUID, following the pages of the list.
+func (c *BitbucketClient) listKeys(token, uuid string) ([]bitbucketKey, error) {
+	var keys []bitbucketKey
+	for path := "/users/" + url.PathEscape(uuid) + "/ssh-keys?pagelen=100"; path != ""; {
+		var page struct {
+			Values []bitbucketKey `json:"values"`
+			// Next is the URL of the next page, empty on the last page.
+			Next string `json:"next"`
+		}
+		if err := c.do(http.MethodGet, path, token, nil, &page); err != nil {
+			return nil, err
+		}
+		keys = append(keys, page.Values...)
+
+		path = ""
+		if strings.HasPrefix(page.Next, c.baseURL()+"/") {
+			path = strings.TrimPrefix(page.Next, c.baseURL())
+		}
+	}
+
+	return keys, nil
+}
+
+// FetchPublicKeys fetches the public SSH keys of a Bitbucket user, by username, UUID or account ID.
+func (c *BitbucketClient) FetchPublicKeys(username string) ([]string, error) {
+	user, err := c.findUser(username)
+	if err != nil {
+		return nil, fmt.Errorf("could not fetch public keys: %w", err)
+	}
+
+	keys, err := c.listKeys(c.Token, user.UUID)
+	if err != nil {
+		return nil, fmt.Errorf("could not fetch public keys: %w", err)
+	}
+
+	publicKeys := make([]string, len(keys))
+	for i, key := range keys {
+		publicKeys[i] = key.Key
+	}
+
+	return publicKeys, nil
+}
+
+// IsGithubUsernameValid checks if a user exists on Bitbucket.
+func (c *BitbucketClient) IsGithubUsernameValid(username string) error {
+	_, err := c.findUser(username)
+	return err
+}
+
+// AuthenticatedUser returns the username of the user the token belongs to.
+func (c *BitbucketClient) AuthenticatedUser(token string) (string, error) {
+	user, err := c.currentUser(token)
+	return user.Username, err
+}
+
+// publicKey converts a key of the API, deriving its ID from its UUID so that it stays the same across runs, e.g. for
+// a key rotation removing the old key after being resumed.
+func (c *BitbucketClient) publicKey(key bitbucketKey) PublicKey {
+	if c.keyUUIDs == nil {
+		c.keyUUIDs = map[int64]string{}
+	}
+
+	hash := fnv.New64a()
+	hash.Write([]byte(key.UUID))
+	id := int64(hash.Sum64() & math.MaxInt64)
+	c.keyUUIDs[id] = key.UUID
+
+	return PublicKey{ID: id, Key: key.Key, Title: key.Label}
+}
+
+// AddPublicKey registers an SSH key for the user the token belongs to.
+func (c *BitbucketClient) AddPublicKey(token, title, key string) (PublicKey, error) {
+	user, err := c.currentUser(token)
+	if err != nil {
+		return PublicKey{}, err
+	}
+
+	var added bitbucketKey
+	err = c.do(http.MethodPost, "/users/"+url.PathEscape(user.UUID)+"/ssh-keys", token, map[string]string{"key": key, "label": title}, &added)
+	if err != nil {
+		return PublicKey{}, err
+	}
+
+	return c.publicKey(added), nil
+}
+
+// AddSigningKey makes the key verify the SSH signatures of the user the token belongs to. Bitbucket verifies
+// signatures with the user's SSH keys, so the key is only added if it isn't yet.
+func (c *BitbucketClient) AddSigningKey(token, title, key string) (PublicKey, error) {
+	return addKeyIfMissing(c, token, title, key)
+}
+
+// ListOwnPublicKeys lists the SSH keys of the user the token belongs to.
+func (c *BitbucketClient) ListOwnPublicKeys(token string) ([]PublicKey, error) {
+	user, err := c.currentUser(token)
+	if err != nil {
+		return nil, err
+	}
+
+	keys, err := c.listKeys(token, user.UUID)
+	if err != nil {
+		return nil, err
+	}
+
+	publicKeys := make([]PublicKey, len(keys))
+	for i, key := range keys {
+		publicKeys[i] = c.publicKey(key)
+	}
+
+	return publicKeys, nil
+}
+
+// DeletePublicKey removes an SSH key of the user the token belongs to, by the ID ListOwnPublicKeys or AddPublicKey
+// returned for it.
+func (c *BitbucketClient) DeletePublicKey(token string, id int64) error {
+	user, err := c.currentUser(token)
+	if err != nil {
+		return err
+	}
+
+	uuid, ok := c.keyUUIDs[id]
+	if !ok {
+		// The ID comes from an earlier run, the keys have to be listed to find its UUID.
+		keys, err := c.listKeys(token, user.UUID)
+		if err != nil {
+			return err
+		}
+		for _, key := range keys {
+			c.publicKey(key)
+		}
+		if uuid, ok = c.keyUUIDs[id]; !ok {
+			return &StatusError{StatusCode: http.StatusNotFound, message: fmt.Sprintf("key %d not found", id)}
+		}
+	}
+
+	return c.do(http.MethodDelete, "/users/"+url.PathEscape(user.UUID)+"/ssh-keys/"+url.PathEscape(uuid), token, nil, nil)
+}
+
+// ListOwnSigningKeys returns no keys, as Bitbucket verifies signatures with the keys of ListOwnPublicKeys.
+func (c *BitbucketClient) ListOwnSigningKeys(token string) ([]PublicKey, error) {
+	return nil, nil
+}
+
+// DeleteSigningKey removes an SSH key of the user the token belongs to, like DeletePublicKey.
+func (c *BitbucketClient) DeleteSigningKey(token string, id int64) error {
+	return c.DeletePublicKey(token, id)
+}
@@ -0,0 +1,111 @@
+package git
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// fakeBitbucket serves the users and SSH keys endpoints of the Bitbucket API for john, who authenticates with
+// the app password "john:secret". Users are only shown to authenticated requests.
+func fakeBitbucket(t *testing.T) (*httptest.Server, *[]bitbucketKey) {
+	t.Helper()
+	const uuid = "{0b0e1a2c}"
+	keys := &[]bitbucketKey{{UUID: "{k1}", Key: "ssh-ed25519 AAAA1", Label: "laptop"}}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if username, password, ok := r.BasicAuth(); !ok || username != "john" || password != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		switch {
+		case r.URL.Path == "/2.0/user":
+			w.Write([]byte(`{"uuid":"` + uuid + `","username":"john","account_id":"557058:1"}`))
+		case r.URL.Path == "/2.0/users/{jane}":
+			w.Write([]byte(`{"uuid":"{jane}","username":"jane","account_id":"557058:2"}`))
+		case r.Method == http.MethodGet && r.URL.Path == "/2.0/users/"+uuid+"/ssh-keys":
+			json.NewEncoder(w).Encode(map[string]interface{}{"values": *keys})
+		case r.Method == http.MethodPost && r.URL.Path == "/2.0/users/"+uuid+"/ssh-keys":
+			var key bitbucketKey
+			json.NewDecoder(r.Body).Decode(&key)
+			key.UUID = "{k2}"
+			*keys = append(*keys, key)
+			w.WriteHeader(http.StatusCreated)
+			json.NewEncoder(w).Encode(key)
+		case r.Method == http.MethodDelete && r.URL.Path == "/2.0/users/"+uuid+"/ssh-keys/{k1}":
+			*keys = (*keys)[1:]
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	return server, keys
+}
+
+func TestBitbucketUsersAndKeys(t *testing.T) {
+	server, _ := fakeBitbucket(t)
+	client := &BitbucketClient{BaseURL: server.URL + "/2.0", Token: "john:secret"}
+
+	for _, username := range []string{"john", "557058:1", "{jane}"} {
+		if err := client.IsGithubUsernameValid(username); err != nil {
+			t.Errorf("Expected '%s' to be valid, but got: %v", username, err)
+		}
+	}
+	if err := client.IsGithubUsernameValid("{nobody}"); err == nil || err.Error() != "username not found" {
+		t.Errorf("Expected 'username not found', but got: %v", err)
+	}
+
+	keys, err := client.FetchPublicKeys("John")
+	if err != nil {
+		t.Fatalf("Did not expect an error, but got: %v", err)
+	}
+	if want := []string{"ssh-ed25519 AAAA1"}; !reflect.DeepEqual(keys, want) {
+		t.Errorf("Expected %v, but got %v", want, keys)
+	}
+
+	if err := NewBitbucketClient("").IsGithubUsernameValid("john"); err != errBitbucketNoCredentials {
+		t.Errorf("Expected an error without credentials, but got: %v", err)
+	}
+}
+
+func TestBitbucketOwnKeys(t *testing.T) {
+	server, keys := fakeBitbucket(t)
+	client := &BitbucketClient{BaseURL: server.URL + "/2.0"}
+
+	if login, err := client.AuthenticatedUser("john:secret"); err != nil || login != "john" {
+		t.Errorf("Expected login 'john', but got '%s', %v", login, err)
+	}
+
+	added, err := client.AddPublicKey("john:secret", "gas@laptop", "ssh-ed25519 AAAA2")
+	if err != nil || added.Title != "gas@laptop" {
+		t.Fatalf("Expected the key to be added, but got %+v, %v", added, err)
+	}
+
+	signing, err := client.AddSigningKey("john:secret", "gas@laptop", "ssh-ed25519 AAAA2")
+	if err != nil || signing.ID != added.ID || len(*keys) != 2 {
+		t.Errorf("Expected the existing key to be used for signing, but got %+v, %v with keys %+v", signing, err, *keys)
+	}
+
+	own, err := client.ListOwnPublicKeys("john:secret")
+	if err != nil || len(own) != 2 {
+		t.Fatalf("Expected 2 keys, but got %+v, %v", own, err)
+	}
+	// IDs stay valid for another client, e.g. when a key rotation is resumed.
+	if err := (&BitbucketClient{BaseURL: client.BaseURL}).DeletePublicKey("john:secret", own[0].ID); err != nil || len(*keys) != 1 {
+		t.Errorf("Expected the key to be deleted, but got %v with keys %+v", err, *keys)
+	}
+	if err := client.DeletePublicKey("john:secret", 42); err == nil {
+		t.Errorf("Expected an error for an unknown key, but got none")
+	}
+
+	_, err = client.AddPublicKey("jane:wrong", "gas@laptop", "ssh-ed25519 AAAA3")
+	if err == nil || !strings.Contains(err.Error(), "401") {
+		t.Errorf("Expected an unauthorized error, but got: %v", err)
+	}
+}
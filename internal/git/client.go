@@ -5,18 +5,50 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"os"
+	"strings"
 )
 
+// DefaultGitHubAPIURL is the API of github.com.
+const DefaultGitHubAPIURL = "https://api.github.com"
+
+// GitHubClient looks up users and their public keys on a forge. Every provider implements it, see Forge.
 type GitHubClient interface {
 	FetchPublicKeys(username string) ([]string, error)
 	IsGithubUsernameValid(username string) error
 }
 
-type RealGitHubClient struct{}
+// KeyUploader registers SSH keys for the user an API token belongs to.
+type KeyUploader interface {
+	AddPublicKey(token, title, key string) (PublicKey, error)
+	AddSigningKey(token, title, key string) (PublicKey, error)
+}
+
+type RealGitHubClient struct {
+	// BaseURL of the API, defaults to DefaultGitHubAPIURL.
+	BaseURL string
+}
+
+// NewGitHubClient returns a client for the API of the provided host, either github.com or a GitHub Enterprise Server.
+func NewGitHubClient(host string) *RealGitHubClient {
+	if host == "" || host == "github.com" || host == "ssh.github.com" {
+		return &RealGitHubClient{}
+	}
+
+	return &RealGitHubClient{BaseURL: fmt.Sprintf("https://%s/api/v3", host)}
+}
+
+func (c *RealGitHubClient) baseURL() string {
+	if c.BaseURL == "" {
+		return DefaultGitHubAPIURL
+	}
+
+	return strings.TrimSuffix(c.BaseURL, "/")
+}
 
 // fetchGitHubPublicKeys fetches the public keys of a github user.
 func (c *RealGitHubClient) FetchPublicKeys(username string) ([]string, error) {
-	url := fmt.Sprintf("https://api.github.com/users/%s/keys", username)
+	url := fmt.Sprintf("%s/users/%s/keys", c.baseURL(), username)
 	resp, err := http.Get(url)
 	if err != nil {
 		return nil, err
@@ -45,7 +77,7 @@ func (c *RealGitHubClient) FetchPublicKeys(username string) ([]string, error) {
 
 // isGithubUsernameValid checks if a username exists on github.
 func (c *RealGitHubClient) IsGithubUsernameValid(username string) error {
-	url := fmt.Sprintf("https://api.github.com/users/%s", username)
+	url := fmt.Sprintf("%s/users/%s", c.baseURL(), username)
 	resp, err := http.Get(url)
 	if err != nil {
 		return errors.New("could not check if username is valid")
@@ -58,3 +90,123 @@ func (c *RealGitHubClient) IsGithubUsernameValid(username string) error {
 
 	return nil
 }
+
+// FetchSSHHostKeys fetches the SSH host keys the server publishes in its meta endpoint.
+func (c *RealGitHubClient) FetchSSHHostKeys() ([]string, error) {
+	resp, err := http.Get(c.baseURL() + "/meta")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("could not fetch host keys from %s/meta: %s", c.baseURL(), resp.Status)
+	}
+
+	var meta struct {
+		SSHKeys []string `json:"ssh_keys"`
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&meta); err != nil {
+		return nil, err
+	}
+
+	if len(meta.SSHKeys) == 0 {
+		return nil, fmt.Errorf("%s/meta does not publish any SSH host keys", c.baseURL())
+	}
+
+	return meta.SSHKeys, nil
+}
+
+// PublicKey is an SSH key registered on the forge for the authenticated user.
+type PublicKey struct {
+	ID    int64  `json:"id"`
+	Key   string `json:"key"`
+	Title string `json:"title"`
+	// UsageType is what GitLab lets the key do: auth, signing or auth_and_signing.
+	UsageType string `json:"usage_type,omitempty"`
+}
+
+// do sends an authenticated API request, decoding the JSON response into out when it's not nil.
+func (c *RealGitHubClient) do(method, path, token string, body, out interface{}) error {
+	return sendJSON(method, c.baseURL(), path, c.header(token), body, out)
+}
+
+// header returns the headers of requests authenticated with token.
+func (c *RealGitHubClient) header(token string) http.Header {
+	header := http.Header{}
+	header.Set("Accept", "application/vnd.github+json")
+	header.Set("Authorization", "Bearer "+token)
+
+	return header
+}
+
+// AuthenticatedUser returns the login of the user the token belongs to.
+func (c *RealGitHubClient) AuthenticatedUser(token string) (string, error) {
+	var user struct {
+		Login string `json:"login"`
+	}
+	err := c.do(http.MethodGet, "/user", token, nil, &user)
+	return user.Login, err
+}
+
+// AddPublicKey registers an SSH authentication key for the user the token belongs to.
+func (c *RealGitHubClient) AddPublicKey(token, title, key string) (PublicKey, error) {
+	var added PublicKey
+	err := c.do(http.MethodPost, "/user/keys", token, map[string]string{"title": title, "key": key}, &added)
+	return added, err
+}
+
+// AddSigningKey registers an SSH signing key for the user the token belongs to.
+func (c *RealGitHubClient) AddSigningKey(token, title, key string) (PublicKey, error) {
+	var added PublicKey
+	err := c.do(http.MethodPost, "/user/ssh_signing_keys", token, map[string]string{"title": title, "key": key}, &added)
+	return added, err
+}
+
+// ListOwnPublicKeys lists the SSH authentication keys of the user the token belongs to, following the pages of the
+// list.
+func (c *RealGitHubClient) ListOwnPublicKeys(token string) ([]PublicKey, error) {
+	return c.listPages("/user/keys?per_page=100", token)
+}
+
+// ListOwnSigningKeys lists the SSH signing keys of the user the token belongs to, following the pages of the list.
+func (c *RealGitHubClient) ListOwnSigningKeys(token string) ([]PublicKey, error) {
+	return c.listPages("/user/ssh_signing_keys?per_page=100", token)
+}
+
+// listPages lists the keys of path and of the pages linked from it.
+func (c *RealGitHubClient) listPages(path, token string) ([]PublicKey, error) {
+	var keys []PublicKey
+	for path != "" {
+		var page []PublicKey
+		header, err := send(http.MethodGet, c.baseURL(), path, c.header(token), nil, &page)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, page...)
+		path = nextLink(header, c.baseURL())
+	}
+
+	return keys, nil
+}
+
+// DeletePublicKey removes an SSH authentication key of the user the token belongs to.
+func (c *RealGitHubClient) DeletePublicKey(token string, id int64) error {
+	return c.do(http.MethodDelete, fmt.Sprintf("/user/keys/%d", id), token, nil, nil)
+}
+
+// DeleteSigningKey removes an SSH signing key of the user the token belongs to.
+func (c *RealGitHubClient) DeleteSigningKey(token string, id int64) error {
+	return c.do(http.MethodDelete, fmt.Sprintf("/user/ssh_signing_keys/%d", id), token, nil, nil)
+}
+
+// KeyTitle returns the title of keys uploaded by gas, naming the machine they were uploaded from.
+func KeyTitle() string {
+	hostname, err := os.Hostname()
+	if err != nil || hostname == "" {
+		hostname = "unknown"
+	}
+
+	return "gas@" + hostname
+}
@@ -0,0 +1,107 @@
+package git
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/style77/gas/internal/git/gittest"
+)
+
+func TestFetchSSHHostKeys(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v3/meta" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(`{"verifiable_password_authentication":false,"ssh_keys":["ssh-ed25519 AAAA1","ecdsa-sha2-nistp256 AAAA2"]}`))
+	}))
+	defer server.Close()
+
+	client := &RealGitHubClient{BaseURL: server.URL + "/api/v3/"}
+	keys, err := client.FetchSSHHostKeys()
+	if err != nil {
+		t.Fatalf("Did not expect an error, but got: %v", err)
+	}
+	if want := []string{"ssh-ed25519 AAAA1", "ecdsa-sha2-nistp256 AAAA2"}; !reflect.DeepEqual(keys, want) {
+		t.Errorf("Expected %v, but got %v", want, keys)
+	}
+
+	client = &RealGitHubClient{BaseURL: server.URL}
+	if _, err := client.FetchSSHHostKeys(); err == nil {
+		t.Errorf("Expected an error for a missing meta endpoint, but got none")
+	}
+}
+
+func TestNewGitHubClient(t *testing.T) {
+	if got := NewGitHubClient("github.com").baseURL(); got != DefaultGitHubAPIURL {
+		t.Errorf("Expected '%s', but got '%s'", DefaultGitHubAPIURL, got)
+	}
+	if got := NewGitHubClient("github.example.com").baseURL(); got != "https://github.example.com/api/v3" {
+		t.Errorf("Expected the GitHub Enterprise API, but got '%s'", got)
+	}
+}
+
+func TestPublicKeys(t *testing.T) {
+	fake, server := gittest.NewGitHub(t, "john", "secret")
+	client := &RealGitHubClient{BaseURL: server.URL}
+
+	if login, err := client.AuthenticatedUser("secret"); err != nil || login != "john" {
+		t.Errorf("Expected login 'john', but got '%s', %v", login, err)
+	}
+
+	added, err := client.AddPublicKey("secret", "gas@laptop", "ssh-ed25519 AAAA1")
+	if err != nil {
+		t.Fatalf("Did not expect an error, but got: %v", err)
+	}
+	if added.ID != 1 || added.Title != "gas@laptop" {
+		t.Errorf("Unexpected key: %+v", added)
+	}
+
+	listed, err := client.ListOwnPublicKeys("secret")
+	if err != nil || len(listed) != 1 || listed[0].Key != "ssh-ed25519 AAAA1" {
+		t.Errorf("Expected the added key to be listed, but got %+v, %v", listed, err)
+	}
+
+	for _, key := range []string{"ssh-ed25519 AAAA2", "ssh-ed25519 AAAA3", "ssh-ed25519 AAAA4", "ssh-ed25519 AAAA5"} {
+		if _, err := client.AddPublicKey("secret", "gas@laptop", key); err != nil {
+			t.Fatalf("Did not expect an error, but got: %v", err)
+		}
+	}
+	listed, err = client.ListOwnPublicKeys("secret")
+	if err != nil || len(listed) != 5 || listed[4].Key != "ssh-ed25519 AAAA5" {
+		t.Errorf("Expected every page of keys to be listed, but got %+v, %v", listed, err)
+	}
+
+	for _, key := range listed {
+		if err := client.DeletePublicKey("secret", key.ID); err != nil {
+			t.Errorf("Did not expect an error, but got: %v", err)
+		}
+	}
+	if len(fake.Keys) != 0 {
+		t.Errorf("Expected the key to be deleted, but got %+v", fake.Keys)
+	}
+
+	signing, err := client.AddSigningKey("secret", "gas@laptop", "ssh-ed25519 AAAA6")
+	if err != nil || signing.ID != 100 {
+		t.Errorf("Expected the signing key to be added, but got %+v, %v", signing, err)
+	}
+	if len(fake.Keys) != 0 {
+		t.Errorf("Expected signing keys to be kept apart from authentication keys, but got %+v", fake.Keys)
+	}
+
+	signingKeys, err := client.ListOwnSigningKeys("secret")
+	if err != nil || len(signingKeys) != 1 || signingKeys[0].Key != "ssh-ed25519 AAAA6" {
+		t.Errorf("Expected the signing key to be listed, but got %+v, %v", signingKeys, err)
+	}
+	if err := client.DeleteSigningKey("secret", signing.ID); err != nil || len(fake.SigningKeys) != 0 {
+		t.Errorf("Expected the signing key to be deleted, but got %v with keys %+v", err, fake.SigningKeys)
+	}
+
+	_, err = client.AddPublicKey("wrong", "gas@laptop", "ssh-ed25519 AAAA1")
+	if err == nil || !strings.Contains(err.Error(), "Bad credentials") {
+		t.Errorf("Expected a bad credentials error, but got: %v", err)
+	}
+}
@@ -0,0 +1,55 @@
+package git
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// ConfigScope selects which git configuration file is read or written.
+type ConfigScope string
+
+const (
+	GlobalScope ConfigScope = "--global"
+	LocalScope  ConfigScope = "--local"
+)
+
+// GetConfig returns the value of key in the provided scope, or an empty string if it is not set.
+func GetConfig(scope ConfigScope, key string) string {
+	value, _ := exec.Command(Binary, "config", string(scope), "--get", key).Output()
+	return strings.TrimSpace(string(value))
+}
+
+// SetConfig sets key to value in the provided scope.
+func SetConfig(scope ConfigScope, key, value string) error {
+	err := exec.Command(Binary, "config", string(scope), key, value).Run()
+	if err != nil {
+		return fmt.Errorf("failed to set %s", key)
+	}
+
+	return nil
+}
+
+// AddConfig adds value to the multi-valued key in the provided scope.
+func AddConfig(scope ConfigScope, key, value string) error {
+	err := exec.Command(Binary, "config", string(scope), "--add", key, value).Run()
+	if err != nil {
+		return fmt.Errorf("failed to add %s", key)
+	}
+
+	return nil
+}
+
+// UnsetConfig removes key from the provided scope. Removing a key that is not set is not an error.
+func UnsetConfig(scope ConfigScope, key string) error {
+	err := exec.Command(Binary, "config", string(scope), "--unset-all", key).Run()
+	if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() == 5 {
+		// exit code 5 means the key was not set
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to unset %s", key)
+	}
+
+	return nil
+}
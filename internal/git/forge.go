@@ -0,0 +1,236 @@
+package git
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// Forge providers gas can talk to.
+const (
+	GitHubProvider = "github"
+	GitLabProvider = "gitlab"
+	// GiteaProvider also covers Forgejo, which kept Gitea's API.
+	GiteaProvider = "gitea"
+	// BitbucketProvider is Bitbucket Cloud.
+	BitbucketProvider = "bitbucket"
+)
+
+// Providers lists the supported forge providers, the default first.
+var Providers = []string{GitHubProvider, GitLabProvider, GiteaProvider, BitbucketProvider}
+
+// Forge is the API of a forge provider, used to check users and to manage the SSH keys of the authenticated user.
+type Forge interface {
+	GitHubClient
+	KeyUploader
+	AuthenticatedUser(token string) (string, error)
+	ListOwnPublicKeys(token string) ([]PublicKey, error)
+	DeletePublicKey(token string, id int64) error
+	// ListOwnSigningKeys lists the SSH signing keys kept apart from the keys of ListOwnPublicKeys. Forges verifying
+	// signatures with the keys of ListOwnPublicKeys have none.
+	ListOwnSigningKeys(token string) ([]PublicKey, error)
+	DeleteSigningKey(token string, id int64) error
+}
+
+// addKeyIfMissing registers key with AddPublicKey unless the user the token belongs to has it already. It adds
+// signing keys on forges verifying SSH signatures with the user's SSH keys.
+func addKeyIfMissing(forge Forge, token, title, key string) (PublicKey, error) {
+	keys, err := forge.ListOwnPublicKeys(token)
+	if err != nil {
+		return PublicKey{}, err
+	}
+
+	for _, existing := range keys {
+		if sameKey(existing.Key, key) {
+			return existing, nil
+		}
+	}
+
+	return forge.AddPublicKey(token, title, key)
+}
+
+// sameKey checks if two keys in the authorized_keys format have the same key data, ignoring their comments.
+func sameKey(a, b string) bool {
+	fieldsA, fieldsB := strings.Fields(a), strings.Fields(b)
+	return len(fieldsA) > 1 && len(fieldsB) > 1 && fieldsA[1] == fieldsB[1]
+}
+
+// NormalizeProvider returns the provider for a configured value, GitHub when it's empty.
+func NormalizeProvider(provider string) (string, error) {
+	provider = strings.ToLower(strings.TrimSpace(provider))
+	switch provider {
+	case "":
+		return GitHubProvider, nil
+	case "forgejo":
+		return GiteaProvider, nil
+	}
+
+	for _, known := range Providers {
+		if provider == known {
+			return provider, nil
+		}
+	}
+
+	return "", fmt.Errorf("unsupported forge provider '%s', use one of: %s", provider, strings.Join(Providers, ", "))
+}
+
+// ProviderName returns the name of the provider shown to users.
+func ProviderName(provider string) string {
+	switch provider {
+	case GitLabProvider:
+		return "GitLab"
+	case GiteaProvider:
+		return "Gitea"
+	case BitbucketProvider:
+		return "Bitbucket"
+	default:
+		return "GitHub"
+	}
+}
+
+// DefaultHost returns the host of the public instance of the provider.
+func DefaultHost(provider string) string {
+	switch provider {
+	case GitLabProvider:
+		return "gitlab.com"
+	case GiteaProvider:
+		return "codeberg.org"
+	case BitbucketProvider:
+		return "bitbucket.org"
+	default:
+		return "github.com"
+	}
+}
+
+// NewForge returns a client for the API of the provider at host, which may be a self-hosted instance.
+// Bitbucket is only supported on bitbucket.org, and its client needs credentials to look up users.
+func NewForge(provider, host string) (Forge, error) {
+	provider, err := NormalizeProvider(provider)
+	if err != nil {
+		return nil, err
+	}
+
+	switch provider {
+	case GitLabProvider:
+		return NewGitLabClient(host), nil
+	case GiteaProvider:
+		return NewGiteaClient(host), nil
+	case BitbucketProvider:
+		return NewBitbucketClient(""), nil
+	default:
+		return NewGitHubClient(host), nil
+	}
+}
+
+// NewForgeAt returns a client for the API of the provider's instance at baseURL, the address of its web interface
+// like https://codeberg.org or https://example.com/git for an instance served under a path.
+func NewForgeAt(provider, baseURL string) (Forge, error) {
+	provider, err := NormalizeProvider(provider)
+	if err != nil {
+		return nil, err
+	}
+
+	parsed, err := url.Parse(baseURL)
+	if err != nil || parsed.Host == "" || (parsed.Scheme != "https" && parsed.Scheme != "http") {
+		return nil, fmt.Errorf("invalid base URL '%s', use an address like https://git.example.com", baseURL)
+	}
+	baseURL = strings.TrimSuffix(baseURL, "/")
+
+	switch provider {
+	case GitLabProvider:
+		return &GitLabClient{BaseURL: baseURL + "/api/v4"}, nil
+	case GiteaProvider:
+		return &GiteaClient{BaseURL: baseURL + "/api/v1"}, nil
+	case BitbucketProvider:
+		return nil, fmt.Errorf("Bitbucket is only supported on bitbucket.org, remove the base URL '%s'", baseURL)
+	default:
+		if parsed.Host == "github.com" {
+			return &RealGitHubClient{}, nil
+		}
+		return &RealGitHubClient{BaseURL: baseURL + "/api/v3"}, nil
+	}
+}
+
+// StatusError is returned for API requests the forge answered with an error status.
+type StatusError struct {
+	StatusCode int
+	message    string
+}
+
+func (e *StatusError) Error() string {
+	return e.message
+}
+
+// sendJSON sends an API request with a JSON body, decoding the JSON response into out when it's not nil.
+func sendJSON(method, baseURL, path string, header http.Header, body, out interface{}) error {
+	_, err := send(method, baseURL, path, header, body, out)
+	return err
+}
+
+// send works like sendJSON, but also returns the headers of the response, e.g. to find the next page of a list.
+func send(method, baseURL, path string, header http.Header, body, out interface{}) (http.Header, error) {
+	var reader io.Reader
+	if body != nil {
+		content, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reader = bytes.NewReader(content)
+	}
+
+	req, err := http.NewRequest(method, baseURL+path, reader)
+	if err != nil {
+		return nil, err
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		// GitHub sends the message as a string, GitLab also as an object of failed fields.
+		var apiError struct {
+			Message interface{} `json:"message"`
+		}
+		json.NewDecoder(resp.Body).Decode(&apiError)
+		message := fmt.Sprintf("%s %s failed: %s", method, path, resp.Status)
+		if apiError.Message != nil && apiError.Message != "" {
+			message += fmt.Sprintf(" (%v)", apiError.Message)
+		}
+		return nil, &StatusError{StatusCode: resp.StatusCode, message: message}
+	}
+
+	if out == nil {
+		return resp.Header, nil
+	}
+
+	return resp.Header, json.NewDecoder(resp.Body).Decode(out)
+}
+
+// linkPattern matches the next page in a Link header, like `<https://api.github.com/user/keys?page=2>; rel="next"`.
+var linkPattern = regexp.MustCompile(`<([^>]+)>;\s*rel="next"`)
+
+// nextLink returns the path of the next page of a list from the Link header of a response, relative to baseURL.
+// It returns an empty string on the last page.
+func nextLink(header http.Header, baseURL string) string {
+	for _, link := range header.Values("Link") {
+		if matches := linkPattern.FindStringSubmatch(link); matches != nil && strings.HasPrefix(matches[1], baseURL+"/") {
+			return strings.TrimPrefix(matches[1], baseURL)
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,134 @@
+package git
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// DefaultGiteaAPIURL is the API of codeberg.org, the largest public Forgejo instance.
+const DefaultGiteaAPIURL = "https://codeberg.org/api/v1"
+
+// GiteaClient is the client of the REST API of Gitea and its fork Forgejo, which share it.
+type GiteaClient struct {
+	// BaseURL of the API, defaults to DefaultGiteaAPIURL.
+	BaseURL string
+}
+
+// NewGiteaClient returns a client for the API of the Gitea or Forgejo instance at the provided host.
+func NewGiteaClient(host string) *GiteaClient {
+	if host == "" || host == "codeberg.org" {
+		return &GiteaClient{}
+	}
+
+	return &GiteaClient{BaseURL: fmt.Sprintf("https://%s/api/v1", host)}
+}
+
+func (c *GiteaClient) baseURL() string {
+	if c.BaseURL == "" {
+		return DefaultGiteaAPIURL
+	}
+
+	return strings.TrimSuffix(c.BaseURL, "/")
+}
+
+// FetchPublicKeys fetches the public SSH keys of a Gitea user.
+func (c *GiteaClient) FetchPublicKeys(username string) ([]string, error) {
+	keys, err := c.listKeys("/users/"+url.PathEscape(username)+"/keys", "")
+	if err != nil {
+		return nil, fmt.Errorf("could not fetch public keys: %w", err)
+	}
+
+	publicKeys := make([]string, len(keys))
+	for i, key := range keys {
+		publicKeys[i] = key.Key
+	}
+
+	return publicKeys, nil
+}
+
+// IsGithubUsernameValid checks if a username exists on the Gitea instance.
+func (c *GiteaClient) IsGithubUsernameValid(username string) error {
+	resp, err := http.Get(c.baseURL() + "/users/" + url.PathEscape(username))
+	if err != nil {
+		return errors.New("could not check if username is valid")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return errors.New("username not found")
+	}
+
+	return nil
+}
+
+// do sends an API request authenticated by token unless it's empty, decoding the JSON response into out when it's
+// not nil.
+func (c *GiteaClient) do(method, path, token string, body, out interface{}) error {
+	header := http.Header{}
+	if token != "" {
+		header.Set("Authorization", "token "+token)
+	}
+
+	return sendJSON(method, c.baseURL(), path, header, body, out)
+}
+
+// AuthenticatedUser returns the login of the user the token belongs to.
+func (c *GiteaClient) AuthenticatedUser(token string) (string, error) {
+	var user struct {
+		Login string `json:"login"`
+	}
+	err := c.do(http.MethodGet, "/user", token, nil, &user)
+	return user.Login, err
+}
+
+// AddPublicKey registers an SSH key for the user the token belongs to.
+func (c *GiteaClient) AddPublicKey(token, title, key string) (PublicKey, error) {
+	var added PublicKey
+	err := c.do(http.MethodPost, "/user/keys", token, map[string]string{"title": title, "key": key}, &added)
+	return added, err
+}
+
+// AddSigningKey makes the key verify the SSH signatures of the user the token belongs to. Gitea has no separate
+// signing keys and verifies signatures with the user's SSH keys, so the key is only added if it isn't yet.
+func (c *GiteaClient) AddSigningKey(token, title, key string) (PublicKey, error) {
+	return addKeyIfMissing(c, token, title, key)
+}
+
+// ListOwnPublicKeys lists the SSH keys of the user the token belongs to, following the pages of the list.
+func (c *GiteaClient) ListOwnPublicKeys(token string) ([]PublicKey, error) {
+	return c.listKeys("/user/keys", token)
+}
+
+// listKeys lists the keys of path, following the pages of the list. Pages are requested until an empty one, as the
+// instance's MAX_RESPONSE_ITEMS can make them smaller than the limit.
+func (c *GiteaClient) listKeys(path, token string) ([]PublicKey, error) {
+	var keys []PublicKey
+	for page := 1; ; page++ {
+		var pageKeys []PublicKey
+		if err := c.do(http.MethodGet, fmt.Sprintf("%s?limit=50&page=%d", path, page), token, nil, &pageKeys); err != nil {
+			return nil, err
+		}
+		if len(pageKeys) == 0 {
+			return keys, nil
+		}
+		keys = append(keys, pageKeys...)
+	}
+}
+
+// DeletePublicKey removes an SSH key of the user the token belongs to.
+func (c *GiteaClient) DeletePublicKey(token string, id int64) error {
+	return c.do(http.MethodDelete, fmt.Sprintf("/user/keys/%d", id), token, nil, nil)
+}
+
+// ListOwnSigningKeys returns no keys, as Gitea verifies signatures with the keys of ListOwnPublicKeys.
+func (c *GiteaClient) ListOwnSigningKeys(token string) ([]PublicKey, error) {
+	return nil, nil
+}
+
+// DeleteSigningKey removes an SSH key of the user the token belongs to, like DeletePublicKey.
+func (c *GiteaClient) DeleteSigningKey(token string, id int64) error {
+	return c.DeletePublicKey(token, id)
+}
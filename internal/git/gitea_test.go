@@ -0,0 +1,160 @@
+package git
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// fakeGitea serves the users and keys endpoints of the Gitea API of an instance under /forgejo for a single token.
+func fakeGitea(t *testing.T, token string) (*httptest.Server, *[]PublicKey) {
+	t.Helper()
+	keys := &[]PublicKey{{ID: 1, Title: "laptop", Key: "ssh-ed25519 AAAA1 john@laptop"}}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/forgejo/api/v1/users/john":
+			w.Write([]byte(`{"id":7,"login":"john"}`))
+		case r.URL.Path == "/forgejo/api/v1/users/john/keys":
+			writeGiteaPage(w, r, *keys)
+		case r.URL.Path == "/forgejo/api/v1/user" || strings.HasPrefix(r.URL.Path, "/forgejo/api/v1/user/"):
+			if r.Header.Get("Authorization") != "token "+token {
+				w.WriteHeader(http.StatusUnauthorized)
+				w.Write([]byte(`{"message":"token is required"}`))
+				return
+			}
+			switch {
+			case r.Method == http.MethodGet && r.URL.Path == "/forgejo/api/v1/user":
+				w.Write([]byte(`{"id":7,"login":"john"}`))
+			case r.Method == http.MethodGet && r.URL.Path == "/forgejo/api/v1/user/keys":
+				writeGiteaPage(w, r, *keys)
+			case r.Method == http.MethodPost && r.URL.Path == "/forgejo/api/v1/user/keys":
+				var body struct{ Title, Key string }
+				json.NewDecoder(r.Body).Decode(&body)
+				key := PublicKey{ID: int64(len(*keys) + 1), Title: body.Title, Key: body.Key}
+				*keys = append(*keys, key)
+				w.WriteHeader(http.StatusCreated)
+				json.NewEncoder(w).Encode(key)
+			case r.Method == http.MethodDelete && r.URL.Path == "/forgejo/api/v1/user/keys/1":
+				*keys = (*keys)[1:]
+				w.WriteHeader(http.StatusNoContent)
+			default:
+				http.NotFound(w, r)
+			}
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	return server, keys
+}
+
+// writeGiteaPage writes a single key per page, like an instance with MAX_RESPONSE_ITEMS = 1.
+func writeGiteaPage(w http.ResponseWriter, r *http.Request, keys []PublicKey) {
+	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+	if page < 1 {
+		page = 1
+	}
+	json.NewEncoder(w).Encode(keys[min(page-1, len(keys)):min(page, len(keys))])
+}
+
+func TestGiteaUsersAndKeys(t *testing.T) {
+	server, registered := fakeGitea(t, "secret")
+	*registered = append(*registered, PublicKey{ID: 2, Title: "desktop", Key: "ssh-ed25519 AAAA2 john@desktop"})
+	forge, err := NewForgeAt("forgejo", server.URL+"/forgejo/")
+	if err != nil {
+		t.Fatalf("Did not expect an error, but got: %v", err)
+	}
+
+	if err := forge.IsGithubUsernameValid("john"); err != nil {
+		t.Errorf("Expected 'john' to be valid, but got: %v", err)
+	}
+	if err := forge.IsGithubUsernameValid("jane"); err == nil || err.Error() != "username not found" {
+		t.Errorf("Expected 'username not found', but got: %v", err)
+	}
+
+	keys, err := forge.FetchPublicKeys("john")
+	if err != nil {
+		t.Fatalf("Did not expect an error, but got: %v", err)
+	}
+	if want := []string{"ssh-ed25519 AAAA1 john@laptop", "ssh-ed25519 AAAA2 john@desktop"}; !reflect.DeepEqual(keys, want) {
+		t.Errorf("Expected %v, but got %v", want, keys)
+	}
+}
+
+func TestGiteaOwnKeys(t *testing.T) {
+	server, keys := fakeGitea(t, "secret")
+	client := &GiteaClient{BaseURL: server.URL + "/forgejo/api/v1"}
+
+	if login, err := client.AuthenticatedUser("secret"); err != nil || login != "john" {
+		t.Errorf("Expected login 'john', but got '%s', %v", login, err)
+	}
+
+	added, err := client.AddPublicKey("secret", "gas@laptop", "ssh-ed25519 AAAA2")
+	if err != nil || added.ID != 2 {
+		t.Fatalf("Expected the key to be added, but got %+v, %v", added, err)
+	}
+
+	signing, err := client.AddSigningKey("secret", "gas@laptop", "ssh-ed25519 AAAA2")
+	if err != nil || signing.ID != 2 || len(*keys) != 2 {
+		t.Errorf("Expected the existing key to be used for signing, but got %+v, %v with keys %+v", signing, err, *keys)
+	}
+
+	own, err := client.ListOwnPublicKeys("secret")
+	if err != nil || len(own) != 2 || own[1].ID != 2 {
+		t.Errorf("Expected the keys of every page, but got %+v, %v", own, err)
+	}
+
+	if err := client.DeletePublicKey("secret", 1); err != nil || len(*keys) != 1 {
+		t.Errorf("Expected the key to be deleted, but got %v with keys %+v", err, *keys)
+	}
+
+	_, err = client.AddPublicKey("wrong", "gas@laptop", "ssh-ed25519 AAAA3")
+	if err == nil || !strings.Contains(err.Error(), "token is required") {
+		t.Errorf("Expected an unauthorized error, but got: %v", err)
+	}
+}
+
+func TestNewForgeAt(t *testing.T) {
+	tests := []struct {
+		provider string
+		baseURL  string
+		want     string
+	}{
+		{"github", "https://github.com", DefaultGitHubAPIURL},
+		{"github", "https://github.example.com/", "https://github.example.com/api/v3"},
+		{"gitlab", "https://gitlab.example.com", "https://gitlab.example.com/api/v4"},
+		{"gitea", "https://example.com/git", "https://example.com/git/api/v1"},
+	}
+
+	for _, tt := range tests {
+		forge, err := NewForgeAt(tt.provider, tt.baseURL)
+		if err != nil {
+			t.Fatalf("Did not expect an error, but got: %v", err)
+		}
+		var got string
+		switch client := forge.(type) {
+		case *RealGitHubClient:
+			got = client.baseURL()
+		case *GitLabClient:
+			got = client.baseURL()
+		case *GiteaClient:
+			got = client.baseURL()
+		}
+		if got != tt.want {
+			t.Errorf("NewForgeAt(%s, %s) uses '%s', want '%s'", tt.provider, tt.baseURL, got, tt.want)
+		}
+	}
+
+	if _, err := NewForgeAt("gitea", "git.example.com"); err == nil {
+		t.Errorf("Expected an error for a base URL without a scheme, but got none")
+	}
+	if _, err := NewForgeAt("bitbucket", "https://bitbucket.example.com"); err == nil {
+		t.Errorf("Expected an error for a self-hosted Bitbucket, but got none")
+	}
+}
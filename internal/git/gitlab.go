@@ -0,0 +1,184 @@
+package git
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// DefaultGitLabAPIURL is the API of gitlab.com.
+const DefaultGitLabAPIURL = "https://gitlab.com/api/v4"
+
+// GitLabClient is the client of the REST API of gitlab.com or a self-hosted GitLab.
+type GitLabClient struct {
+	// BaseURL of the API, defaults to DefaultGitLabAPIURL.
+	BaseURL string
+}
+
+// NewGitLabClient returns a client for the API of the provided host, either gitlab.com or a self-hosted GitLab.
+func NewGitLabClient(host string) *GitLabClient {
+	if host == "" || host == "gitlab.com" || host == "altssh.gitlab.com" {
+		return &GitLabClient{}
+	}
+
+	return &GitLabClient{BaseURL: fmt.Sprintf("https://%s/api/v4", host)}
+}
+
+func (c *GitLabClient) baseURL() string {
+	if c.BaseURL == "" {
+		return DefaultGitLabAPIURL
+	}
+
+	return strings.TrimSuffix(c.BaseURL, "/")
+}
+
+// gitLabUser is a user as returned by the users API.
+type gitLabUser struct {
+	ID       int64  `json:"id"`
+	Username string `json:"username"`
+}
+
+// errGitLabUserNotFound is returned when no user has the requested username.
+var errGitLabUserNotFound = errors.New("username not found")
+
+// findUser looks up a user by username. Unlike GitHub, the keys of a user are only listed by their numeric ID.
+func (c *GitLabClient) findUser(username string) (gitLabUser, error) {
+	var users []gitLabUser
+	err := sendJSON(http.MethodGet, c.baseURL(), "/users?username="+url.QueryEscape(username), nil, nil, &users)
+	if err != nil {
+		return gitLabUser{}, err
+	}
+
+	for _, user := range users {
+		if strings.EqualFold(user.Username, username) {
+			return user, nil
+		}
+	}
+
+	return gitLabUser{}, errGitLabUserNotFound
+}
+
+// FetchPublicKeys fetches the public SSH keys of a GitLab user that authenticate, leaving out signing keys.
+func (c *GitLabClient) FetchPublicKeys(username string) ([]string, error) {
+	user, err := c.findUser(username)
+	if err != nil {
+		return nil, fmt.Errorf("could not fetch public keys: %w", err)
+	}
+
+	keys, err := c.listKeys(fmt.Sprintf("/users/%d/keys", user.ID), "")
+	if err != nil {
+		return nil, fmt.Errorf("could not fetch public keys: %w", err)
+	}
+
+	var publicKeys []string
+	for _, key := range keys {
+		if key.UsageType == "signing" {
+			continue
+		}
+		publicKeys = append(publicKeys, key.Key)
+	}
+
+	return publicKeys, nil
+}
+
+// IsGithubUsernameValid checks if a username exists on GitLab.
+func (c *GitLabClient) IsGithubUsernameValid(username string) error {
+	_, err := c.findUser(username)
+	if err != nil && !errors.Is(err, errGitLabUserNotFound) {
+		return errors.New("could not check if username is valid")
+	}
+
+	return err
+}
+
+// do sends an authenticated API request, decoding the JSON response into out when it's not nil.
+func (c *GitLabClient) do(method, path, token string, body, out interface{}) error {
+	_, err := c.send(method, path, token, body, out)
+	return err
+}
+
+// send works like do, but also returns the headers of the response. Requests without a token aren't authenticated.
+func (c *GitLabClient) send(method, path, token string, body, out interface{}) (http.Header, error) {
+	header := http.Header{}
+	if token != "" {
+		header.Set("Authorization", "Bearer "+token)
+	}
+
+	return send(method, c.baseURL(), path, header, body, out)
+}
+
+// AuthenticatedUser returns the username of the user the token belongs to.
+func (c *GitLabClient) AuthenticatedUser(token string) (string, error) {
+	var user gitLabUser
+	err := c.do(http.MethodGet, "/user", token, nil, &user)
+	return user.Username, err
+}
+
+// AddPublicKey registers an SSH key for the user the token belongs to, for authentication and signing. GitLab
+// doesn't register a key twice, so a key can't be added for signing after it was added for authentication only.
+func (c *GitLabClient) AddPublicKey(token, title, key string) (PublicKey, error) {
+	var added PublicKey
+	err := c.do(http.MethodPost, "/user/keys", token, map[string]string{"title": title, "key": key, "usage_type": "auth_and_signing"}, &added)
+	return added, err
+}
+
+// AddSigningKey registers an SSH signing key for the user the token belongs to, unless the key is registered
+// already. GitLab keeps both kinds in the same list, told apart by their usage type.
+func (c *GitLabClient) AddSigningKey(token, title, key string) (PublicKey, error) {
+	keys, err := c.ListOwnPublicKeys(token)
+	if err != nil {
+		return PublicKey{}, err
+	}
+	for _, existing := range keys {
+		if !sameKey(existing.Key, key) {
+			continue
+		}
+		if existing.UsageType == "auth" {
+			return PublicKey{}, fmt.Errorf("the key '%s' is registered for authentication only, remove it on GitLab to register it for signing as well", existing.Title)
+		}
+		return existing, nil
+	}
+
+	var added PublicKey
+	err = c.do(http.MethodPost, "/user/keys", token, map[string]string{"title": title, "key": key, "usage_type": "signing"}, &added)
+	return added, err
+}
+
+// ListOwnPublicKeys lists the SSH keys of the user the token belongs to, following the pages of the list.
+func (c *GitLabClient) ListOwnPublicKeys(token string) ([]PublicKey, error) {
+	return c.listKeys("/user/keys", token)
+}
+
+// listKeys lists the keys of path, following the pages of the list.
+func (c *GitLabClient) listKeys(path, token string) ([]PublicKey, error) {
+	var keys []PublicKey
+	for page := "1"; page != ""; {
+		var pageKeys []PublicKey
+		header, err := c.send(http.MethodGet, path+"?per_page=100&page="+url.QueryEscape(page), token, nil, &pageKeys)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, pageKeys...)
+		// GitLab leaves X-Next-Page empty on the last page.
+		page = header.Get("X-Next-Page")
+	}
+
+	return keys, nil
+}
+
+// DeletePublicKey removes an SSH key of the user the token belongs to.
+func (c *GitLabClient) DeletePublicKey(token string, id int64) error {
+	return c.do(http.MethodDelete, fmt.Sprintf("/user/keys/%d", id), token, nil, nil)
+}
+
+// ListOwnSigningKeys returns no keys, as GitLab lists signing keys with ListOwnPublicKeys.
+func (c *GitLabClient) ListOwnSigningKeys(token string) ([]PublicKey, error) {
+	return nil, nil
+}
+
+// DeleteSigningKey removes an SSH key of the user the token belongs to, like DeletePublicKey.
+func (c *GitLabClient) DeleteSigningKey(token string, id int64) error {
+	return c.DeletePublicKey(token, id)
+}
@@ -0,0 +1,155 @@
+package git
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// fakeGitLab serves the users and keys endpoints of the GitLab API under /api/v4 for a single token. The keys of
+// the token's user are listed 2 per page, with the next page in X-Next-Page.
+func fakeGitLab(t *testing.T, token string) (*httptest.Server, *[]map[string]string) {
+	t.Helper()
+	added := &[]map[string]string{}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/api/v4/users":
+			if r.URL.Query().Get("username") == "john" {
+				w.Write([]byte(`[{"id":42,"username":"john"}]`))
+				return
+			}
+			w.Write([]byte(`[]`))
+		case r.URL.Path == "/api/v4/users/42/keys":
+			pages := map[string]string{
+				"1": `[{"id":1,"title":"laptop","key":"ssh-ed25519 AAAA1","usage_type":"auth"},{"id":2,"title":"desktop","key":"ssh-ed25519 AAAA2","usage_type":"auth_and_signing"}]`,
+				"2": `[{"id":3,"title":"ci","key":"ssh-ed25519 AAAA3","usage_type":"signing"},{"id":4,"title":"server","key":"ssh-ed25519 AAAA4","usage_type":"auth"}]`,
+			}
+			if page := r.URL.Query().Get("page"); page == "1" {
+				w.Header().Set("X-Next-Page", "2")
+			}
+			w.Write([]byte(pages[r.URL.Query().Get("page")]))
+		case strings.HasPrefix(r.URL.Path, "/api/v4/user"):
+			if r.Header.Get("Authorization") != "Bearer "+token {
+				w.WriteHeader(http.StatusUnauthorized)
+				w.Write([]byte(`{"message":"401 Unauthorized"}`))
+				return
+			}
+			switch {
+			case r.Method == http.MethodGet && r.URL.Path == "/api/v4/user":
+				w.Write([]byte(`{"id":42,"username":"john"}`))
+			case r.Method == http.MethodGet && r.URL.Path == "/api/v4/user/keys":
+				pages := map[string]string{
+					"1": `[{"id":1,"title":"laptop","key":"ssh-ed25519 AAAA1","usage_type":"auth"},{"id":2,"title":"desktop","key":"ssh-ed25519 AAAA2","usage_type":"auth_and_signing"}]`,
+					"2": `[{"id":3,"title":"ci","key":"ssh-ed25519 AAAA3","usage_type":"signing"}]`,
+				}
+				if page := r.URL.Query().Get("page"); page == "1" {
+					w.Header().Set("X-Next-Page", "2")
+				}
+				w.Write([]byte(pages[r.URL.Query().Get("page")]))
+			case r.Method == http.MethodPost && r.URL.Path == "/api/v4/user/keys":
+				var body map[string]string
+				json.NewDecoder(r.Body).Decode(&body)
+				*added = append(*added, body)
+				w.WriteHeader(http.StatusCreated)
+				json.NewEncoder(w).Encode(PublicKey{ID: int64(len(*added)), Title: body["title"], Key: body["key"]})
+			case r.Method == http.MethodDelete && r.URL.Path == "/api/v4/user/keys/1":
+				w.WriteHeader(http.StatusNoContent)
+			default:
+				http.NotFound(w, r)
+			}
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	return server, added
+}
+
+func TestGitLabUsersAndKeys(t *testing.T) {
+	server, _ := fakeGitLab(t, "secret")
+	client := &GitLabClient{BaseURL: server.URL + "/api/v4/"}
+
+	if err := client.IsGithubUsernameValid("john"); err != nil {
+		t.Errorf("Expected 'john' to be valid, but got: %v", err)
+	}
+	if err := client.IsGithubUsernameValid("jane"); err == nil || err.Error() != "username not found" {
+		t.Errorf("Expected 'username not found', but got: %v", err)
+	}
+
+	keys, err := client.FetchPublicKeys("john")
+	if err != nil {
+		t.Fatalf("Did not expect an error, but got: %v", err)
+	}
+	// keys of every page, leaving out the signing key
+	if want := []string{"ssh-ed25519 AAAA1", "ssh-ed25519 AAAA2", "ssh-ed25519 AAAA4"}; !reflect.DeepEqual(keys, want) {
+		t.Errorf("Expected %v, but got %v", want, keys)
+	}
+
+	if _, err := client.FetchPublicKeys("jane"); err == nil {
+		t.Errorf("Expected an error for an unknown user, but got none")
+	}
+}
+
+func TestGitLabOwnKeys(t *testing.T) {
+	server, added := fakeGitLab(t, "secret")
+	client := &GitLabClient{BaseURL: server.URL + "/api/v4"}
+
+	if login, err := client.AuthenticatedUser("secret"); err != nil || login != "john" {
+		t.Errorf("Expected login 'john', but got '%s', %v", login, err)
+	}
+
+	if _, err := client.AddPublicKey("secret", "gas@laptop", "ssh-ed25519 AAAA4"); err != nil {
+		t.Fatalf("Did not expect an error, but got: %v", err)
+	}
+	if _, err := client.AddSigningKey("secret", "gas@laptop", "ssh-ed25519 AAAA5"); err != nil {
+		t.Fatalf("Did not expect an error, but got: %v", err)
+	}
+	if len(*added) != 2 || (*added)[0]["usage_type"] != "auth_and_signing" || (*added)[1]["usage_type"] != "signing" {
+		t.Errorf("Expected an authentication and signing key and a signing key, but got %+v", *added)
+	}
+
+	// GitLab doesn't register a key twice, so registered keys are reused for signing
+	if signing, err := client.AddSigningKey("secret", "gas@laptop", "ssh-ed25519 AAAA2 john@desktop"); err != nil || signing.ID != 2 || len(*added) != 2 {
+		t.Errorf("Expected the registered key to be used for signing, but got %+v, %v", signing, err)
+	}
+	if _, err := client.AddSigningKey("secret", "gas@laptop", "ssh-ed25519 AAAA1"); err == nil || !strings.Contains(err.Error(), "authentication only") {
+		t.Errorf("Expected an error for a key registered for authentication only, but got: %v", err)
+	}
+
+	own, err := client.ListOwnPublicKeys("secret")
+	if err != nil || len(own) != 3 || own[2].Title != "ci" {
+		t.Errorf("Expected the keys of both pages, but got %+v, %v", own, err)
+	}
+
+	if err := client.DeletePublicKey("secret", 1); err != nil {
+		t.Errorf("Did not expect an error, but got: %v", err)
+	}
+
+	_, err = client.AddPublicKey("wrong", "gas@laptop", "ssh-ed25519 AAAA3")
+	if err == nil || !strings.Contains(err.Error(), "401 Unauthorized") {
+		t.Errorf("Expected an unauthorized error, but got: %v", err)
+	}
+}
+
+func TestNewForge(t *testing.T) {
+	forge, err := NewForge("GitLab", "gitlab.example.com")
+	if err != nil {
+		t.Fatalf("Did not expect an error, but got: %v", err)
+	}
+	if client, ok := forge.(*GitLabClient); !ok || client.baseURL() != "https://gitlab.example.com/api/v4" {
+		t.Errorf("Expected a client of the self-hosted GitLab, but got %#v", forge)
+	}
+
+	if forge, _ := NewForge("", "github.com"); forge.(*RealGitHubClient).baseURL() != DefaultGitHubAPIURL {
+		t.Errorf("Expected GitHub to be the default provider")
+	}
+
+	if _, err := NewForge("svn", "example.com"); err == nil {
+		t.Errorf("Expected an error for an unsupported provider, but got none")
+	}
+}
@@ -0,0 +1,183 @@
+// Package gittest provides fakes of forge APIs for tests.
+package gittest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// Key is an SSH key registered on the fake forge.
+type Key struct {
+	ID    int64  `json:"id"`
+	Key   string `json:"key"`
+	Title string `json:"title"`
+}
+
+// GitHub is a fake of the key endpoints of the GitHub API for the user Login, authenticated by Token. It also
+// serves the OAuth device flow, which grants Token to the OAuth app ClientID right away.
+type GitHub struct {
+	Login    string
+	Token    string
+	ClientID string
+	// ListingDelay is the number of requests the public listing of the user's keys leaves out new keys for, like
+	// the cache of GitHub.
+	ListingDelay int
+	// PerPage caps the keys listed per page, the next page is linked in the Link header. Defaults to 2.
+	PerPage int
+
+	// Keys and SigningKeys are the registered authentication and signing keys.
+	Keys        []Key
+	SigningKeys []Key
+
+	mu            sync.Mutex
+	nextID        int64
+	nextSigningID int64
+}
+
+// NewGitHub starts a fake GitHub API for the user login authenticated by token, closed when the test ends.
+func NewGitHub(t testing.TB, login, token string) (*GitHub, *httptest.Server) {
+	t.Helper()
+	fake := &GitHub{Login: login, Token: token}
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+
+	return fake, server
+}
+
+func (f *GitHub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if r.Method == http.MethodPost && strings.HasPrefix(r.URL.Path, "/login/") {
+		f.serveDeviceFlow(w, r)
+		return
+	}
+
+	if r.Method == http.MethodGet && strings.HasPrefix(r.URL.Path, "/users/") {
+		listed := []Key{}
+		switch {
+		case r.URL.Path != "/users/"+f.Login+"/keys":
+		case f.ListingDelay > 0:
+			f.ListingDelay--
+		default:
+			for _, key := range f.Keys {
+				// The public listing leaves out the titles.
+				listed = append(listed, Key{ID: key.ID, Key: key.Key})
+			}
+		}
+		json.NewEncoder(w).Encode(listed)
+		return
+	}
+
+	if r.Header.Get("Authorization") != "Bearer "+f.Token {
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(`{"message":"Bad credentials"}`))
+		return
+	}
+
+	switch {
+	case r.Method == http.MethodGet && r.URL.Path == "/user":
+		json.NewEncoder(w).Encode(map[string]string{"login": f.Login})
+	case r.Method == http.MethodGet && r.URL.Path == "/user/keys":
+		f.listPage(w, r, f.Keys)
+	case r.Method == http.MethodGet && r.URL.Path == "/user/ssh_signing_keys":
+		f.listPage(w, r, f.SigningKeys)
+	case r.Method == http.MethodPost && r.URL.Path == "/user/keys":
+		// Authentication keys keep their IDs when others are deleted.
+		if f.add(w, r, &f.Keys, f.nextID+1) {
+			f.nextID++
+		}
+	case r.Method == http.MethodPost && r.URL.Path == "/user/ssh_signing_keys":
+		// Signing keys are numbered from 100, so that they aren't mistaken for authentication keys.
+		if f.add(w, r, &f.SigningKeys, 100+f.nextSigningID) {
+			f.nextSigningID++
+		}
+	case r.Method == http.MethodDelete && strings.HasPrefix(r.URL.Path, "/user/keys/"):
+		f.remove(w, r, &f.Keys)
+	case r.Method == http.MethodDelete && strings.HasPrefix(r.URL.Path, "/user/ssh_signing_keys/"):
+		f.remove(w, r, &f.SigningKeys)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// add registers the key in the request body in keys under id, reporting whether the request was valid.
+func (f *GitHub) add(w http.ResponseWriter, r *http.Request, keys *[]Key, id int64) bool {
+	var body struct{ Title, Key string }
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.Key == "" {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		w.Write([]byte(`{"message":"Validation Failed"}`))
+		return false
+	}
+
+	key := Key{ID: id, Title: body.Title, Key: body.Key}
+	*keys = append(*keys, key)
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(key)
+	return true
+}
+
+// remove deletes the key of keys whose ID ends the request path.
+func (f *GitHub) remove(w http.ResponseWriter, r *http.Request, keys *[]Key) {
+	for i, key := range *keys {
+		if strings.HasSuffix(r.URL.Path, fmt.Sprintf("/%d", key.ID)) {
+			*keys = append((*keys)[:i], (*keys)[i+1:]...)
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+	}
+	http.NotFound(w, r)
+}
+
+// listPage writes the page of keys requested with the page parameter.
+func (f *GitHub) listPage(w http.ResponseWriter, r *http.Request, keys []Key) {
+	perPage := f.PerPage
+	if perPage == 0 {
+		perPage = 2
+	}
+	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+	if page < 1 {
+		page = 1
+	}
+
+	start, end := min((page-1)*perPage, len(keys)), min(page*perPage, len(keys))
+	if end < len(keys) {
+		w.Header().Set("Link", fmt.Sprintf(`<http://%s%s?per_page=100&page=%d>; rel="next", <http://%s%s?per_page=100&page=1>; rel="first"`, r.Host, r.URL.Path, page+1, r.Host, r.URL.Path))
+	}
+	json.NewEncoder(w).Encode(keys[start:end])
+}
+
+// serveDeviceFlow answers the device code and token endpoints of the OAuth device flow.
+func (f *GitHub) serveDeviceFlow(w http.ResponseWriter, r *http.Request) {
+	r.ParseForm()
+	if f.ClientID == "" || r.Form.Get("client_id") != f.ClientID {
+		w.Write([]byte(`{"error":"incorrect_client_credentials"}`))
+		return
+	}
+
+	switch r.URL.Path {
+	case "/login/device/code":
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"device_code":      "device",
+			"user_code":        "ABCD-1234",
+			"verification_uri": "http://" + r.Host + "/login/device",
+			"expires_in":       900,
+			"interval":         5,
+		})
+	case "/login/oauth/access_token":
+		if r.Form.Get("device_code") != "device" {
+			w.Write([]byte(`{"error":"bad_verification_code"}`))
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{"access_token": f.Token, "token_type": "bearer", "scope": r.Form.Get("scope")})
+	default:
+		http.NotFound(w, r)
+	}
+}
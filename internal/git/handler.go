@@ -7,9 +7,13 @@ import (
 	"strings"
 )
 
+// Binary is the git executable used to run git commands.
+// It is overridden when gas runs as a git shim, so that gas never invokes itself.
+var Binary = "git"
+
 // HandleGitCommand runs the provided git command with the provided arguments.
 func HandleGitCommand(args []string) error {
-	gitCmd := exec.Command("git", args...)
+	gitCmd := exec.Command(Binary, args...)
 	gitCmd.Stdout = os.Stdout
 	gitCmd.Stderr = os.Stderr
 	gitCmd.Stdin = os.Stdin
@@ -24,34 +28,74 @@ func HandleGitCommand(args []string) error {
 
 // UpdateGlobalGitConfig updates the global git configuration with the provided username and email.
 func UpdateGlobalGitConfig(username, email string) {
-	exec.Command("git", "config", "--global", "user.name", username).Run()
-	exec.Command("git", "config", "--global", "user.email", email).Run()
+	exec.Command(Binary, "config", "--global", "user.name", username).Run()
+	exec.Command(Binary, "config", "--global", "user.email", email).Run()
+}
+
+// UpdateLocalGitConfig updates the git configuration of the current repository with the provided username and email.
+func UpdateLocalGitConfig(username, email string) error {
+	err := exec.Command(Binary, "config", "--local", "user.name", username).Run()
+	if err != nil {
+		return errors.New("failed to set local user.name")
+	}
+
+	err = exec.Command(Binary, "config", "--local", "user.email", email).Run()
+	if err != nil {
+		return errors.New("failed to set local user.email")
+	}
+
+	return nil
 }
 
 func IsCurrentGlobal(email string) bool {
-	currentEmail, _ := exec.Command("git", "config", "--global", "user.email").Output()
+	currentEmail, _ := exec.Command(Binary, "config", "--global", "user.email").Output()
 
 	return strings.TrimSpace(string(currentEmail)) == string(email)
 }
 
 func GetCurrentGlobal() string {
-	currentEmail, _ := exec.Command("git", "config", "--global", "user.email").Output()
+	currentEmail, _ := exec.Command(Binary, "config", "--global", "user.email").Output()
 
 	return strings.TrimSpace(string(currentEmail))
 }
 
+// GetEffectiveIdentity returns the user name and email git will commit with in the current directory,
+// taking repository-local configuration into account.
+func GetEffectiveIdentity() (string, string) {
+	currentName, _ := exec.Command(Binary, "config", "user.name").Output()
+	currentEmail, _ := exec.Command(Binary, "config", "user.email").Output()
+
+	return strings.TrimSpace(string(currentName)), strings.TrimSpace(string(currentEmail))
+}
+
+// GetRemotes lists the names of the remotes of the current repository.
+func GetRemotes() []string {
+	remotes, _ := exec.Command(Binary, "remote").Output()
+	return strings.Fields(string(remotes))
+}
+
 // GetCurrentRemoteUrl fetches the current URL of the specified remote.
 func GetCurrentRemoteUrl(remoteName string) string {
-	remoteUrl, _ := exec.Command("git", "remote", "get-url", remoteName).Output()
+	remoteUrl, _ := exec.Command(Binary, "remote", "get-url", remoteName).Output()
 	return strings.TrimSpace(string(remoteUrl))
 }
 
 // SetRemoteUrl sets the URL of the specified remote.
 func SetRemoteUrl(remoteName, remoteUrl string) error {
-	err := exec.Command("git", "remote", "set-url", remoteName, remoteUrl).Run()
+	err := exec.Command(Binary, "remote", "set-url", remoteName, remoteUrl).Run()
 	if err != nil {
 		return errors.New("failed to set remote URL")
 	}
 
 	return nil
 }
+
+// GetVersion returns the output of 'git --version', or an empty string if git can't be run.
+func GetVersion() string {
+	version, err := exec.Command(Binary, "--version").Output()
+	if err != nil {
+		return ""
+	}
+
+	return strings.TrimSpace(string(version))
+}
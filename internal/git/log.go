@@ -0,0 +1,102 @@
+package git
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// ZeroHash is the object name git uses for refs that don't exist, e.g. in pre-push hook input.
+const ZeroHash = "0000000000000000000000000000000000000000"
+
+// Commit is the identity information of a single commit.
+type Commit struct {
+	Hash           string
+	AuthorName     string
+	AuthorEmail    string
+	CommitterName  string
+	CommitterEmail string
+}
+
+// commitFormat separates the fields with the unit separator, which can't appear in names or emails.
+const commitFormat = "--format=%H%x1f%an%x1f%ae%x1f%cn%x1f%ce"
+
+// ListCommits lists the commits selected by the provided git log arguments, e.g. a revision range.
+func ListCommits(args ...string) ([]Commit, error) {
+	output, err := exec.Command(Binary, append([]string{"log", commitFormat}, args...)...).Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list commits: %w", err)
+	}
+
+	return parseCommits(string(output)), nil
+}
+
+// parseCommits parses the output of git log produced with commitFormat.
+func parseCommits(output string) []Commit {
+	var commits []Commit
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Split(line, "\x1f")
+		if len(fields) != 5 {
+			continue
+		}
+
+		commits = append(commits, Commit{
+			Hash:           fields[0],
+			AuthorName:     fields[1],
+			AuthorEmail:    fields[2],
+			CommitterName:  fields[3],
+			CommitterEmail: fields[4],
+		})
+	}
+
+	return commits
+}
+
+// GetAuthorIdent returns the name and email the next commit will be authored with,
+// including overrides from GIT_AUTHOR_NAME and GIT_AUTHOR_EMAIL.
+func GetAuthorIdent() (string, string, error) {
+	output, err := exec.Command(Binary, "var", "GIT_AUTHOR_IDENT").Output()
+	if err != nil {
+		return "", "", errors.New("failed to determine commit author")
+	}
+
+	return parseIdent(strings.TrimSpace(string(output)))
+}
+
+// parseIdent parses an identity like "John Doe <john@example.com> 1700000000 +0100".
+func parseIdent(ident string) (string, string, error) {
+	start := strings.LastIndex(ident, "<")
+	end := strings.LastIndex(ident, ">")
+	if start == -1 || end < start {
+		return "", "", fmt.Errorf("invalid identity: %s", ident)
+	}
+
+	return strings.TrimSpace(ident[:start]), ident[start+1 : end], nil
+}
+
+// GetCommonDir returns the absolute path of the git directory shared by all worktrees of the current repository.
+func GetCommonDir() (string, error) {
+	output, err := exec.Command(Binary, "rev-parse", "--git-common-dir").Output()
+	if err != nil {
+		return "", errors.New("not a git repository")
+	}
+
+	return filepath.Abs(strings.TrimSpace(string(output)))
+}
+
+// GetTopLevel returns the absolute path of the working tree of the current repository.
+func GetTopLevel() (string, error) {
+	output, err := exec.Command(Binary, "rev-parse", "--show-toplevel").Output()
+	if err != nil {
+		return "", errors.New("not a git repository")
+	}
+
+	return strings.TrimSpace(string(output)), nil
+}
+
+// RevisionExists checks if the provided revision names an existing commit.
+func RevisionExists(revision string) bool {
+	return exec.Command(Binary, "rev-parse", "--verify", "--quiet", revision+"^{commit}").Run() == nil
+}
@@ -0,0 +1,88 @@
+package helpers
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// ReadCertificate reads an OpenSSH user certificate, such as the "-cert.pub" file issued by a certificate authority.
+func ReadCertificate(path string) (*ssh.Certificate, error) {
+	expandedPath, err := ExpandPath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	content, err := os.ReadFile(expandedPath)
+	if err != nil {
+		return nil, fmt.Errorf("could not read certificate '%s': %w", path, err)
+	}
+
+	publicKey, _, _, _, err := ssh.ParseAuthorizedKey(content)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse certificate '%s': %w", path, err)
+	}
+
+	cert, ok := publicKey.(*ssh.Certificate)
+	if !ok {
+		return nil, fmt.Errorf("'%s' is a public key, not a certificate", path)
+	}
+	if cert.CertType != ssh.UserCert {
+		return nil, fmt.Errorf("'%s' is a host certificate, not a user certificate", path)
+	}
+
+	return cert, nil
+}
+
+// CertificateValidBefore returns when the certificate expires, and false if it never does.
+func CertificateValidBefore(cert *ssh.Certificate) (time.Time, bool) {
+	if cert.ValidBefore == ssh.CertTimeInfinity {
+		return time.Time{}, false
+	}
+
+	return time.Unix(int64(cert.ValidBefore), 0), true
+}
+
+// DescribeCertificate summarizes the principals and validity of a certificate.
+func DescribeCertificate(cert *ssh.Certificate, now time.Time) string {
+	principals := "any principal"
+	if len(cert.ValidPrincipals) > 0 {
+		principals = "principals " + strings.Join(cert.ValidPrincipals, ", ")
+	}
+
+	validBefore, expires := CertificateValidBefore(cert)
+	if !expires {
+		return principals + ", never expires"
+	}
+	if now.After(validBefore) {
+		return fmt.Sprintf("%s, expired %s", principals, validBefore.Local().Format("2006-01-02 15:04"))
+	}
+
+	return fmt.Sprintf("%s, valid until %s (%s left)", principals, validBefore.Local().Format("2006-01-02 15:04"), validBefore.Sub(now).Round(time.Minute))
+}
+
+// CertificateWarning returns why the certificate can't be used soon, or an empty string.
+// A certificate is about to expire in the last tenth of its validity period.
+func CertificateWarning(cert *ssh.Certificate, now time.Time) string {
+	validAfter := time.Unix(int64(cert.ValidAfter), 0)
+	if now.Before(validAfter) {
+		return fmt.Sprintf("certificate is not valid until %s", validAfter.Local().Format("2006-01-02 15:04"))
+	}
+
+	validBefore, expires := CertificateValidBefore(cert)
+	if !expires {
+		return ""
+	}
+	if now.After(validBefore) {
+		return fmt.Sprintf("certificate expired %s, request a new one from your certificate authority", validBefore.Local().Format("2006-01-02 15:04"))
+	}
+
+	if remaining := validBefore.Sub(now); remaining < validBefore.Sub(validAfter)/10 {
+		return fmt.Sprintf("certificate expires in %s", remaining.Round(time.Minute))
+	}
+
+	return ""
+}
@@ -0,0 +1,49 @@
+package helpers
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/style77/gas/internal/sshtest"
+	"golang.org/x/crypto/ssh"
+)
+
+func TestReadCertificate(t *testing.T) {
+	issued := time.Date(2026, 10, 19, 9, 0, 0, 0, time.UTC)
+	_, publicKey := sshtest.NewKey(t)
+	userCert := filepath.Join(t.TempDir(), "id_ed25519-cert.pub")
+	sshtest.WriteCertificate(t, userCert, publicKey, ssh.UserCert, []string{"john", "deploy"}, issued)
+	hostCert := filepath.Join(t.TempDir(), "host-cert.pub")
+	sshtest.WriteCertificate(t, hostCert, publicKey, ssh.HostCert, []string{"john", "deploy"}, issued)
+
+	cert, err := ReadCertificate(userCert)
+	if err != nil {
+		t.Fatalf("Did not expect an error, but got: %v", err)
+	}
+
+	tests := []struct {
+		now         time.Time
+		description string
+		warning     string
+	}{
+		{issued.Add(time.Hour), "principals john, deploy, valid until", ""},
+		{issued.Add(7*time.Hour + 30*time.Minute), "(30m0s left)", "certificate expires in 30m0s"},
+		{issued.Add(9 * time.Hour), "principals john, deploy, expired", "certificate expired"},
+		{issued.Add(-time.Hour), "valid until", "certificate is not valid until"},
+	}
+	for _, tt := range tests {
+		if got := DescribeCertificate(cert, tt.now); !strings.Contains(got, tt.description) {
+			t.Errorf("DescribeCertificate() = '%s', expected it to contain '%s'", got, tt.description)
+		}
+		got := CertificateWarning(cert, tt.now)
+		if (tt.warning == "") != (got == "") || !strings.HasPrefix(got, tt.warning) {
+			t.Errorf("CertificateWarning() = '%s', want '%s'", got, tt.warning)
+		}
+	}
+
+	if _, err := ReadCertificate(hostCert); err == nil {
+		t.Errorf("Expected an error for a host certificate, but got none")
+	}
+}
@@ -0,0 +1,164 @@
+package helpers
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// GPGKey is an OpenPGP secret key as listed by gpg.
+type GPGKey struct {
+	KeyID       string
+	Fingerprint string
+	// Validity is the gpg validity flag of the key, e.g. "e" for expired or "r" for revoked.
+	Validity string
+	// Expires is the expiration time of the key, or the zero time if it never expires.
+	Expires time.Time
+	UIDs    []string
+}
+
+var uidEmailPattern = regexp.MustCompile(`<([^>]+)>`)
+
+// ListGPGSecretKeys lists the secret keys available to the provided gpg program using the provided CommandExecutor.
+func ListGPGSecretKeys(program string, executor CommandExecutor) ([]GPGKey, error) {
+	if program == "" {
+		program = "gpg"
+	}
+
+	if _, err := executor.LookPath(program); err != nil {
+		return nil, fmt.Errorf("%s not found", program)
+	}
+
+	output, err := executor.ExecCommand(program, "--list-secret-keys", "--with-colons").Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list gpg secret keys: %w", err)
+	}
+
+	return ParseGPGColons(string(output)), nil
+}
+
+// FindGPGSecretKey returns the secret key matching the provided key ID or fingerprint.
+func FindGPGSecretKey(keyID, program string, executor CommandExecutor) (GPGKey, error) {
+	keys, err := ListGPGSecretKeys(program, executor)
+	if err != nil {
+		return GPGKey{}, err
+	}
+
+	for _, key := range keys {
+		if key.Matches(keyID) {
+			return key, nil
+		}
+	}
+
+	return GPGKey{}, errors.New("gpg secret key not found")
+}
+
+// ParseGPGColons parses the primary keys out of "gpg --with-colons" output.
+func ParseGPGColons(output string) []GPGKey {
+	var keys []GPGKey
+	var current *GPGKey
+	// fingerprints and user IDs following a subkey don't belong to the primary key
+	inSubkey := false
+
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Split(strings.TrimSpace(line), ":")
+		if len(fields) < 10 {
+			continue
+		}
+
+		switch fields[0] {
+		case "sec":
+			keys = append(keys, GPGKey{KeyID: fields[4], Validity: fields[1], Expires: parseEpoch(fields[6])})
+			current = &keys[len(keys)-1]
+			inSubkey = false
+		case "ssb":
+			inSubkey = true
+		case "fpr":
+			if current != nil && !inSubkey && current.Fingerprint == "" {
+				current.Fingerprint = fields[9]
+			}
+		case "uid":
+			if current != nil {
+				current.UIDs = append(current.UIDs, unescapeColons(fields[9]))
+			}
+		}
+	}
+
+	return keys
+}
+
+// Matches checks if the provided key ID, long key ID or fingerprint identifies the key.
+func (k GPGKey) Matches(keyID string) bool {
+	keyID = strings.ToUpper(strings.TrimPrefix(keyID, "0x"))
+	if keyID == "" {
+		return false
+	}
+
+	return strings.HasSuffix(strings.ToUpper(k.Fingerprint), keyID) || strings.HasSuffix(strings.ToUpper(k.KeyID), keyID)
+}
+
+// HasEmail checks if any of the user IDs of the key uses the provided email.
+func (k GPGKey) HasEmail(email string) bool {
+	for _, uid := range k.UIDs {
+		if matches := uidEmailPattern.FindStringSubmatch(uid); matches != nil && strings.EqualFold(matches[1], email) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// IsExpired checks if the key is expired or revoked at the provided time.
+func (k GPGKey) IsExpired(now time.Time) bool {
+	if k.Validity == "e" || k.Validity == "r" {
+		return true
+	}
+
+	return !k.Expires.IsZero() && now.After(k.Expires)
+}
+
+func (k GPGKey) String() string {
+	description := k.KeyID
+	if len(k.UIDs) > 0 {
+		description += " " + k.UIDs[0]
+	}
+	if !k.Expires.IsZero() {
+		description += " (expires " + k.Expires.Format("2006-01-02") + ")"
+	}
+
+	return description
+}
+
+// FilterGPGKeysByEmail returns the keys with a user ID using the provided email.
+func FilterGPGKeysByEmail(keys []GPGKey, email string) []GPGKey {
+	var result []GPGKey
+	for _, key := range keys {
+		if key.HasEmail(email) {
+			result = append(result, key)
+		}
+	}
+
+	return result
+}
+
+func parseEpoch(value string) time.Time {
+	seconds, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || seconds == 0 {
+		return time.Time{}
+	}
+
+	return time.Unix(seconds, 0)
+}
+
+// unescapeColons decodes the "\x3a" style escapes gpg uses in colon listings.
+func unescapeColons(value string) string {
+	unquoted, err := strconv.Unquote(`"` + strings.ReplaceAll(value, `"`, `\"`) + `"`)
+	if err != nil {
+		return value
+	}
+
+	return unquoted
+}
@@ -0,0 +1,91 @@
+package helpers
+
+import (
+	"os/exec"
+	"testing"
+	"time"
+)
+
+const gpgColonsOutput = `sec:u:255:22:8A1B2C3D4E5F6071:1700000000:1800000000::u:::scESC:::+:::23::0:
+fpr:::::::::0123456789ABCDEF01238A1B2C3D4E5F6071:
+grp:::::::::AAAABBBBCCCCDDDDEEEEFFFF0000111122223333:
+uid:u::::1700000000::HASH1::John Doe <john@work.com>::::::::::0:
+uid:u::::1700000000::HASH2::John Doe (personal\x3a home) <john@example.com>::::::::::0:
+ssb:u:255:18:1122334455667788:1700000000::::::e:::+:::23:
+fpr:::::::::FFFFEEEEDDDDCCCCBBBBAAAA1122334455667788:
+sec:e:255:22:9999888877776666:1600000000:1650000000::u:::scESC:::+:::23::0:
+fpr:::::::::00000000000000000000000009999888877776666:
+uid:e::::1600000000::HASH3::Old Key <john@work.com>::::::::::0:
+`
+
+func TestParseGPGColons(t *testing.T) {
+	keys := ParseGPGColons(gpgColonsOutput)
+	if len(keys) != 2 {
+		t.Fatalf("Expected 2 keys, but got %d", len(keys))
+	}
+
+	key := keys[0]
+	if key.KeyID != "8A1B2C3D4E5F6071" || key.Fingerprint != "0123456789ABCDEF01238A1B2C3D4E5F6071" {
+		t.Errorf("Unexpected key ID or fingerprint: %+v", key)
+	}
+	if len(key.UIDs) != 2 || key.UIDs[1] != "John Doe (personal: home) <john@example.com>" {
+		t.Errorf("Unexpected user IDs: %v", key.UIDs)
+	}
+	if !key.HasEmail("JOHN@example.com") || key.HasEmail("jane@example.com") {
+		t.Errorf("Unexpected email matching for %v", key.UIDs)
+	}
+	if !key.Matches("0x4E5F6071") || !key.Matches("0123456789abcdef01238a1b2c3d4e5f6071") || key.Matches("1122334455667788") {
+		t.Errorf("Unexpected key ID matching for %+v", key)
+	}
+
+	now := time.Unix(1750000000, 0)
+	if key.IsExpired(now) {
+		t.Errorf("Did not expect key to be expired")
+	}
+	if !key.IsExpired(time.Unix(1900000000, 0)) {
+		t.Errorf("Expected key to be expired after its expiration time")
+	}
+	if !keys[1].IsExpired(now) {
+		t.Errorf("Expected key with expired validity to be expired")
+	}
+
+	if filtered := FilterGPGKeysByEmail(keys, "john@example.com"); len(filtered) != 1 || filtered[0].KeyID != key.KeyID {
+		t.Errorf("Unexpected filtered keys: %v", filtered)
+	}
+}
+
+func TestListGPGSecretKeys_WithGNUPGHOME(t *testing.T) {
+	if _, err := exec.LookPath("gpg"); err != nil {
+		t.Skip("gpg not installed")
+	}
+
+	gnupgHome := t.TempDir()
+	t.Setenv("GNUPGHOME", gnupgHome)
+	t.Cleanup(func() {
+		exec.Command("gpgconf", "--kill", "gpg-agent").Run()
+	})
+
+	generate := exec.Command("gpg", "--batch", "--passphrase", "", "--quick-generate-key", "Test User <test@example.com>", "ed25519", "sign", "1y")
+	if output, err := generate.CombinedOutput(); err != nil {
+		t.Skipf("could not generate gpg key: %v\n%s", err, output)
+	}
+
+	keys, err := ListGPGSecretKeys("", RealCommandExecutor{})
+	if err != nil {
+		t.Fatalf("Did not expect an error, but got: %v", err)
+	}
+	if len(keys) != 1 {
+		t.Fatalf("Expected 1 key, but got %d", len(keys))
+	}
+	if !keys[0].HasEmail("test@example.com") {
+		t.Errorf("Expected key to have the generated email, but got %v", keys[0].UIDs)
+	}
+	if keys[0].Expires.IsZero() || keys[0].IsExpired(time.Now()) {
+		t.Errorf("Expected key to expire in a year, but got %v", keys[0].Expires)
+	}
+
+	key, err := FindGPGSecretKey(keys[0].Fingerprint, "", RealCommandExecutor{})
+	if err != nil || key.KeyID != keys[0].KeyID {
+		t.Errorf("Expected to find key by fingerprint, but got %+v, %v", key, err)
+	}
+}
@@ -5,16 +5,23 @@ import (
 	"regexp"
 )
 
-// ExtractUserAndRepo extracts the user and repo name from a remote URL
+// ExtractUserAndRepo extracts the user and repo name from a remote URL.
+// For GitLab's nested groups like "group/subgroup/repo.git", the user is the whole namespace "group/subgroup",
+// and for Bitbucket's "workspace/repo.git" it's the workspace.
 func ExtractUserAndRepo(remoteUrl string) (string, string, error) {
-	// Match patterns like "git@hostname:user/repo.git"
-	sshPattern := regexp.MustCompile(`git@[\w.-]+:([\w.-]+)/([\w.-]+)\.git`)
+	// Match patterns like "ssh://git@hostname/user/repo.git" or "ssh://git@hostname:7999/user/repo.git"
+	sshUrlPattern := regexp.MustCompile(`^ssh://(?:[^@/]+@)?[\w.-]+(?::\d+)?/([\w.-]+(?:/[\w.-]+)*)/([\w.-]+)\.git`)
+
+	// Match patterns like "git@hostname:user/repo.git" or "git@hostname:group/subgroup/repo.git"
+	sshPattern := regexp.MustCompile(`git@[\w.-]+:([\w.-]+(?:/[\w.-]+)*)/([\w.-]+)\.git`)
 
-	// Match patterns like "https://hostname/user/repo.git"
-	httpsPattern := regexp.MustCompile(`https://[\w.-]+/([\w.-]+)/([\w.-]+)\.git`)
+	// Match patterns like "https://hostname/user/repo.git" or "https://login@hostname/user/repo.git"
+	httpsPattern := regexp.MustCompile(`https://(?:[^@/]+@)?[\w.-]+/([\w.-]+(?:/[\w.-]+)*)/([\w.-]+)\.git`)
 
 	var matches []string
-	if sshPattern.MatchString(remoteUrl) {
+	if sshUrlPattern.MatchString(remoteUrl) {
+		matches = sshUrlPattern.FindStringSubmatch(remoteUrl)
+	} else if sshPattern.MatchString(remoteUrl) {
 		matches = sshPattern.FindStringSubmatch(remoteUrl)
 	} else if httpsPattern.MatchString(remoteUrl) {
 		matches = httpsPattern.FindStringSubmatch(remoteUrl)
@@ -27,3 +34,32 @@ func ExtractUserAndRepo(remoteUrl string) (string, string, error) {
 	}
 	return "", "", fmt.Errorf("failed to parse remote URL: %s", remoteUrl)
 }
+
+// ExtractHost extracts the host (or SSH alias) from a remote URL
+func ExtractHost(remoteUrl string) (string, error) {
+	// Match patterns like "git@hostname:user/repo.git"
+	scpPattern := regexp.MustCompile(`^[\w.-]+@([\w.-]+):`)
+
+	// Match patterns like "ssh://git@hostname:22/user/repo.git" or "https://hostname/user/repo.git"
+	urlPattern := regexp.MustCompile(`^(?:ssh|https?)://(?:[^@/]+@)?([\w.-]+)(?::\d+)?/`)
+
+	if matches := scpPattern.FindStringSubmatch(remoteUrl); matches != nil {
+		return matches[1], nil
+	}
+	if matches := urlPattern.FindStringSubmatch(remoteUrl); matches != nil {
+		return matches[1], nil
+	}
+
+	return "", fmt.Errorf("unsupported remote URL format: %s", remoteUrl)
+}
+
+// ExtractHTTPSUser extracts the username from an HTTPS remote URL like "https://login@hostname/user/repo.git".
+// It returns an empty string for other URLs.
+func ExtractHTTPSUser(remoteUrl string) string {
+	matches := regexp.MustCompile(`^https?://([^@:/]+)(?::[^@/]*)?@`).FindStringSubmatch(remoteUrl)
+	if matches == nil {
+		return ""
+	}
+
+	return matches[1]
+}
@@ -6,6 +6,7 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strings"
 
 	"golang.org/x/crypto/ssh"
 )
@@ -64,16 +65,18 @@ func IsValidSSHKey(path interface{}) error {
 
 // GenerateSSHKey generates an ssh key using the provided CommandExecutor.
 func GenerateSSHKey(email string, executor CommandExecutor) (string, error) {
-	var keyPath string
 	homeDir, err := os.UserHomeDir()
 	if err != nil {
 		return "", fmt.Errorf("failed to get user home directory: %w", err)
 	}
 
-	keyPath = filepath.Join(homeDir, ".ssh", "id_rsa")
+	return GenerateSSHKeyAt(email, filepath.Join(homeDir, ".ssh", "id_rsa"), executor)
+}
 
+// GenerateSSHKeyAt generates an ssh key at the provided path using the provided CommandExecutor.
+func GenerateSSHKeyAt(email, keyPath string, executor CommandExecutor) (string, error) {
 	sshDir := filepath.Dir(keyPath)
-	err = os.MkdirAll(sshDir, 0700)
+	err := os.MkdirAll(sshDir, 0700)
 	if err != nil {
 		return "", fmt.Errorf("failed to create .ssh directory: %w", err)
 	}
@@ -94,3 +97,41 @@ func GenerateSSHKey(email string, executor CommandExecutor) (string, error) {
 	fmt.Println("SSH key successfully generated at:", keyPath)
 	return keyPath, nil
 }
+
+// AuthorizedKey returns the public key of the provided private key in authorized_keys format, without a comment.
+func AuthorizedKey(privateKeyPath string) (string, error) {
+	publicKey, err := ReadPublicKey(privateKeyPath)
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(string(ssh.MarshalAuthorizedKey(publicKey))), nil
+}
+
+// ReadPublicKey reads the public key of the provided private key.
+// The ".pub" file next to the key is preferred, so that passphrase protected keys don't need to be decrypted.
+func ReadPublicKey(privateKeyPath string) (ssh.PublicKey, error) {
+	expandedPath, err := ExpandPath(privateKeyPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if publicKeyData, err := os.ReadFile(expandedPath + ".pub"); err == nil {
+		publicKey, _, _, _, err := ssh.ParseAuthorizedKey(publicKeyData)
+		if err == nil {
+			return publicKey, nil
+		}
+	}
+
+	keyData, err := os.ReadFile(expandedPath)
+	if err != nil {
+		return nil, errors.New("could not read key")
+	}
+
+	privateKey, err := ssh.ParsePrivateKey(keyData)
+	if err != nil {
+		return nil, fmt.Errorf("could not read public key of '%s': %w", privateKeyPath, err)
+	}
+
+	return privateKey.PublicKey(), nil
+}
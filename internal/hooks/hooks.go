@@ -0,0 +1,274 @@
+package hooks
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/style77/gas/internal/accounts"
+	"github.com/style77/gas/internal/git"
+	"github.com/style77/gas/internal/repo"
+)
+
+// ManagedHooks are the client-side hooks gas installs. Hooks other than pre-commit and pre-push
+// only chain to the repository's own hooks, which git would otherwise skip once core.hooksPath is set.
+var ManagedHooks = []string{
+	"applypatch-msg",
+	"commit-msg",
+	"fsmonitor-watchman",
+	"post-applypatch",
+	"post-checkout",
+	"post-commit",
+	"post-index-change",
+	"post-merge",
+	"post-rewrite",
+	"pre-applypatch",
+	"pre-auto-gc",
+	"pre-commit",
+	"pre-merge-commit",
+	"pre-push",
+	"pre-rebase",
+	"prepare-commit-msg",
+	"push-to-checkout",
+	"reference-transaction",
+	"sendemail-validate",
+}
+
+// stdinHooks are the hooks git feeds data on stdin, which has to be replayed to chained hooks.
+var stdinHooks = map[string]bool{
+	"pre-push":              true,
+	"post-rewrite":          true,
+	"reference-transaction": true,
+}
+
+// ErrIdentityMismatch is returned when a commit identity doesn't match the expected account.
+var ErrIdentityMismatch = errors.New("identity mismatch")
+
+// PushUpdate is a single ref update passed to the pre-push hook on stdin.
+type PushUpdate struct {
+	LocalRef  string
+	LocalSha  string
+	RemoteRef string
+	RemoteSha string
+}
+
+// Run runs the gas logic of the hook and then chains to the hooks in chainDirs.
+// It returns the exit code of the hook.
+func Run(name string, args []string, stdin io.Reader, chainDirs []string) int {
+	var input []byte
+	if stdinHooks[name] {
+		var err error
+		input, err = io.ReadAll(stdin)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "gas: could not read hook input:", err)
+			return 1
+		}
+		stdin = bytes.NewReader(input)
+	}
+
+	var err error
+	switch name {
+	case "pre-commit":
+		err = PreCommit()
+	case "pre-push":
+		err = PrePush(args, bytes.NewReader(input))
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, "Use --no-verify to skip this check.")
+		return 1
+	}
+
+	for _, dir := range chainDirs {
+		if input != nil {
+			stdin = bytes.NewReader(input)
+		}
+
+		code, err := runChained(filepath.Join(dir, name), args, stdin)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "gas:", err)
+			return 1
+		}
+		if code != 0 {
+			return code
+		}
+	}
+
+	return 0
+}
+
+// PreCommit checks the commit author against the accounts expected for the repository's remotes.
+func PreCommit() error {
+	_, email, err := git.GetAuthorIdent()
+	if err != nil {
+		return err
+	}
+
+	return CheckAuthor(email, repo.ExpectedAccounts())
+}
+
+// CheckAuthor checks that email belongs to one of the expected accounts. No expected accounts means anything goes.
+func CheckAuthor(email string, expected map[string]accounts.Account) error {
+	if len(expected) == 0 {
+		return nil
+	}
+
+	remotes := make([]string, 0, len(expected))
+	for remote, account := range expected {
+		if strings.EqualFold(account.Email, email) {
+			return nil
+		}
+		remotes = append(remotes, remote)
+	}
+	sort.Strings(remotes)
+
+	var message strings.Builder
+	fmt.Fprintf(&message, "gas: %s: commit author <%s> doesn't match the expected account", ErrIdentityMismatch, email)
+	for _, remote := range remotes {
+		fmt.Fprintf(&message, "\n  remote '%s' expects '%s' <%s>", remote, expected[remote].Name, expected[remote].Email)
+	}
+
+	return errors.New(message.String())
+}
+
+// PrePush checks the author of every pushed commit against the account expected for the push URL.
+func PrePush(args []string, stdin io.Reader) error {
+	if len(args) < 2 {
+		return errors.New("gas: pre-push hook expects the remote name and URL")
+	}
+	remoteName, remoteUrl := args[0], args[1]
+	if !isRemote(remoteName) {
+		// git passes the URL as the name when pushing to a URL, which has no remote-tracking refs
+		remoteName = ""
+	}
+
+	expected := repo.ExpectedAccountForUrl(remoteUrl)
+	if expected == nil {
+		return nil
+	}
+
+	updates, err := ParsePushUpdates(stdin)
+	if err != nil {
+		return err
+	}
+
+	var mismatched []git.Commit
+	for _, update := range updates {
+		if update.LocalSha == git.ZeroHash {
+			// deleting a ref pushes no commits
+			continue
+		}
+
+		commits, err := git.ListCommits(update.RevListArgs(remoteName, git.RevisionExists)...)
+		if err != nil {
+			return err
+		}
+		mismatched = append(mismatched, CheckCommits(commits, *expected)...)
+	}
+
+	if len(mismatched) == 0 {
+		return nil
+	}
+
+	var message strings.Builder
+	fmt.Fprintf(&message, "gas: %s: %d pushed commit(s) are not authored by '%s' <%s>, expected for %s:",
+		ErrIdentityMismatch, len(mismatched), expected.Name, expected.Email, remoteUrl)
+	for _, commit := range mismatched {
+		fmt.Fprintf(&message, "\n  %s %s <%s>", commit.Hash, commit.AuthorName, commit.AuthorEmail)
+	}
+
+	return errors.New(message.String())
+}
+
+// ParsePushUpdates parses the "<local ref> <local sha> <remote ref> <remote sha>" lines given to pre-push.
+func ParsePushUpdates(r io.Reader) ([]PushUpdate, error) {
+	var updates []PushUpdate
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) != 4 {
+			return nil, fmt.Errorf("invalid pre-push input: %s", scanner.Text())
+		}
+
+		updates = append(updates, PushUpdate{
+			LocalRef:  fields[0],
+			LocalSha:  fields[1],
+			RemoteRef: fields[2],
+			RemoteSha: fields[3],
+		})
+	}
+
+	return updates, scanner.Err()
+}
+
+// isRemote checks if name is a remote configured in the current repository.
+func isRemote(name string) bool {
+	for _, remote := range git.GetRemotes() {
+		if remote == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// RevListArgs returns the git log arguments selecting the commits the update pushes.
+// New refs, and force pushes over commits we don't have, push everything not yet on the remote.
+// Without a remote name, everything not on any remote is considered pushed.
+func (u PushUpdate) RevListArgs(remoteName string, exists func(revision string) bool) []string {
+	if u.RemoteSha == git.ZeroHash || !exists(u.RemoteSha) {
+		if remoteName == "" {
+			return []string{u.LocalSha, "--not", "--remotes"}
+		}
+		return []string{u.LocalSha, "--not", "--remotes=" + remoteName}
+	}
+
+	return []string{u.RemoteSha + ".." + u.LocalSha}
+}
+
+// CheckCommits returns the commits not authored with the email of the expected account.
+func CheckCommits(commits []git.Commit, expected accounts.Account) []git.Commit {
+	var mismatched []git.Commit
+	for _, commit := range commits {
+		if !strings.EqualFold(commit.AuthorEmail, expected.Email) {
+			mismatched = append(mismatched, commit)
+		}
+	}
+
+	return mismatched
+}
+
+// runChained runs the hook at path if it exists and is executable, returning its exit code.
+func runChained(path string, args []string, stdin io.Reader) (int, error) {
+	info, err := os.Stat(path)
+	if err != nil || info.IsDir() || info.Mode()&0111 == 0 {
+		return 0, nil
+	}
+
+	hookCmd := exec.Command(path, args...)
+	hookCmd.Stdin = stdin
+	hookCmd.Stdout = os.Stdout
+	hookCmd.Stderr = os.Stderr
+
+	err = hookCmd.Run()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode(), nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("could not run hook '%s': %w", path, err)
+	}
+
+	return 0, nil
+}
@@ -0,0 +1,161 @@
+package hooks
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/style77/gas/internal/accounts"
+	"github.com/style77/gas/internal/git"
+)
+
+func TestParsePushUpdates(t *testing.T) {
+	input := "refs/heads/main 1111111111111111111111111111111111111111 refs/heads/main 2222222222222222222222222222222222222222\n" +
+		"\n" +
+		"(delete) 0000000000000000000000000000000000000000 refs/heads/old 3333333333333333333333333333333333333333\n"
+
+	updates, err := ParsePushUpdates(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("Did not expect an error, but got: %v", err)
+	}
+
+	expected := []PushUpdate{
+		{"refs/heads/main", "1111111111111111111111111111111111111111", "refs/heads/main", "2222222222222222222222222222222222222222"},
+		{"(delete)", git.ZeroHash, "refs/heads/old", "3333333333333333333333333333333333333333"},
+	}
+	if !reflect.DeepEqual(updates, expected) {
+		t.Errorf("Expected %v, but got %v", expected, updates)
+	}
+
+	if _, err := ParsePushUpdates(strings.NewReader("refs/heads/main 1111\n")); err == nil {
+		t.Errorf("Expected an error for malformed input, but got none")
+	}
+}
+
+func TestRevListArgs(t *testing.T) {
+	exists := func(revision string) bool { return revision == "bbbb" }
+
+	tests := []struct {
+		name   string
+		remote string
+		update PushUpdate
+		want   []string
+	}{
+		{
+			name:   "Update of existing ref",
+			remote: "origin",
+			update: PushUpdate{LocalSha: "aaaa", RemoteSha: "bbbb"},
+			want:   []string{"bbbb..aaaa"},
+		},
+		{
+			name:   "New ref",
+			remote: "origin",
+			update: PushUpdate{LocalSha: "aaaa", RemoteSha: git.ZeroHash},
+			want:   []string{"aaaa", "--not", "--remotes=origin"},
+		},
+		{
+			name:   "Force push over unknown commits",
+			remote: "origin",
+			update: PushUpdate{LocalSha: "aaaa", RemoteSha: "cccc"},
+			want:   []string{"aaaa", "--not", "--remotes=origin"},
+		},
+		{
+			name:   "New ref pushed to a URL",
+			update: PushUpdate{LocalSha: "aaaa", RemoteSha: git.ZeroHash},
+			want:   []string{"aaaa", "--not", "--remotes"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.update.RevListArgs(tt.remote, exists); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("RevListArgs() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckCommits(t *testing.T) {
+	expected := accounts.Account{Name: "john-work", Email: "john@work.com"}
+	commits := []git.Commit{
+		{Hash: "aaaa", AuthorEmail: "john@work.com"},
+		{Hash: "bbbb", AuthorEmail: "John@Work.com"},
+		{Hash: "cccc", AuthorEmail: "john@example.com"},
+	}
+
+	mismatched := CheckCommits(commits, expected)
+	if len(mismatched) != 1 || mismatched[0].Hash != "cccc" {
+		t.Errorf("Expected only commit 'cccc' to mismatch, but got %v", mismatched)
+	}
+}
+
+func TestCheckAuthor(t *testing.T) {
+	expected := map[string]accounts.Account{
+		"origin":   {Name: "john-work", Email: "john@work.com"},
+		"personal": {Name: "johnDoe98", Email: "john@example.com"},
+	}
+
+	if err := CheckAuthor("john@example.com", expected); err != nil {
+		t.Errorf("Did not expect an error, but got: %v", err)
+	}
+	if err := CheckAuthor("someone@else.com", nil); err != nil {
+		t.Errorf("Did not expect an error without expected accounts, but got: %v", err)
+	}
+
+	err := CheckAuthor("someone@else.com", expected)
+	if err == nil {
+		t.Fatalf("Expected an error, but got none")
+	}
+	if !strings.Contains(err.Error(), "remote 'origin' expects 'john-work' <john@work.com>") {
+		t.Errorf("Expected error to name the expected account, but got: %v", err)
+	}
+}
+
+func TestWriteScriptsAndChain(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("hook scripts are shell scripts")
+	}
+
+	dir := t.TempDir()
+	if err := WriteScripts(dir, "/usr/local/bin/gas"); err != nil {
+		t.Fatalf("Did not expect an error, but got: %v", err)
+	}
+
+	script, err := os.ReadFile(filepath.Join(dir, "pre-push"))
+	if err != nil {
+		t.Fatalf("Failed to read hook: %v", err)
+	}
+	if !strings.Contains(string(script), `exec "/usr/local/bin/gas" hook pre-push "$@"`) {
+		t.Errorf("Unexpected hook script: %s", script)
+	}
+	// core.hooksPath hides every hook of the repository, so these have to be chained as well
+	for _, name := range []string{"reference-transaction", "post-index-change", "push-to-checkout", "fsmonitor-watchman"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Errorf("Expected a '%s' hook, but got: %v", name, err)
+		}
+	}
+
+	// chained hooks receive the arguments and the replayed stdin
+	repoHooks := t.TempDir()
+	output := filepath.Join(t.TempDir(), "output")
+	chained := "#!/bin/sh\necho \"$@\" > " + output + "\ncat >> " + output + "\nexit 3\n"
+	if err := os.WriteFile(filepath.Join(repoHooks, "post-rewrite"), []byte(chained), 0755); err != nil {
+		t.Fatalf("Failed to write chained hook: %v", err)
+	}
+
+	code := Run("post-rewrite", []string{"amend"}, strings.NewReader("aaaa bbbb\n"), []string{repoHooks, t.TempDir()})
+	if code != 3 {
+		t.Errorf("Expected exit code of the chained hook, but got %d", code)
+	}
+
+	got, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatalf("Chained hook did not run: %v", err)
+	}
+	if string(got) != "amend\naaaa bbbb\n" {
+		t.Errorf("Unexpected chained hook output: %q", got)
+	}
+}
@@ -0,0 +1,118 @@
+package hooks
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/style77/gas/internal/git"
+	"github.com/style77/gas/internal/helpers"
+)
+
+const hooksPathKey = "core.hooksPath"
+
+// hookScript is the content of every gas managed hook. It hands the hook over to "gas hook <name>".
+const hookScript = `#!/bin/sh
+# Managed by gas. Run 'gas hooks uninstall' to remove.
+exec "%s" hook %s "$@"
+`
+
+// DefaultDir returns the directory the gas managed hooks are installed into.
+func DefaultDir() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get user home directory: %w", err)
+	}
+
+	return filepath.Join(homeDir, ".gas", "hooks"), nil
+}
+
+// WriteScripts writes a script for every managed hook into dir, calling the gas binary at gasPath.
+func WriteScripts(dir, gasPath string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create hooks directory: %w", err)
+	}
+
+	gasPath = filepath.ToSlash(gasPath)
+	for _, name := range ManagedHooks {
+		script := fmt.Sprintf(hookScript, gasPath, name)
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(script), 0755); err != nil {
+			return fmt.Errorf("failed to write hook '%s': %w", name, err)
+		}
+	}
+
+	return nil
+}
+
+// Install writes the managed hooks into dir and points the global core.hooksPath at it.
+// It returns the previously configured global hooks path, so that its hooks can still be chained.
+func Install(dir, gasPath string) (string, error) {
+	if err := WriteScripts(dir, gasPath); err != nil {
+		return "", err
+	}
+
+	previous := git.GetConfig(git.GlobalScope, hooksPathKey)
+	if isSameDir(previous, dir) {
+		previous = ""
+	}
+
+	if err := git.SetConfig(git.GlobalScope, hooksPathKey, dir); err != nil {
+		return "", err
+	}
+
+	return previous, nil
+}
+
+// Uninstall restores the previous global core.hooksPath and removes the managed hooks from dir.
+func Uninstall(dir, previous string) error {
+	if current := git.GetConfig(git.GlobalScope, hooksPathKey); isSameDir(current, dir) {
+		var err error
+		if previous != "" {
+			err = git.SetConfig(git.GlobalScope, hooksPathKey, previous)
+		} else {
+			err = git.UnsetConfig(git.GlobalScope, hooksPathKey)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, name := range ManagedHooks {
+		if err := os.Remove(filepath.Join(dir, name)); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove hook '%s': %w", name, err)
+		}
+	}
+
+	return nil
+}
+
+// ChainDirs returns the directories whose hooks run after the gas checks:
+// the repository's own hooks directory and the global hooks path configured before gas.
+func ChainDirs(previous string) []string {
+	var dirs []string
+
+	if commonDir, err := git.GetCommonDir(); err == nil {
+		dirs = append(dirs, filepath.Join(commonDir, "hooks"))
+	}
+
+	if previous != "" {
+		if expanded, err := helpers.ExpandPath(previous); err == nil {
+			dirs = append(dirs, expanded)
+		}
+	}
+
+	return dirs
+}
+
+// isSameDir checks if both paths point to the same directory.
+func isSameDir(a, b string) bool {
+	if a == "" || b == "" {
+		return false
+	}
+
+	a, _ = helpers.ExpandPath(a)
+	b, _ = helpers.ExpandPath(b)
+
+	return strings.TrimRight(filepath.Clean(a), string(filepath.Separator)) == strings.TrimRight(filepath.Clean(b), string(filepath.Separator))
+}
@@ -0,0 +1,167 @@
+package mailmap
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/style77/gas/internal/accounts"
+	"github.com/style77/gas/internal/git"
+)
+
+// Entry is a single .mailmap mapping of a commit identity onto a proper identity.
+// An empty ProperEmail keeps the commit email and only replaces the name.
+type Entry struct {
+	ProperName  string
+	ProperEmail string
+	CommitName  string
+	CommitEmail string
+}
+
+// Mailmap is a parsed .mailmap file. Lines that are not entries, like comments, are kept as they are.
+type Mailmap struct {
+	lines   []string
+	entries map[int]Entry
+}
+
+var entryPattern = regexp.MustCompile(`^\s*([^<#]*?)\s*<([^>]*)>\s*(?:([^<#]*?)\s*<([^>]*)>)?\s*(#.*)?$`)
+
+// Parse parses the content of a .mailmap file.
+func Parse(content string) *Mailmap {
+	m := &Mailmap{entries: map[int]Entry{}}
+	if content == "" {
+		return m
+	}
+
+	for i, line := range strings.Split(strings.TrimRight(content, "\n"), "\n") {
+		m.lines = append(m.lines, line)
+
+		matches := entryPattern.FindStringSubmatch(line)
+		if matches == nil {
+			continue
+		}
+
+		if matches[4] == "" {
+			// "Proper Name <commit@email>"
+			m.entries[i] = Entry{ProperName: matches[1], CommitEmail: matches[2]}
+		} else {
+			m.entries[i] = Entry{ProperName: matches[1], ProperEmail: matches[2], CommitName: matches[3], CommitEmail: matches[4]}
+		}
+	}
+
+	return m
+}
+
+// Merge adds the provided entries. An entry replaces an existing one for the same commit identity,
+// entries already present are skipped. It returns true if the mailmap changed.
+func (m *Mailmap) Merge(entries []Entry) bool {
+	changed := false
+
+	for _, entry := range entries {
+		index, found := m.find(entry)
+		if found && m.entries[index].equal(entry) {
+			continue
+		}
+
+		if found {
+			m.lines[index] = entry.String()
+		} else {
+			index = len(m.lines)
+			m.lines = append(m.lines, entry.String())
+		}
+		m.entries[index] = entry
+		changed = true
+	}
+
+	return changed
+}
+
+// find returns the index of the line mapping the same commit identity as entry. git applies the last of
+// duplicate entries, so that's the one returned.
+func (m *Mailmap) find(entry Entry) (int, bool) {
+	for index := len(m.lines) - 1; index >= 0; index-- {
+		if existing, ok := m.entries[index]; ok && existing.key() == entry.key() {
+			return index, true
+		}
+	}
+
+	return 0, false
+}
+
+// String renders the mailmap back into the .mailmap format.
+func (m *Mailmap) String() string {
+	if len(m.lines) == 0 {
+		return ""
+	}
+
+	return strings.Join(m.lines, "\n") + "\n"
+}
+
+// String renders the entry as a .mailmap line.
+func (e Entry) String() string {
+	if e.ProperEmail == "" {
+		return fmt.Sprintf("%s <%s>", e.ProperName, e.CommitEmail)
+	}
+
+	line := fmt.Sprintf("%s <%s>", e.ProperName, e.ProperEmail)
+	if e.CommitName != "" {
+		line += " " + e.CommitName
+	}
+	line += fmt.Sprintf(" <%s>", e.CommitEmail)
+
+	return strings.TrimSpace(line)
+}
+
+// key identifies the commit identity the entry applies to. Emails in .mailmap are case-insensitive.
+func (e Entry) key() string {
+	return strings.ToLower(e.CommitEmail) + "\x00" + e.CommitName
+}
+
+func (e Entry) equal(other Entry) bool {
+	return e.ProperName == other.ProperName && strings.EqualFold(e.ProperEmail, other.ProperEmail) && e.key() == other.key()
+}
+
+// Generate returns the entries mapping every email of an account seen in commits onto the canonical account.
+// Commits made with the canonical email under another name get their name fixed.
+func Generate(commits []git.Commit, canonical accounts.Account, accountList []accounts.Account) []Entry {
+	strayEmails := map[string]bool{}
+	renamed := false
+
+	for _, commit := range commits {
+		for _, identity := range [][2]string{
+			{commit.AuthorName, commit.AuthorEmail},
+			{commit.CommitterName, commit.CommitterEmail},
+		} {
+			name, email := identity[0], identity[1]
+
+			if strings.EqualFold(email, canonical.Email) {
+				if name != canonical.Name {
+					renamed = true
+				}
+				continue
+			}
+
+			if _, ok := accounts.FindAccountByEmail(accountList, email); ok {
+				strayEmails[strings.ToLower(email)] = true
+			}
+		}
+	}
+
+	var entries []Entry
+	if renamed {
+		entries = append(entries, Entry{ProperName: canonical.Name, CommitEmail: canonical.Email})
+	}
+
+	emails := make([]string, 0, len(strayEmails))
+	for email := range strayEmails {
+		emails = append(emails, email)
+	}
+	sort.Strings(emails)
+
+	for _, email := range emails {
+		entries = append(entries, Entry{ProperName: canonical.Name, ProperEmail: canonical.Email, CommitEmail: email})
+	}
+
+	return entries
+}
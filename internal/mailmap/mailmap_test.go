@@ -0,0 +1,89 @@
+package mailmap
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/style77/gas/internal/accounts"
+	"github.com/style77/gas/internal/git"
+)
+
+func TestParse(t *testing.T) {
+	content := `# team members
+Jane Doe <jane@acme.com>
+John Work <john@work.com> <john@example.com>
+John Work <john@work.com> johnny <JOHN@old.com>
+`
+
+	m := Parse(content)
+	if m.String() != content {
+		t.Errorf("Expected content to round trip, but got:\n%s", m.String())
+	}
+
+	expected := map[int]Entry{
+		1: {ProperName: "Jane Doe", CommitEmail: "jane@acme.com"},
+		2: {ProperName: "John Work", ProperEmail: "john@work.com", CommitEmail: "john@example.com"},
+		3: {ProperName: "John Work", ProperEmail: "john@work.com", CommitName: "johnny", CommitEmail: "JOHN@old.com"},
+	}
+	if !reflect.DeepEqual(m.entries, expected) {
+		t.Errorf("Expected entries %+v, but got %+v", expected, m.entries)
+	}
+}
+
+func TestMerge(t *testing.T) {
+	m := Parse("# team\nOld Name <john@work.com> <john@example.com>\n")
+
+	changed := m.Merge([]Entry{
+		{ProperName: "John Work", ProperEmail: "john@work.com", CommitEmail: "JOHN@example.com"},
+		{ProperName: "John Work", ProperEmail: "john@work.com", CommitEmail: "john@side.dev"},
+	})
+	if !changed {
+		t.Errorf("Expected mailmap to change")
+	}
+
+	expected := "# team\nJohn Work <john@work.com> <JOHN@example.com>\nJohn Work <john@work.com> <john@side.dev>\n"
+	if m.String() != expected {
+		t.Errorf("Expected:\n%s\nbut got:\n%s", expected, m.String())
+	}
+
+	// merging the same entries again is a no-op
+	if m.Merge([]Entry{{ProperName: "John Work", ProperEmail: "john@work.com", CommitEmail: "john@side.dev"}}) {
+		t.Errorf("Expected mailmap not to change")
+	}
+}
+
+func TestMergeDuplicates(t *testing.T) {
+	// git applies the last entry for a commit identity, so that's the one replaced
+	content := "A <a@work.com> <john@example.com>\nB <b@work.com> <john@example.com>\nC <c@work.com> <john@example.com>\n"
+	for i := 0; i < 20; i++ {
+		m := Parse(content)
+		m.Merge([]Entry{{ProperName: "John Work", ProperEmail: "john@work.com", CommitEmail: "john@example.com"}})
+
+		expected := "A <a@work.com> <john@example.com>\nB <b@work.com> <john@example.com>\nJohn Work <john@work.com> <john@example.com>\n"
+		if m.String() != expected {
+			t.Fatalf("Expected:\n%s\nbut got:\n%s", expected, m.String())
+		}
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	work := accounts.Account{Name: "John Work", Email: "john@work.com"}
+	personal := accounts.Account{Name: "johnDoe98", Email: "john@example.com"}
+	side := accounts.Account{Name: "john-side", Email: "john@side.dev"}
+
+	commits := []git.Commit{
+		{AuthorName: "John Work", AuthorEmail: "john@work.com", CommitterName: "John Work", CommitterEmail: "john@work.com"},
+		{AuthorName: "johnDoe98", AuthorEmail: "John@Example.com", CommitterName: "jane", CommitterEmail: "jane@acme.com"},
+		{AuthorName: "jw", AuthorEmail: "john@work.com", CommitterName: "john-side", CommitterEmail: "john@side.dev"},
+	}
+
+	entries := Generate(commits, work, []accounts.Account{work, personal, side})
+	expected := []Entry{
+		{ProperName: "John Work", CommitEmail: "john@work.com"},
+		{ProperName: "John Work", ProperEmail: "john@work.com", CommitEmail: "john@example.com"},
+		{ProperName: "John Work", ProperEmail: "john@work.com", CommitEmail: "john@side.dev"},
+	}
+	if !reflect.DeepEqual(entries, expected) {
+		t.Errorf("Expected entries %+v, but got %+v", expected, entries)
+	}
+}
@@ -0,0 +1,152 @@
+package oauth
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// DefaultBaseURL is the OAuth server of github.com.
+const DefaultBaseURL = "https://github.com"
+
+// slowDownIncrease is added to the polling interval every time the server answers slow_down, as RFC 8628 requires.
+const slowDownIncrease = 5 * time.Second
+
+var (
+	// ErrExpired is returned when the user didn't enter the code before it expired.
+	ErrExpired = errors.New("the device code expired before it was entered, run the login again")
+	// ErrDenied is returned when the user declined the authorization.
+	ErrDenied = errors.New("the authorization was denied")
+)
+
+// DeviceFlow implements the OAuth 2.0 device authorization grant of GitHub.
+type DeviceFlow struct {
+	// BaseURL of the OAuth server, defaults to DefaultBaseURL.
+	BaseURL  string
+	ClientID string
+	Scopes   []string
+
+	// Sleep waits between polls, defaults to time.Sleep.
+	Sleep func(time.Duration)
+}
+
+// DeviceCode is the code the user enters at VerificationURI to authorize the device.
+type DeviceCode struct {
+	DeviceCode      string `json:"device_code"`
+	UserCode        string `json:"user_code"`
+	VerificationURI string `json:"verification_uri"`
+	ExpiresIn       int    `json:"expires_in"`
+	Interval        int    `json:"interval"`
+}
+
+// Token is an access token issued by the OAuth server.
+type Token struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	Scope       string `json:"scope"`
+}
+
+func (f *DeviceFlow) baseURL() string {
+	if f.BaseURL == "" {
+		return DefaultBaseURL
+	}
+
+	return strings.TrimSuffix(f.BaseURL, "/")
+}
+
+// post sends a form to the OAuth server and decodes its JSON answer into out.
+func (f *DeviceFlow) post(path string, form url.Values, out interface{}) error {
+	req, err := http.NewRequest(http.MethodPost, f.baseURL()+path, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("POST %s failed: %s", path, resp.Status)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// RequestCode starts the flow and returns the code to show to the user.
+func (f *DeviceFlow) RequestCode() (DeviceCode, error) {
+	var code DeviceCode
+	form := url.Values{"client_id": {f.ClientID}, "scope": {strings.Join(f.Scopes, " ")}}
+	if err := f.post("/login/device/code", form, &code); err != nil {
+		return code, fmt.Errorf("could not request a device code: %w", err)
+	}
+	if code.DeviceCode == "" || code.UserCode == "" {
+		return code, errors.New("could not request a device code: the server did not return one")
+	}
+
+	return code, nil
+}
+
+// PollToken polls the token endpoint until the user authorized the device, denied it, or the code expired.
+func (f *DeviceFlow) PollToken(code DeviceCode) (Token, error) {
+	sleep := f.Sleep
+	if sleep == nil {
+		sleep = time.Sleep
+	}
+
+	interval := time.Duration(code.Interval) * time.Second
+	if interval <= 0 {
+		interval = 5 * time.Second
+	}
+
+	form := url.Values{
+		"client_id":   {f.ClientID},
+		"device_code": {code.DeviceCode},
+		"grant_type":  {"urn:ietf:params:oauth:grant-type:device_code"},
+	}
+
+	for {
+		sleep(interval)
+
+		var answer struct {
+			Token
+			Error            string `json:"error"`
+			ErrorDescription string `json:"error_description"`
+			Interval         int    `json:"interval"`
+		}
+		if err := f.post("/login/oauth/access_token", form, &answer); err != nil {
+			return Token{}, fmt.Errorf("could not poll for the access token: %w", err)
+		}
+
+		switch answer.Error {
+		case "":
+			if answer.AccessToken == "" {
+				return Token{}, errors.New("the server did not return an access token")
+			}
+			return answer.Token, nil
+		case "authorization_pending":
+		case "slow_down":
+			if answer.Interval > 0 {
+				interval = time.Duration(answer.Interval) * time.Second
+			} else {
+				interval += slowDownIncrease
+			}
+		case "expired_token":
+			return Token{}, ErrExpired
+		case "access_denied":
+			return Token{}, ErrDenied
+		default:
+			if answer.ErrorDescription != "" {
+				return Token{}, fmt.Errorf("%s: %s", answer.Error, answer.ErrorDescription)
+			}
+			return Token{}, errors.New(answer.Error)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package oauth
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// fakeOAuthServer answers the token endpoint with the provided responses in order.
+func fakeOAuthServer(t *testing.T, responses ...string) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		if r.Form.Get("client_id") != "client" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		switch r.URL.Path {
+		case "/login/device/code":
+			if r.Form.Get("scope") != "read:user admin:public_key" {
+				t.Errorf("Unexpected scope '%s'", r.Form.Get("scope"))
+			}
+			w.Write([]byte(`{"device_code":"device","user_code":"ABCD-1234","verification_uri":"https://github.com/login/device","expires_in":900,"interval":5}`))
+		case "/login/oauth/access_token":
+			if r.Form.Get("device_code") != "device" || r.Form.Get("grant_type") != "urn:ietf:params:oauth:grant-type:device_code" {
+				t.Errorf("Unexpected token request %v", r.Form)
+			}
+			w.Write([]byte(responses[0]))
+			responses = responses[1:]
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func TestDeviceFlow(t *testing.T) {
+	server := fakeOAuthServer(t,
+		`{"error":"authorization_pending"}`,
+		`{"error":"slow_down"}`,
+		`{"error":"slow_down","interval":20}`,
+		`{"access_token":"gho_secret","token_type":"bearer","scope":"read:user"}`,
+	)
+
+	var waited []time.Duration
+	flow := &DeviceFlow{
+		BaseURL:  server.URL,
+		ClientID: "client",
+		Scopes:   []string{"read:user", "admin:public_key"},
+		Sleep:    func(d time.Duration) { waited = append(waited, d) },
+	}
+
+	code, err := flow.RequestCode()
+	if err != nil {
+		t.Fatalf("Did not expect an error, but got: %v", err)
+	}
+	if code.UserCode != "ABCD-1234" {
+		t.Errorf("Expected user code 'ABCD-1234', but got '%s'", code.UserCode)
+	}
+
+	token, err := flow.PollToken(code)
+	if err != nil {
+		t.Fatalf("Did not expect an error, but got: %v", err)
+	}
+	if token.AccessToken != "gho_secret" {
+		t.Errorf("Expected token 'gho_secret', but got '%s'", token.AccessToken)
+	}
+
+	want := []time.Duration{5 * time.Second, 5 * time.Second, 10 * time.Second, 20 * time.Second}
+	if !reflect.DeepEqual(waited, want) {
+		t.Errorf("Expected to wait %v, but waited %v", want, waited)
+	}
+}
+
+func TestDeviceFlowErrors(t *testing.T) {
+	tests := []struct {
+		response string
+		want     error
+	}{
+		{`{"error":"expired_token"}`, ErrExpired},
+		{`{"error":"access_denied"}`, ErrDenied},
+	}
+	for _, tt := range tests {
+		server := fakeOAuthServer(t, `{"error":"authorization_pending"}`, tt.response)
+		flow := &DeviceFlow{BaseURL: server.URL, ClientID: "client", Scopes: []string{"read:user", "admin:public_key"}, Sleep: func(time.Duration) {}}
+
+		code, err := flow.RequestCode()
+		if err != nil {
+			t.Fatalf("Did not expect an error, but got: %v", err)
+		}
+		if _, err := flow.PollToken(code); !errors.Is(err, tt.want) {
+			t.Errorf("Expected error '%v', but got: %v", tt.want, err)
+		}
+	}
+
+	flow := &DeviceFlow{BaseURL: fakeOAuthServer(t).URL, ClientID: "unknown"}
+	if _, err := flow.RequestCode(); err == nil {
+		t.Errorf("Expected an error for an unknown client, but got none")
+	}
+}
@@ -0,0 +1,194 @@
+package repo
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/style77/gas/internal/accounts"
+	"github.com/style77/gas/internal/git"
+	"github.com/style77/gas/internal/helpers"
+)
+
+// Identity describes who the current repository commits and pushes as.
+type Identity struct {
+	Name       string
+	Email      string
+	RemoteName string
+	RemoteUrl  string
+	// PushAccount is the account bound to the SSH alias of the remote, if any.
+	PushAccount *accounts.Account
+	// ExpectedAccount is the account the remote should be used with, if it can be determined.
+	ExpectedAccount *accounts.Account
+}
+
+// ResolveIdentity works out the effective commit identity and the account expected for the remote.
+func ResolveIdentity(remoteName string) Identity {
+	name, email := git.GetEffectiveIdentity()
+	identity := Identity{
+		Name:       name,
+		Email:      email,
+		RemoteName: remoteName,
+		RemoteUrl:  git.GetCurrentRemoteUrl(remoteName),
+	}
+
+	identity.PushAccount, identity.ExpectedAccount = expectedAccount(identity.RemoteUrl, accounts.GetAccounts())
+	return identity
+}
+
+// DefaultRemote returns "origin" if it exists, or the first remote of the current repository otherwise.
+func DefaultRemote() string {
+	remotes := git.GetRemotes()
+	for _, remote := range remotes {
+		if remote == "origin" {
+			return remote
+		}
+	}
+
+	if len(remotes) > 0 {
+		return remotes[0]
+	}
+
+	return ""
+}
+
+// Matches reports whether the commit identity agrees with the account expected for the remote.
+func (i Identity) Matches() bool {
+	return i.ExpectedAccount != nil && strings.EqualFold(i.Email, i.ExpectedAccount.Email)
+}
+
+func (i Identity) String() string {
+	if i.Name == "" && i.Email == "" {
+		return "<no identity>"
+	}
+
+	return fmt.Sprintf("%s <%s>", i.Name, i.Email)
+}
+
+// ExpectedAccountForUrl returns the account the provided remote URL should be used with, if it can be determined.
+func ExpectedAccountForUrl(remoteUrl string) *accounts.Account {
+	_, expected := expectedAccount(remoteUrl, accounts.GetAccounts())
+	return expected
+}
+
+// ExpectedAccounts returns the accounts expected for the remotes of the current repository, keyed by remote name.
+func ExpectedAccounts() map[string]accounts.Account {
+	accountList := accounts.GetAccounts()
+	result := map[string]accounts.Account{}
+
+	for _, remote := range git.GetRemotes() {
+		if _, expected := expectedAccount(git.GetCurrentRemoteUrl(remote), accountList); expected != nil {
+			result[remote] = *expected
+		}
+	}
+
+	return result
+}
+
+// expectedAccount finds the account bound to the remote's SSH alias, or to the username of an HTTPS remote on the
+// account's forge, and the account the remote should be used with. If the remote doesn't use either, the account whose forge
+// login matches the remote owner is expected.
+func expectedAccount(remoteUrl string, accountList []accounts.Account) (*accounts.Account, *accounts.Account) {
+	host, err := helpers.ExtractHost(remoteUrl)
+	if err != nil {
+		return nil, nil
+	}
+
+	for i := range accountList {
+		if accountList[i].SSHAlias != "" && accountList[i].SSHAlias == host {
+			return &accountList[i], &accountList[i]
+		}
+	}
+
+	if user := helpers.ExtractHTTPSUser(remoteUrl); user != "" {
+		for i := range accountList {
+			// the same login can exist on several forges
+			if strings.EqualFold(accountList[i].ForgeHost(), host) && strings.EqualFold(accountList[i].HTTPSUser(), user) {
+				return &accountList[i], &accountList[i]
+			}
+		}
+	}
+
+	owner, _, err := helpers.ExtractUserAndRepo(remoteUrl)
+	if err != nil {
+		return nil, nil
+	}
+
+	for i := range accountList {
+		if accountList[i].Login != "" && strings.EqualFold(accountList[i].Login, owner) {
+			return nil, &accountList[i]
+		}
+	}
+
+	return nil, nil
+}
+
+// ConfirmIdentity checks the identity of the current repository before running the provided git command.
+// It returns true if the command should be run.
+func ConfirmIdentity(args []string) (bool, error) {
+	return checkIdentity(args, true)
+}
+
+// GuardIdentity works like ConfirmIdentity, but only prompts when the identity mismatches the expected account.
+func GuardIdentity(args []string) (bool, error) {
+	return checkIdentity(args, false)
+}
+
+func checkIdentity(args []string, confirmUnknown bool) (bool, error) {
+	identity := ResolveIdentity(DefaultRemote())
+	command := strings.Join(args, " ")
+
+	if identity.Matches() {
+		return true, nil
+	}
+
+	if identity.ExpectedAccount == nil {
+		if !confirmUnknown {
+			return true, nil
+		}
+
+		var isProperAccount bool
+		err := survey.AskOne(&survey.Confirm{
+			Message: fmt.Sprintf("Do you want to run '%s' as '%s'?", command, identity),
+		}, &isProperAccount)
+
+		return isProperAccount, err
+	}
+
+	expected := identity.ExpectedAccount
+	fmt.Printf("Identity mismatch: this repository commits as '%s', but remote '%s' (%s) expects account '%s' (%s <%s>).\n",
+		identity, identity.RemoteName, identity.RemoteUrl, expected.Handle, expected.Name, expected.Email)
+	if identity.PushAccount != nil {
+		if helpers.ExtractHTTPSUser(identity.RemoteUrl) != "" {
+			fmt.Printf("Pushes will authenticate as account '%s' over HTTPS.\n", identity.PushAccount.Handle)
+		} else {
+			fmt.Printf("Pushes will authenticate as account '%s' through SSH alias '%s'.\n", identity.PushAccount.Handle, identity.PushAccount.SSHAlias)
+		}
+	}
+
+	fixOption := fmt.Sprintf("Set local identity to '%s <%s>' and run '%s'", expected.Name, expected.Email, command)
+	continueOption := fmt.Sprintf("Run '%s' as '%s' anyway", command, identity)
+	abortOption := "Abort"
+
+	var choice string
+	err := survey.AskOne(&survey.Select{
+		Message: "What would you like to do?",
+		Options: []string{fixOption, continueOption, abortOption},
+	}, &choice)
+	if err != nil {
+		return false, err
+	}
+
+	switch choice {
+	case fixOption:
+		if err := git.UpdateLocalGitConfig(expected.Name, expected.Email); err != nil {
+			return false, err
+		}
+		fmt.Printf("Configured repository to commit as '%s <%s>'.\n", expected.Name, expected.Email)
+		return true, nil
+	case continueOption:
+		return true, nil
+	default:
+		return false, nil
+	}
+}
@@ -0,0 +1,84 @@
+package repo
+
+import (
+	"testing"
+
+	"github.com/style77/gas/internal/accounts"
+)
+
+func TestExpectedAccount(t *testing.T) {
+	accountList := []accounts.Account{
+		{Handle: "lab", Name: "John Doe", Login: "john-work", Email: "john@lab.dev", Provider: "gitlab"},
+		{Handle: "personal", Name: "John Doe", Login: "johnDoe98", Email: "john@example.com", SSHAlias: "github-personal"},
+		{Handle: "work", Name: "John Doe", Login: "john-work", Email: "john@work.com", SSHAlias: "github-work"},
+		{Handle: "side", Name: "John Doe", Email: "john@side.dev"},
+	}
+
+	tests := []struct {
+		name         string
+		remoteUrl    string
+		wantPush     string
+		wantExpected string
+	}{
+		{
+			name:         "Remote using SSH alias",
+			remoteUrl:    "git@github-work:acme/repo.git",
+			wantPush:     "work",
+			wantExpected: "work",
+		},
+		{
+			name:         "Remote owned by account without alias",
+			remoteUrl:    "git@github.com:johndoe98/repo.git",
+			wantExpected: "personal",
+		},
+		{
+			name:         "HTTPS remote with account username",
+			remoteUrl:    "https://john-work@github.com/acme/repo.git",
+			wantPush:     "work",
+			wantExpected: "work",
+		},
+		{
+			name:         "HTTPS remote with the username of an account on another forge",
+			remoteUrl:    "https://john-work@gitlab.com/acme/repo.git",
+			wantPush:     "lab",
+			wantExpected: "lab",
+		},
+		{
+			name:         "HTTPS remote with the handle of an account without login",
+			remoteUrl:    "https://side@github.com/acme/repo.git",
+			wantPush:     "side",
+			wantExpected: "side",
+		},
+		{
+			name:      "Remote owned by the handle of an account",
+			remoteUrl: "git@github.com:side/repo.git",
+		},
+		{
+			name:      "Unknown remote",
+			remoteUrl: "https://github.com/acme/repo.git",
+		},
+		{
+			name:      "No remote",
+			remoteUrl: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			push, expected := expectedAccount(tt.remoteUrl, accountList)
+			if got := accountName(push); got != tt.wantPush {
+				t.Errorf("expectedAccount() push = %v, want %v", got, tt.wantPush)
+			}
+			if got := accountName(expected); got != tt.wantExpected {
+				t.Errorf("expectedAccount() expected = %v, want %v", got, tt.wantExpected)
+			}
+		})
+	}
+}
+
+func accountName(account *accounts.Account) string {
+	if account == nil {
+		return ""
+	}
+	return account.Handle
+}
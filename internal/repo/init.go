@@ -36,3 +36,40 @@ func SetRemoteUrl(account *accounts.Account, remoteName string) error {
 
 	return nil
 }
+
+// SetHTTPSRemoteUrl points the remote at https://<login>@host/user/repo.git, so that git asks the credential
+// helper for the account's token.
+func SetHTTPSRemoteUrl(account *accounts.Account, remoteName, host string) error {
+	remoteUrl := git.GetCurrentRemoteUrl(remoteName)
+
+	user, repo, err := helpers.ExtractUserAndRepo(remoteUrl)
+	if err != nil {
+		return err
+	}
+
+	newRemoteUrl := fmt.Sprintf("https://%s@%s/%s/%s.git", account.HTTPSUser(), host, user, repo)
+
+	var isCorrectRemoteURL bool
+	survey.AskOne(&survey.Confirm{
+		Message: fmt.Sprintf("Do you want to set the remote URL to '%s'?", newRemoteUrl),
+	}, &isCorrectRemoteURL)
+
+	if !isCorrectRemoteURL {
+		return errors.New("remote URL not set")
+	}
+
+	return git.SetRemoteUrl(remoteName, newRemoteUrl)
+}
+
+// ConfigureCredentialHelper makes the repository use only the provided credential helper.
+// The empty value resets the helpers inherited from the global config.
+func ConfigureCredentialHelper(helper string) error {
+	if err := git.UnsetConfig(git.LocalScope, "credential.helper"); err != nil {
+		return err
+	}
+	if err := git.AddConfig(git.LocalScope, "credential.helper", ""); err != nil {
+		return err
+	}
+
+	return git.AddConfig(git.LocalScope, "credential.helper", helper)
+}
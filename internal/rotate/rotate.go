@@ -0,0 +1,231 @@
+package rotate
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Step is a completed step of a rotation. Steps run in the order they are declared.
+type Step string
+
+const (
+	StepStarted    Step = ""
+	StepGenerated  Step = "generated"
+	StepUploaded   Step = "uploaded"
+	StepVerified   Step = "verified"
+	StepConfigured Step = "configured"
+	StepRemoved    Step = "removed"
+	StepArchived   Step = "archived"
+)
+
+var steps = []Step{StepStarted, StepGenerated, StepUploaded, StepVerified, StepConfigured, StepRemoved, StepArchived}
+
+// State is the progress of a rotation, saved after every step so an interrupted rotation can continue.
+type State struct {
+	Account    string    `json:"account"`
+	OldKeyPath string    `json:"oldKeyPath"`
+	NewKeyPath string    `json:"newKeyPath"`
+	StartedAt  time.Time `json:"startedAt"`
+	Step       Step      `json:"step"`
+	// UploadedKeyID is the forge's ID of the new key, if it was uploaded.
+	UploadedKeyID int64 `json:"uploadedKeyId,omitempty"`
+	// OldKeyID is the forge's ID of the old key, if it was looked up before the new key was uploaded.
+	OldKeyID int64 `json:"oldKeyId,omitempty"`
+	// ArchivedTo lists the paths the old key files were moved to.
+	ArchivedTo []string `json:"archivedTo,omitempty"`
+}
+
+// done reports whether the state is at or past step.
+func (s *State) done(step Step) bool {
+	for _, candidate := range steps {
+		if candidate == step {
+			return true
+		}
+		if candidate == s.Step {
+			return false
+		}
+	}
+
+	return false
+}
+
+// StatePath returns the path of the state file of a rotation of the provided account.
+func StatePath(account string) (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("could not find user home directory: %w", err)
+	}
+
+	return filepath.Join(homeDir, ".gas", "rotate", account+".json"), nil
+}
+
+// LoadState reads a saved rotation. It returns nil if no rotation is in progress.
+func LoadState(path string) (*State, error) {
+	content, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not read rotation state: %w", err)
+	}
+
+	var state State
+	if err := json.Unmarshal(content, &state); err != nil {
+		return nil, fmt.Errorf("could not parse rotation state '%s': %w", path, err)
+	}
+
+	return &state, nil
+}
+
+// Save writes the state to path.
+func (s *State) Save(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("could not create rotation state directory: %w", err)
+	}
+
+	content, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, content, 0600)
+}
+
+// Rotator runs the steps of a rotation. The optional steps are skipped when their function is nil.
+type Rotator struct {
+	StatePath  string
+	ArchiveDir string
+	Out        io.Writer
+
+	// Generate creates the new key at the provided path.
+	Generate func(keyPath string) error
+	// Upload registers the new key on the forge and returns its ID.
+	Upload func(keyPath string) (int64, error)
+	// Verify checks that the forge authenticates the new key as the account.
+	Verify func(keyPath string) error
+	// Configure points the account and its SSH alias at the new key, once it's verified.
+	Configure func(keyPath string) error
+	// FindOld looks up the forge's ID of the old key before the new one is uploaded, 0 if it isn't registered.
+	FindOld func(keyPath string) (int64, error)
+	// RemoveOld removes the old key from the forge, by the ID found by FindOld unless it's 0.
+	RemoveOld func(keyPath string, id int64) error
+}
+
+// Run continues the rotation from the last completed step and removes the state file once it's done.
+func (r *Rotator) Run(state *State) error {
+	if !state.done(StepGenerated) {
+		if err := r.Generate(state.NewKeyPath); err != nil {
+			return fmt.Errorf("could not generate the new key: %w", err)
+		}
+		if err := r.advance(state, StepGenerated); err != nil {
+			return err
+		}
+	}
+
+	if !state.done(StepUploaded) {
+		if r.FindOld != nil && state.OldKeyID == 0 {
+			id, err := r.FindOld(state.OldKeyPath)
+			if err != nil {
+				return fmt.Errorf("could not look up the old key on the forge: %w", err)
+			}
+			state.OldKeyID = id
+		}
+		if r.Upload != nil {
+			id, err := r.Upload(state.NewKeyPath)
+			if err != nil {
+				return fmt.Errorf("could not upload the new key: %w", err)
+			}
+			state.UploadedKeyID = id
+			fmt.Fprintf(r.Out, "Uploaded the new key '%s'.\n", state.NewKeyPath)
+		}
+		if err := r.advance(state, StepUploaded); err != nil {
+			return err
+		}
+	}
+
+	// The account only switches to the new key once the forge accepts it, so that a failed upload doesn't break pushes.
+	if !state.done(StepVerified) {
+		if err := r.Verify(state.NewKeyPath); err != nil {
+			return fmt.Errorf("the new key does not authenticate as '%s' yet: %w", state.Account, err)
+		}
+		fmt.Fprintf(r.Out, "Verified the new key authenticates as '%s'.\n", state.Account)
+		if err := r.advance(state, StepVerified); err != nil {
+			return err
+		}
+	}
+
+	if !state.done(StepConfigured) {
+		if err := r.Configure(state.NewKeyPath); err != nil {
+			return fmt.Errorf("could not configure the new key: %w", err)
+		}
+		fmt.Fprintf(r.Out, "Account '%s' now uses '%s'.\n", state.Account, state.NewKeyPath)
+		if err := r.advance(state, StepConfigured); err != nil {
+			return err
+		}
+	}
+
+	if !state.done(StepRemoved) {
+		if r.RemoveOld != nil {
+			if err := r.RemoveOld(state.OldKeyPath, state.OldKeyID); err != nil {
+				return fmt.Errorf("could not remove the old key from the forge: %w", err)
+			}
+			fmt.Fprintln(r.Out, "Removed the old key from the forge.")
+		}
+		if err := r.advance(state, StepRemoved); err != nil {
+			return err
+		}
+	}
+
+	if !state.done(StepArchived) {
+		archived, err := archiveKey(state.OldKeyPath, r.ArchiveDir, state.StartedAt)
+		if err != nil {
+			return fmt.Errorf("could not archive the old key: %w", err)
+		}
+		state.ArchivedTo = archived
+		for _, path := range archived {
+			fmt.Fprintf(r.Out, "Archived the old key to '%s'.\n", path)
+		}
+		if err := r.advance(state, StepArchived); err != nil {
+			return err
+		}
+	}
+
+	if err := os.Remove(r.StatePath); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("could not remove rotation state: %w", err)
+	}
+
+	return nil
+}
+
+func (r *Rotator) advance(state *State, step Step) error {
+	state.Step = step
+	return state.Save(r.StatePath)
+}
+
+// archiveKey moves the private and public key files to dir, suffixing them with the rotation date.
+// Files that were already moved are skipped, so archiving can be retried.
+func archiveKey(keyPath, dir string, rotatedAt time.Time) ([]string, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+
+	var archived []string
+	for _, path := range []string{keyPath, keyPath + ".pub"} {
+		target := filepath.Join(dir, fmt.Sprintf("%s.%s", filepath.Base(path), rotatedAt.Format("20060102-150405")))
+		err := os.Rename(path, target)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return archived, err
+		}
+		archived = append(archived, target)
+	}
+
+	return archived, nil
+}
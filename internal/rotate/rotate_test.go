@@ -0,0 +1,132 @@
+package rotate
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestRunResumes(t *testing.T) {
+	dir := t.TempDir()
+	oldKey := filepath.Join(dir, "id_work")
+	os.WriteFile(oldKey, []byte("old"), 0600)
+	os.WriteFile(oldKey+".pub", []byte("old.pub"), 0644)
+
+	var calls []string
+	registered := false
+	rotator := &Rotator{
+		StatePath:  filepath.Join(dir, "state", "work.json"),
+		ArchiveDir: filepath.Join(dir, "archive"),
+		Out:        &bytes.Buffer{},
+		Generate: func(keyPath string) error {
+			calls = append(calls, "generate")
+			return os.WriteFile(keyPath, []byte("new"), 0600)
+		},
+		Configure: func(keyPath string) error {
+			calls = append(calls, "configure")
+			return nil
+		},
+		Verify: func(keyPath string) error {
+			calls = append(calls, "verify")
+			if !registered {
+				return errors.New("permission denied")
+			}
+			return nil
+		},
+		FindOld: func(keyPath string) (int64, error) {
+			calls = append(calls, "find "+filepath.Base(keyPath))
+			return 7, nil
+		},
+		RemoveOld: func(keyPath string, id int64) error {
+			calls = append(calls, fmt.Sprintf("remove %s %d", filepath.Base(keyPath), id))
+			return nil
+		},
+	}
+
+	startedAt := time.Date(2026, 10, 19, 9, 0, 0, 0, time.UTC)
+	state := &State{Account: "work", OldKeyPath: oldKey, NewKeyPath: filepath.Join(dir, "id_work_2026"), StartedAt: startedAt}
+
+	if err := rotator.Run(state); err == nil {
+		t.Fatalf("Expected the rotation to stop at verification, but it finished")
+	}
+
+	saved, err := LoadState(rotator.StatePath)
+	if err != nil || saved == nil {
+		t.Fatalf("Expected the state to be saved, but got %v, %v", saved, err)
+	}
+	if saved.Step != StepUploaded {
+		t.Errorf("Expected the rotation to stop before verifying, but got step '%s'", saved.Step)
+	}
+
+	registered = true
+	if err := rotator.Run(saved); err != nil {
+		t.Fatalf("Did not expect an error, but got: %v", err)
+	}
+
+	want := []string{"generate", "find id_work", "verify", "verify", "configure", "remove id_work 7"}
+	if !reflect.DeepEqual(calls, want) {
+		t.Errorf("Expected calls %v, but got %v", want, calls)
+	}
+
+	if _, err := os.Stat(oldKey); !os.IsNotExist(err) {
+		t.Errorf("Expected the old key to be moved to the archive")
+	}
+	archived := filepath.Join(dir, "archive", "id_work.pub.20261019-090000")
+	if content, _ := os.ReadFile(archived); string(content) != "old.pub" {
+		t.Errorf("Expected the old public key in '%s'", archived)
+	}
+
+	if state, _ := LoadState(rotator.StatePath); state != nil {
+		t.Errorf("Expected the state file to be removed, but got %+v", state)
+	}
+}
+
+func TestRunKeepsAccountUntilVerified(t *testing.T) {
+	dir := t.TempDir()
+	oldKey := filepath.Join(dir, "id_work")
+	os.WriteFile(oldKey, []byte("old"), 0600)
+
+	configured := oldKey
+	rotator := &Rotator{
+		StatePath:  filepath.Join(dir, "state", "work.json"),
+		ArchiveDir: filepath.Join(dir, "archive"),
+		Out:        &bytes.Buffer{},
+		Generate: func(keyPath string) error {
+			return os.WriteFile(keyPath, []byte("new"), 0600)
+		},
+		Upload: func(keyPath string) (int64, error) {
+			return 0, errors.New("no token")
+		},
+		Verify: func(keyPath string) error {
+			return errors.New("permission denied")
+		},
+		Configure: func(keyPath string) error {
+			configured = keyPath
+			return nil
+		},
+	}
+
+	state := &State{Account: "work", OldKeyPath: oldKey, NewKeyPath: filepath.Join(dir, "id_work_2026"), StartedAt: time.Now()}
+	if err := rotator.Run(state); err == nil {
+		t.Fatalf("Expected the upload to fail")
+	}
+
+	rotator.Upload = nil
+	if err := rotator.Run(state); err == nil {
+		t.Fatalf("Expected the verification to fail")
+	}
+	if configured != oldKey {
+		t.Errorf("Expected the account to keep '%s' until the new key is verified, but it uses '%s'", oldKey, configured)
+	}
+	if saved, _ := LoadState(rotator.StatePath); saved == nil || saved.Step != StepUploaded {
+		t.Errorf("Expected the rotation to stop before verifying, but got %+v", saved)
+	}
+	if _, err := os.Stat(oldKey); err != nil {
+		t.Errorf("Expected the old key to stay in place, but got: %v", err)
+	}
+}
@@ -0,0 +1,48 @@
+package secrets
+
+import (
+	"errors"
+	"os"
+	"strings"
+)
+
+// Env reads secrets from environment variables, e.g. "token/work" from GAS_TOKEN_WORK. It's meant for CI,
+// where secrets are injected by the runner, so it can't store or list secrets.
+type Env struct{}
+
+// EnvVar returns the environment variable holding the secret stored under name.
+func EnvVar(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			return r
+		}
+		return '_'
+	}, name)
+
+	return "GAS_" + strings.ToUpper(name)
+}
+
+// Get returns the secret stored under name.
+func (Env) Get(name string) (string, error) {
+	value := os.Getenv(EnvVar(name))
+	if value == "" {
+		return "", ErrNotFound
+	}
+
+	return value, nil
+}
+
+// Set fails, environment variables have to be set by the caller of gas.
+func (Env) Set(name, value string) error {
+	return ErrReadOnly
+}
+
+// Delete fails, environment variables have to be unset by the caller of gas.
+func (Env) Delete(name string) error {
+	return ErrReadOnly
+}
+
+// List fails, as the environment variables can't be mapped back to secret names.
+func (Env) List() ([]string, error) {
+	return nil, errors.New("the env backend can't list secrets")
+}
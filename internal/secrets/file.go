@@ -0,0 +1,299 @@
+package secrets
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+// scrypt parameters of passphrase-derived keys.
+const (
+	scryptN = 1 << 15
+	scryptR = 8
+	scryptP = 1
+)
+
+// File stores secrets in a single file sealed with AES-256-GCM.
+// Without Passphrase, the key is read from KeyPath and generated on the first write. The key file sits next to the
+// secrets with the same permissions, so it only guards against the secrets being read by accident, e.g. in a backup
+// or a screen share, not against anyone who can read the user's files. With Passphrase, the key is derived from the
+// passphrase with scrypt and nothing but the encrypted file is stored.
+type File struct {
+	Path    string
+	KeyPath string
+	// Passphrase returns the passphrase of the file. It's asked for at most once.
+	Passphrase func() ([]byte, error)
+	// Unlock returns the passphrase of a file still encrypted with one when Passphrase is nil, so that it can be
+	// read once and encrypted with the key file from then on.
+	Unlock func() ([]byte, error)
+
+	passphrase []byte
+}
+
+// encryptedFile is the content of the secrets file.
+type encryptedFile struct {
+	// Salt of the passphrase-derived key, empty when the key is read from KeyPath.
+	Salt       []byte `json:"salt,omitempty"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// DefaultFile returns the secrets file in ~/.gas.
+func DefaultFile() (*File, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return nil, fmt.Errorf("could not find user home directory: %w", err)
+	}
+
+	dir := filepath.Join(homeDir, ".gas")
+	return &File{Path: filepath.Join(dir, "secrets"), KeyPath: filepath.Join(dir, "secrets.key")}, nil
+}
+
+// Get returns the secret stored under name.
+func (f *File) Get(name string) (string, error) {
+	secrets, err := f.load()
+	if err != nil {
+		return "", err
+	}
+
+	value, ok := secrets[name]
+	if !ok {
+		return "", ErrNotFound
+	}
+
+	return value, nil
+}
+
+// Set stores value under name, replacing a previous value.
+func (f *File) Set(name, value string) error {
+	secrets, err := f.load()
+	if err != nil {
+		return err
+	}
+
+	secrets[name] = value
+	return f.save(secrets)
+}
+
+// List returns the names of the stored secrets.
+func (f *File) List() ([]string, error) {
+	secrets, err := f.load()
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(secrets))
+	for name := range secrets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names, nil
+}
+
+// Delete removes the secret stored under name.
+func (f *File) Delete(name string) error {
+	secrets, err := f.load()
+	if err != nil {
+		return err
+	}
+	if _, ok := secrets[name]; !ok {
+		return ErrNotFound
+	}
+
+	delete(secrets, name)
+	return f.save(secrets)
+}
+
+// load decrypts the secrets file. A missing file holds no secrets.
+func (f *File) load() (map[string]string, error) {
+	secrets := map[string]string{}
+
+	content, err := os.ReadFile(f.Path)
+	if os.IsNotExist(err) {
+		return secrets, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not read secrets: %w", err)
+	}
+
+	var encrypted encryptedFile
+	if err := json.Unmarshal(content, &encrypted); err != nil {
+		return nil, fmt.Errorf("could not parse secrets file '%s': %w", f.Path, err)
+	}
+
+	var key []byte
+	if len(encrypted.Salt) > 0 {
+		passphrase := f.Passphrase
+		if passphrase == nil {
+			passphrase = f.Unlock
+		}
+		if passphrase == nil {
+			return nil, fmt.Errorf("secrets file '%s' is encrypted with a passphrase, set %s to true", f.Path, PassphraseKey)
+		}
+		key, err = f.derivedKey(passphrase, encrypted.Salt)
+	} else {
+		key, err = os.ReadFile(f.KeyPath)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not read the key of the secrets file: %w", err)
+	}
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+
+	plaintext, err := aead.Open(nil, encrypted.Nonce, encrypted.Ciphertext, nil)
+	if err != nil {
+		return nil, fmt.Errorf("could not decrypt secrets file '%s', it was modified or encrypted with another key", f.Path)
+	}
+	if err := json.Unmarshal(plaintext, &secrets); err != nil {
+		return nil, fmt.Errorf("could not parse secrets: %w", err)
+	}
+
+	// After switching between the key file and a passphrase, the file is re-encrypted the first time it's read.
+	if (len(encrypted.Salt) > 0) != (f.Passphrase != nil) {
+		if err := f.save(secrets); err != nil {
+			return nil, fmt.Errorf("could not re-encrypt secrets file '%s': %w", f.Path, err)
+		}
+	}
+
+	return secrets, nil
+}
+
+// save encrypts the secrets with a fresh nonce and writes them with 0600 permissions.
+func (f *File) save(secrets map[string]string) error {
+	var encrypted encryptedFile
+	var key []byte
+	var err error
+	if f.Passphrase != nil {
+		encrypted.Salt = make([]byte, 16)
+		if _, err := io.ReadFull(rand.Reader, encrypted.Salt); err != nil {
+			return err
+		}
+		key, err = f.derivedKey(f.Passphrase, encrypted.Salt)
+	} else {
+		key, err = f.key()
+	}
+	if err != nil {
+		return err
+	}
+	aead, err := newAEAD(key)
+	if err != nil {
+		return err
+	}
+
+	plaintext, err := json.Marshal(secrets)
+	if err != nil {
+		return err
+	}
+	encrypted.Nonce = make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, encrypted.Nonce); err != nil {
+		return err
+	}
+	encrypted.Ciphertext = aead.Seal(nil, encrypted.Nonce, plaintext, nil)
+
+	content, err := json.Marshal(encrypted)
+	if err != nil {
+		return err
+	}
+
+	if err := writePrivateFile(f.Path, content); err != nil {
+		return err
+	}
+
+	// The key of a file that used to be encrypted with a key file isn't needed anymore.
+	if f.Passphrase != nil {
+		if err := os.Remove(f.KeyPath); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// key reads the encryption key, generating it if it doesn't exist yet.
+func (f *File) key() ([]byte, error) {
+	key, err := os.ReadFile(f.KeyPath)
+	if err == nil {
+		return key, nil
+	}
+	if !os.IsNotExist(err) {
+		return nil, fmt.Errorf("could not read the key of the secrets file: %w", err)
+	}
+	// A new key is only generated for a new file, or one that was encrypted with a passphrase until now.
+	if content, err := os.ReadFile(f.Path); err == nil {
+		var encrypted encryptedFile
+		if json.Unmarshal(content, &encrypted) != nil || len(encrypted.Salt) == 0 {
+			return nil, fmt.Errorf("the key of the secrets file '%s' is missing", f.Path)
+		}
+	}
+
+	key = make([]byte, 32)
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		return nil, err
+	}
+
+	return key, writePrivateFile(f.KeyPath, key)
+}
+
+// derivedKey derives the key of the file from the passphrase returned by source.
+func (f *File) derivedKey(source func() ([]byte, error), salt []byte) ([]byte, error) {
+	if f.passphrase == nil {
+		passphrase, err := source()
+		if err != nil {
+			return nil, err
+		}
+		if len(passphrase) == 0 {
+			return nil, errors.New("the passphrase of the secrets file can't be empty")
+		}
+		f.passphrase = passphrase
+	}
+
+	return scrypt.Key(f.passphrase, salt, scryptN, scryptR, scryptP, 32)
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("invalid key of the secrets file: %w", err)
+	}
+
+	return cipher.NewGCM(block)
+}
+
+// writePrivateFile atomically replaces path with content readable only by the user.
+func writePrivateFile(path string, content []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("could not create directory for secrets: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := tmp.Chmod(0600); err != nil {
+		tmp.Close()
+		return err
+	}
+	if _, err := tmp.Write(content); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,175 @@
+package secrets
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestFile(t *testing.T) {
+	dir := t.TempDir()
+	file := &File{Path: filepath.Join(dir, "gas", "secrets"), KeyPath: filepath.Join(dir, "gas", "secrets.key")}
+
+	if _, err := file.Get("token/work"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound before anything was stored, but got: %v", err)
+	}
+
+	if err := file.Set("token/work", "gho_secret"); err != nil {
+		t.Fatalf("Did not expect an error, but got: %v", err)
+	}
+	if err := file.Set("token/personal", "gho_other"); err != nil {
+		t.Fatalf("Did not expect an error, but got: %v", err)
+	}
+
+	if value, err := file.Get("token/work"); err != nil || value != "gho_secret" {
+		t.Errorf("Expected 'gho_secret', but got '%s', %v", value, err)
+	}
+
+	for _, path := range []string{file.Path, file.KeyPath} {
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatalf("Expected '%s' to exist, but got: %v", path, err)
+		}
+		if info.Mode().Perm() != 0600 {
+			t.Errorf("Expected '%s' to have permissions 0600, but got %o", path, info.Mode().Perm())
+		}
+	}
+
+	content, _ := os.ReadFile(file.Path)
+	if bytes.Contains(content, []byte("gho_secret")) {
+		t.Errorf("Expected the secrets file to be encrypted, but it contains the secret")
+	}
+
+	if names, err := file.List(); err != nil || !reflect.DeepEqual(names, []string{"token/personal", "token/work"}) {
+		t.Errorf("Expected both secrets to be listed, but got %v, %v", names, err)
+	}
+
+	if err := file.Delete("token/work"); err != nil {
+		t.Fatalf("Did not expect an error, but got: %v", err)
+	}
+	if _, err := file.Get("token/work"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected the secret to be deleted, but got: %v", err)
+	}
+	if value, _ := file.Get("token/personal"); value != "gho_other" {
+		t.Errorf("Expected the other secret to be kept, but got '%s'", value)
+	}
+	if err := file.Delete("token/work"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound when deleting twice, but got: %v", err)
+	}
+}
+
+func TestFileRejectsTamperingAndOtherKeys(t *testing.T) {
+	dir := t.TempDir()
+	file := &File{Path: filepath.Join(dir, "secrets"), KeyPath: filepath.Join(dir, "secrets.key")}
+	if err := file.Set("token/work", "gho_secret"); err != nil {
+		t.Fatalf("Did not expect an error, but got: %v", err)
+	}
+
+	other := &File{Path: file.Path, KeyPath: filepath.Join(dir, "other.key")}
+	os.WriteFile(other.KeyPath, bytes.Repeat([]byte{1}, 32), 0600)
+	if _, err := other.Get("token/work"); err == nil {
+		t.Errorf("Expected an error when decrypting with another key, but got none")
+	}
+
+	os.Remove(file.KeyPath)
+	if err := file.Set("token/personal", "gho_other"); err == nil {
+		t.Errorf("Expected an error instead of generating a new key for existing secrets, but got none")
+	}
+}
+
+func TestFileWithPassphrase(t *testing.T) {
+	dir := t.TempDir()
+	asked := 0
+	passphrase := func(value string) func() ([]byte, error) {
+		return func() ([]byte, error) {
+			asked++
+			return []byte(value), nil
+		}
+	}
+	file := &File{Path: filepath.Join(dir, "secrets"), KeyPath: filepath.Join(dir, "secrets.key"), Passphrase: passphrase("correct horse")}
+
+	if err := file.Set("token/work", "gho_secret"); err != nil {
+		t.Fatalf("Did not expect an error, but got: %v", err)
+	}
+	if err := file.Set("passphrase/work", "hunter2"); err != nil {
+		t.Fatalf("Did not expect an error, but got: %v", err)
+	}
+	if asked != 1 {
+		t.Errorf("Expected the passphrase to be asked for once, but it was asked for %d times", asked)
+	}
+	if _, err := os.Stat(file.KeyPath); !os.IsNotExist(err) {
+		t.Errorf("Expected no key file with a passphrase, but got: %v", err)
+	}
+
+	reopened := &File{Path: file.Path, KeyPath: file.KeyPath, Passphrase: passphrase("correct horse")}
+	if value, err := reopened.Get("token/work"); err != nil || value != "gho_secret" {
+		t.Errorf("Expected 'gho_secret', but got '%s', %v", value, err)
+	}
+
+	wrong := &File{Path: file.Path, KeyPath: file.KeyPath, Passphrase: passphrase("wrong")}
+	if _, err := wrong.Get("token/work"); err == nil {
+		t.Errorf("Expected an error with a wrong passphrase, but got none")
+	}
+
+	withoutPassphrase := &File{Path: file.Path, KeyPath: file.KeyPath}
+	if _, err := withoutPassphrase.Get("token/work"); err == nil {
+		t.Errorf("Expected an error without a passphrase, but got none")
+	}
+
+	empty := &File{Path: filepath.Join(dir, "other"), KeyPath: file.KeyPath, Passphrase: passphrase("")}
+	if err := empty.Set("token/work", "gho_secret"); err == nil {
+		t.Errorf("Expected an error with an empty passphrase, but got none")
+	}
+}
+
+func TestFileSwitchesBetweenKeyFileAndPassphrase(t *testing.T) {
+	dir := t.TempDir()
+	withKeyFile := &File{Path: filepath.Join(dir, "secrets"), KeyPath: filepath.Join(dir, "secrets.key")}
+	if err := withKeyFile.Set("token/work", "gho_secret"); err != nil {
+		t.Fatalf("Did not expect an error, but got: %v", err)
+	}
+
+	withPassphrase := &File{Path: withKeyFile.Path, KeyPath: withKeyFile.KeyPath, Passphrase: func() ([]byte, error) { return []byte("pw"), nil }}
+	if err := withPassphrase.Set("token/personal", "gho_other"); err != nil {
+		t.Fatalf("Expected the key file encrypted secrets to be re-encrypted with the passphrase, but got: %v", err)
+	}
+	if _, err := os.Stat(withKeyFile.KeyPath); !os.IsNotExist(err) {
+		t.Errorf("Expected the key file to be removed, but got: %v", err)
+	}
+
+	if value, err := withPassphrase.Get("token/work"); err != nil || value != "gho_secret" {
+		t.Errorf("Expected 'gho_secret', but got '%s', %v", value, err)
+	}
+
+	if err := withKeyFile.Set("token/ci", "gho_ci"); err == nil {
+		t.Errorf("Expected an error reading passphrase encrypted secrets without the passphrase, but got none")
+	}
+
+	backToKeyFile := &File{Path: withKeyFile.Path, KeyPath: withKeyFile.KeyPath, Unlock: func() ([]byte, error) { return []byte("pw"), nil }}
+	if value, err := backToKeyFile.Get("token/work"); err != nil || value != "gho_secret" {
+		t.Fatalf("Expected the passphrase encrypted secrets to be readable after switching back, but got '%s', %v", value, err)
+	}
+	if _, err := os.Stat(withKeyFile.KeyPath); err != nil {
+		t.Errorf("Expected the secrets to be re-encrypted with a new key file, but got: %v", err)
+	}
+
+	// From now on, neither the passphrase nor Unlock is needed.
+	withKeyFile = &File{Path: withKeyFile.Path, KeyPath: withKeyFile.KeyPath}
+	if err := withKeyFile.Set("token/ci", "gho_ci"); err != nil {
+		t.Fatalf("Did not expect an error, but got: %v", err)
+	}
+	if names, err := withKeyFile.List(); err != nil || !reflect.DeepEqual(names, []string{"token/ci", "token/personal", "token/work"}) {
+		t.Errorf("Expected every secret to be kept, but got %v, %v", names, err)
+	}
+
+	withPassphrase = &File{Path: withKeyFile.Path, KeyPath: withKeyFile.KeyPath, Passphrase: func() ([]byte, error) { return []byte("pw2"), nil }}
+	if value, err := withPassphrase.Get("token/ci"); err != nil || value != "gho_ci" {
+		t.Fatalf("Expected 'gho_ci', but got '%s', %v", value, err)
+	}
+	if _, err := os.Stat(withKeyFile.KeyPath); !os.IsNotExist(err) {
+		t.Errorf("Expected reading to re-encrypt the secrets with the passphrase and remove the key file, but got: %v", err)
+	}
+}
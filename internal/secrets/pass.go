@@ -0,0 +1,92 @@
+package secrets
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// passPrefix is the folder of the password store gas keeps its secrets in.
+const passPrefix = "gas"
+
+// Pass stores secrets in the password store of pass(1), under gas/<name>.
+type Pass struct{}
+
+// run runs pass with the provided arguments and stdin, returning its stdout.
+func (Pass) run(stdin string, args ...string) (string, error) {
+	if _, err := exec.LookPath("pass"); err != nil {
+		return "", fmt.Errorf("pass not found, install it or select another secrets backend")
+	}
+
+	cmd := exec.Command("pass", args...)
+	cmd.Stdin = strings.NewReader(stdin)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if strings.Contains(stderr.String(), "is not in the password store") {
+			return "", ErrNotFound
+		}
+		return "", fmt.Errorf("pass %s failed: %s", args[0], strings.TrimSpace(stderr.String()))
+	}
+
+	return stdout.String(), nil
+}
+
+// Get returns the first line of the entry of the secret.
+func (p Pass) Get(name string) (string, error) {
+	output, err := p.run("", "show", passPrefix+"/"+name)
+	if err != nil {
+		return "", err
+	}
+
+	return strings.SplitN(output, "\n", 2)[0], nil
+}
+
+// Set stores value under name, replacing a previous value.
+func (p Pass) Set(name, value string) error {
+	_, err := p.run(value+"\n", "insert", "--multiline", "--force", passPrefix+"/"+name)
+	return err
+}
+
+// Delete removes the secret stored under name.
+func (p Pass) Delete(name string) error {
+	_, err := p.run("", "rm", "--force", passPrefix+"/"+name)
+	return err
+}
+
+// List returns the names of the entries in the gas folder of the password store.
+func (Pass) List() ([]string, error) {
+	dir := os.Getenv("PASSWORD_STORE_DIR")
+	if dir == "" {
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			return nil, fmt.Errorf("could not find user home directory: %w", err)
+		}
+		dir = filepath.Join(homeDir, ".password-store")
+	}
+	root := filepath.Join(dir, passPrefix)
+
+	var names []string
+	err := filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() || !strings.HasSuffix(path, ".gpg") {
+			return err
+		}
+		name, err := filepath.Rel(root, strings.TrimSuffix(path, ".gpg"))
+		if err != nil {
+			return err
+		}
+		names = append(names, filepath.ToSlash(name))
+		return nil
+	})
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	return names, err
+}
@@ -0,0 +1,124 @@
+package secrets
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/spf13/viper"
+)
+
+// Config keys of the secret storage.
+const (
+	// BackendKey selects the backend storing secrets, FileBackend by default.
+	BackendKey = "secrets.backend"
+	// PassphraseKey makes the file backend derive its key from a passphrase instead of a key file.
+	PassphraseKey = "secrets.passphrase"
+)
+
+// Backends secrets can be stored in.
+const (
+	FileBackend = "file"
+	PassBackend = "pass"
+	EnvBackend  = "env"
+)
+
+// PassphraseEnvVar holds the passphrase of the secrets file, so that it's not asked for, e.g. in CI.
+const PassphraseEnvVar = "GAS_SECRETS_PASSPHRASE"
+
+var (
+	// ErrNotFound is returned when no secret is stored under the requested name.
+	ErrNotFound = errors.New("secret not found")
+	// ErrReadOnly is returned when writing to a backend that can only be read from.
+	ErrReadOnly = errors.New("the backend is read only")
+)
+
+// SecretStore stores secrets, such as API tokens and key passphrases, under names like "token/work".
+type SecretStore interface {
+	Get(name string) (string, error)
+	Set(name, value string) error
+	Delete(name string) error
+	// List returns the names of the stored secrets.
+	List() ([]string, error)
+}
+
+var validName = regexp.MustCompile(`^[A-Za-z0-9._-]+(/[A-Za-z0-9._-]+)*$`)
+
+// ValidateName checks that a secret name can be stored by every backend.
+func ValidateName(name string) error {
+	if !validName.MatchString(name) || strings.Contains("/"+name+"/", "/../") || strings.Contains("/"+name+"/", "/./") {
+		return fmt.Errorf("invalid secret name '%s', use letters, digits, '.', '_' and '-' separated by '/'", name)
+	}
+
+	return nil
+}
+
+// Open returns the store of the provided backend.
+func Open(backend string) (SecretStore, error) {
+	switch backend {
+	case "", FileBackend:
+		file, err := DefaultFile()
+		if err != nil {
+			return nil, err
+		}
+		if viper.GetBool(PassphraseKey) {
+			file.Passphrase = promptPassphrase
+		} else {
+			file.Unlock = promptPassphrase
+		}
+		return file, nil
+	case PassBackend:
+		return &Pass{}, nil
+	case EnvBackend:
+		return Env{}, nil
+	default:
+		return nil, fmt.Errorf("unknown secrets backend '%s', use '%s', '%s' or '%s'", backend, FileBackend, PassBackend, EnvBackend)
+	}
+}
+
+// Default returns the store of the backend selected in the config.
+func Default() (SecretStore, error) {
+	return Open(viper.GetString(BackendKey))
+}
+
+// promptPassphrase returns the passphrase of the secrets file from the environment, or asks for it.
+func promptPassphrase() ([]byte, error) {
+	if passphrase := os.Getenv(PassphraseEnvVar); passphrase != "" {
+		return []byte(passphrase), nil
+	}
+
+	var passphrase string
+	err := survey.AskOne(&survey.Password{Message: "Enter the passphrase of the gas secrets file:"}, &passphrase)
+	return []byte(passphrase), err
+}
+
+// Migrate copies every secret from one store to another and removes it from the source.
+// It returns the names of the migrated secrets.
+func Migrate(from, to SecretStore) ([]string, error) {
+	names, err := from.List()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, name := range names {
+		value, err := from.Get(name)
+		if err != nil {
+			return nil, fmt.Errorf("could not read secret '%s': %w", name, err)
+		}
+		if err := to.Set(name, value); err != nil {
+			return nil, fmt.Errorf("could not write secret '%s': %w", name, err)
+		}
+	}
+
+	// Secrets are only removed once all of them were copied, so a failed migration loses nothing.
+	for _, name := range names {
+		if err := from.Delete(name); err != nil {
+			return names, fmt.Errorf("could not remove secret '%s' from the old backend: %w", name, err)
+		}
+	}
+
+	return names, nil
+}
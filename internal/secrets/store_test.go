@@ -0,0 +1,71 @@
+package secrets
+
+import (
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestMigrate(t *testing.T) {
+	dir := t.TempDir()
+	from := &File{Path: filepath.Join(dir, "from"), KeyPath: filepath.Join(dir, "from.key")}
+	to := &File{Path: filepath.Join(dir, "to"), KeyPath: filepath.Join(dir, "to.key")}
+	from.Set("token/work", "gho_secret")
+	from.Set("passphrase/work", "hunter2")
+
+	migrated, err := Migrate(from, to)
+	if err != nil {
+		t.Fatalf("Did not expect an error, but got: %v", err)
+	}
+	if want := []string{"passphrase/work", "token/work"}; !reflect.DeepEqual(migrated, want) {
+		t.Errorf("Expected %v to be migrated, but got %v", want, migrated)
+	}
+
+	if value, err := to.Get("token/work"); err != nil || value != "gho_secret" {
+		t.Errorf("Expected 'gho_secret' in the new store, but got '%s', %v", value, err)
+	}
+	if names, _ := from.List(); len(names) != 0 {
+		t.Errorf("Expected the old store to be empty, but got %v", names)
+	}
+}
+
+func TestMigrateKeepsSecretsWhenCopyingFails(t *testing.T) {
+	dir := t.TempDir()
+	from := &File{Path: filepath.Join(dir, "from"), KeyPath: filepath.Join(dir, "from.key")}
+	from.Set("token/work", "gho_secret")
+
+	if _, err := Migrate(from, Env{}); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("Expected ErrReadOnly, but got: %v", err)
+	}
+	if value, err := from.Get("token/work"); err != nil || value != "gho_secret" {
+		t.Errorf("Expected the secret to be kept, but got '%s', %v", value, err)
+	}
+}
+
+func TestEnv(t *testing.T) {
+	t.Setenv("GAS_TOKEN_MY_WORK", "gho_secret")
+
+	if got := EnvVar("token/my-work"); got != "GAS_TOKEN_MY_WORK" {
+		t.Errorf("Expected 'GAS_TOKEN_MY_WORK', but got '%s'", got)
+	}
+	if value, err := (Env{}).Get("token/my-work"); err != nil || value != "gho_secret" {
+		t.Errorf("Expected 'gho_secret', but got '%s', %v", value, err)
+	}
+	if _, err := (Env{}).Get("token/other"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, but got: %v", err)
+	}
+}
+
+func TestValidateName(t *testing.T) {
+	for _, name := range []string{"token/work", "passphrase/john.doe", "ci_token"} {
+		if err := ValidateName(name); err != nil {
+			t.Errorf("Expected '%s' to be valid, but got: %v", name, err)
+		}
+	}
+	for _, name := range []string{"", "/token", "token/", "token//work", "../token", "token/./work", "token work"} {
+		if err := ValidateName(name); err == nil {
+			t.Errorf("Expected '%s' to be invalid", name)
+		}
+	}
+}
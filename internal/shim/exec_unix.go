@@ -0,0 +1,16 @@
+//go:build !windows
+
+package shim
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// run replaces the gas process with the real git binary.
+func run(realGit string, args []string) int {
+	err := syscall.Exec(realGit, append([]string{"git"}, args...), os.Environ())
+	fmt.Fprintln(os.Stderr, "gas: could not run git:", err)
+	return 1
+}
@@ -0,0 +1,30 @@
+//go:build windows
+
+package shim
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+)
+
+// run runs the real git binary and forwards its exit code, since Windows cannot replace the running process.
+func run(realGit string, args []string) int {
+	gitCmd := exec.Command(realGit, args...)
+	gitCmd.Stdout = os.Stdout
+	gitCmd.Stderr = os.Stderr
+	gitCmd.Stdin = os.Stdin
+
+	err := gitCmd.Run()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode()
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "gas: could not run git:", err)
+		return 1
+	}
+
+	return 0
+}
@@ -0,0 +1,73 @@
+package shim
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+)
+
+// DefaultDir returns the directory the git shim is installed into by default.
+func DefaultDir() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get user home directory: %w", err)
+	}
+
+	return filepath.Join(homeDir, ".gas", "shim"), nil
+}
+
+// shimPath returns the path of the git shim inside dir.
+func shimPath(dir string) string {
+	if runtime.GOOS == "windows" {
+		return filepath.Join(dir, "git.exe")
+	}
+	return filepath.Join(dir, "git")
+}
+
+// Install creates a link named git in dir pointing to the gas binary at target.
+func Install(dir, target string) (string, error) {
+	path := shimPath(dir)
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create shim directory: %w", err)
+	}
+
+	if _, err := os.Lstat(path); err == nil {
+		if !isSameFile(path, target) {
+			return "", fmt.Errorf("'%s' already exists and is not a gas shim", path)
+		}
+		return path, nil
+	}
+
+	if err := os.Symlink(target, path); err != nil {
+		return "", fmt.Errorf("failed to create git shim: %w", err)
+	}
+
+	return path, nil
+}
+
+// Uninstall removes the git shim from dir, if it points to the gas binary at target.
+func Uninstall(dir, target string) (string, error) {
+	path := shimPath(dir)
+
+	if _, err := os.Lstat(path); os.IsNotExist(err) {
+		return "", errors.New("git shim is not installed")
+	}
+
+	if !isSameFile(path, target) {
+		return "", fmt.Errorf("'%s' is not a gas shim, refusing to remove it", path)
+	}
+
+	if err := os.Remove(path); err != nil {
+		return "", fmt.Errorf("failed to remove git shim: %w", err)
+	}
+
+	return path, nil
+}
+
+// Executable returns the resolved path of the running gas binary, which the shim should point to.
+func Executable() (string, error) {
+	return executable()
+}
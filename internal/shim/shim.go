@@ -0,0 +1,186 @@
+package shim
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/style77/gas/internal/git"
+)
+
+// realGitEnv holds the path of the real git binary once the shim resolved it.
+// Nested git invocations (e.g. from hooks) inherit it and skip the lookup and the identity checks.
+const realGitEnv = "GAS_SHIM_REAL_GIT"
+
+// sensitiveCommands are the git subcommands that create or publish commits.
+var sensitiveCommands = map[string]bool{
+	"am":          true,
+	"cherry-pick": true,
+	"commit":      true,
+	"merge":       true,
+	"pull":        true,
+	"push":        true,
+	"rebase":      true,
+	"revert":      true,
+	"tag":         true,
+}
+
+// globalOptionsWithValue are the git options placed before the subcommand that take a separate value.
+var globalOptionsWithValue = map[string]bool{
+	"-C":          true,
+	"-c":          true,
+	"--git-dir":   true,
+	"--work-tree": true,
+	"--namespace": true,
+}
+
+// IsInvokedAsGit checks if gas was started through a link or copy named git.
+func IsInvokedAsGit(argv0 string) bool {
+	name := strings.ToLower(filepath.Base(argv0))
+	return name == "git" || name == "git.exe"
+}
+
+// Run resolves the real git binary and runs it with the provided arguments.
+// Sensitive subcommands are passed to check first, the command is only run when it returns true.
+// It returns the exit code of the shim.
+func Run(args []string, check func(args []string) (bool, error)) int {
+	self, err := executable()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "gas:", err)
+		return 1
+	}
+
+	if realGit := os.Getenv(realGitEnv); realGit != "" {
+		if isSameFile(realGit, self) {
+			fmt.Fprintf(os.Stderr, "gas: %s points back to gas, refusing to run recursively\n", realGitEnv)
+			return 1
+		}
+		return run(realGit, args)
+	}
+
+	realGit, err := FindRealGit(os.Getenv("PATH"), self)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "gas:", err)
+		return 1
+	}
+
+	git.Binary = realGit
+	os.Setenv(realGitEnv, realGit)
+
+	subcommand, workDir := ParseSubcommand(args)
+	if sensitiveCommands[subcommand] {
+		ok, err := checkIn(workDir, args, check)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "gas:", err)
+			return 1
+		}
+		if !ok {
+			fmt.Fprintln(os.Stderr, "Exiting.")
+			return 1
+		}
+	}
+
+	return run(realGit, args)
+}
+
+// checkIn runs check in the directory selected with "git -C".
+func checkIn(workDir string, args []string, check func(args []string) (bool, error)) (bool, error) {
+	if workDir == "" {
+		return check(args)
+	}
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		return false, err
+	}
+	if err := os.Chdir(workDir); err != nil {
+		return false, err
+	}
+	defer os.Chdir(cwd)
+
+	return check(args)
+}
+
+// FindRealGit looks for the git binary in the provided PATH, skipping gas itself.
+func FindRealGit(pathEnv, self string) (string, error) {
+	names := []string{"git"}
+	if runtime.GOOS == "windows" {
+		names = []string{"git.exe", "git.cmd", "git.bat"}
+	}
+
+	for _, dir := range filepath.SplitList(pathEnv) {
+		if dir == "" {
+			continue
+		}
+
+		for _, name := range names {
+			candidate := filepath.Join(dir, name)
+			info, err := os.Stat(candidate)
+			if err != nil || info.IsDir() {
+				continue
+			}
+			if runtime.GOOS != "windows" && info.Mode()&0111 == 0 {
+				continue
+			}
+			if isSameFile(candidate, self) {
+				continue
+			}
+
+			return candidate, nil
+		}
+	}
+
+	return "", errors.New("could not find the real git binary in PATH")
+}
+
+// ParseSubcommand returns the git subcommand and the directory passed with "-C", skipping global options.
+func ParseSubcommand(args []string) (string, string) {
+	workDir := ""
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if !strings.HasPrefix(arg, "-") {
+			return arg, workDir
+		}
+
+		if globalOptionsWithValue[arg] {
+			if arg == "-C" && i+1 < len(args) {
+				if filepath.IsAbs(args[i+1]) {
+					workDir = args[i+1]
+				} else {
+					workDir = filepath.Join(workDir, args[i+1])
+				}
+			}
+			i++
+		}
+	}
+
+	return "", workDir
+}
+
+// executable returns the resolved path of the running gas binary.
+func executable() (string, error) {
+	self, err := os.Executable()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.EvalSymlinks(self)
+}
+
+// isSameFile checks if both paths resolve to the same file.
+func isSameFile(a, b string) bool {
+	aInfo, err := os.Stat(a)
+	if err != nil {
+		return false
+	}
+	bInfo, err := os.Stat(b)
+	if err != nil {
+		return false
+	}
+
+	return os.SameFile(aInfo, bInfo)
+}
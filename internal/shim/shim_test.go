@@ -0,0 +1,156 @@
+package shim
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// Helper function to create an executable file in dir
+func createExecutable(t *testing.T, dir, name string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"), 0755); err != nil {
+		t.Fatalf("Failed to create executable: %v", err)
+	}
+	return path
+}
+
+func TestIsInvokedAsGit(t *testing.T) {
+	tests := []struct {
+		argv0 string
+		want  bool
+	}{
+		{"git", true},
+		{"/home/john/.gas/shim/git", true},
+		{"C:\\tools\\GIT.EXE", runtime.GOOS == "windows"},
+		{"gas", false},
+		{"/usr/local/bin/gas", false},
+		{"git-upload-pack", false},
+	}
+
+	for _, tt := range tests {
+		if got := IsInvokedAsGit(tt.argv0); got != tt.want {
+			t.Errorf("IsInvokedAsGit(%q) = %v, want %v", tt.argv0, got, tt.want)
+		}
+	}
+}
+
+func TestParseSubcommand(t *testing.T) {
+	tests := []struct {
+		name           string
+		args           []string
+		wantSubcommand string
+		wantWorkDir    string
+	}{
+		{
+			name:           "Plain subcommand",
+			args:           []string{"commit", "-m", "message"},
+			wantSubcommand: "commit",
+		},
+		{
+			name:           "Config override before subcommand",
+			args:           []string{"-c", "user.email=john@example.com", "push"},
+			wantSubcommand: "push",
+		},
+		{
+			name:           "Work directory",
+			args:           []string{"-C", "/tmp/repo", "--no-pager", "tag", "v1"},
+			wantSubcommand: "tag",
+			wantWorkDir:    "/tmp/repo",
+		},
+		{
+			name:           "Nested work directories",
+			args:           []string{"-C", "/tmp", "-C", "repo", "status"},
+			wantSubcommand: "status",
+			wantWorkDir:    filepath.Join("/tmp", "repo"),
+		},
+		{
+			name: "Only options",
+			args: []string{"--version"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			subcommand, workDir := ParseSubcommand(tt.args)
+			if subcommand != tt.wantSubcommand {
+				t.Errorf("ParseSubcommand() subcommand = %v, want %v", subcommand, tt.wantSubcommand)
+			}
+			if workDir != tt.wantWorkDir {
+				t.Errorf("ParseSubcommand() workDir = %v, want %v", workDir, tt.wantWorkDir)
+			}
+		})
+	}
+}
+
+func TestFindRealGit(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("symlinks require elevated privileges on windows")
+	}
+
+	self := createExecutable(t, t.TempDir(), "gas")
+
+	shimDir := t.TempDir()
+	if _, err := Install(shimDir, self); err != nil {
+		t.Fatalf("Failed to install shim: %v", err)
+	}
+
+	realDir := t.TempDir()
+	realGit := createExecutable(t, realDir, "git")
+
+	emptyDir := t.TempDir()
+
+	got, err := FindRealGit(strings.Join([]string{emptyDir, shimDir, realDir}, string(os.PathListSeparator)), self)
+	if err != nil {
+		t.Fatalf("Did not expect an error, but got: %v", err)
+	}
+	if got != realGit {
+		t.Errorf("Expected real git '%s', but got '%s'", realGit, got)
+	}
+
+	_, err = FindRealGit(shimDir, self)
+	if err == nil {
+		t.Errorf("Expected an error when only the shim is in PATH, but got none")
+	}
+}
+
+func TestInstallAndUninstall(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("symlinks require elevated privileges on windows")
+	}
+
+	self := createExecutable(t, t.TempDir(), "gas")
+	dir := filepath.Join(t.TempDir(), "shim")
+
+	path, err := Install(dir, self)
+	if err != nil {
+		t.Fatalf("Did not expect an error, but got: %v", err)
+	}
+	if !isSameFile(path, self) {
+		t.Errorf("Expected '%s' to point to '%s'", path, self)
+	}
+
+	// installing twice is a no-op
+	if _, err := Install(dir, self); err != nil {
+		t.Errorf("Did not expect an error on reinstall, but got: %v", err)
+	}
+
+	if _, err := Uninstall(dir, self); err != nil {
+		t.Fatalf("Did not expect an error, but got: %v", err)
+	}
+	if _, err := os.Lstat(path); !os.IsNotExist(err) {
+		t.Errorf("Expected shim to be removed")
+	}
+
+	// a real git binary must never be replaced or removed
+	createExecutable(t, dir, "git")
+	if _, err := Install(dir, self); err == nil {
+		t.Errorf("Expected an error when a foreign git exists, but got none")
+	}
+	if _, err := Uninstall(dir, self); err == nil {
+		t.Errorf("Expected an error when removing a foreign git, but got none")
+	}
+}
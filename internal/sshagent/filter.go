@@ -0,0 +1,111 @@
+package sshagent
+
+import (
+	"errors"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+// ErrKeyNotAllowed is returned when a client asks to use a key the filter hides.
+var ErrKeyNotAllowed = errors.New("key is not allowed for this account")
+
+// ErrReadOnly is returned when a client asks the filtering agent to change the upstream agent.
+var ErrReadOnly = errors.New("the filtering agent can't change ssh-agent, use SSH_AUTH_SOCK instead")
+
+// FilteredAgent exposes only the allowed keys of an upstream agent. It is read only, so that its clients can't
+// add, remove or lock keys of the upstream agent.
+type FilteredAgent struct {
+	upstream agent.ExtendedAgent
+	allowed  []ssh.PublicKey
+}
+
+// NewFilteredAgent wraps upstream so that only the allowed keys can be listed and used.
+func NewFilteredAgent(upstream agent.ExtendedAgent, allowed []ssh.PublicKey) *FilteredAgent {
+	return &FilteredAgent{upstream: upstream, allowed: allowed}
+}
+
+func (f *FilteredAgent) isAllowed(key ssh.PublicKey) bool {
+	// certificates are allowed when they certify an allowed key
+	for _, allowed := range f.allowed {
+		if certifiesKey(key.Marshal(), allowed) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// List returns the upstream keys that are allowed.
+func (f *FilteredAgent) List() ([]*agent.Key, error) {
+	keys, err := f.upstream.List()
+	if err != nil {
+		return nil, err
+	}
+
+	var result []*agent.Key
+	for _, key := range keys {
+		publicKey, err := ssh.ParsePublicKey(key.Blob)
+		if err != nil {
+			continue
+		}
+		if f.isAllowed(publicKey) {
+			result = append(result, key)
+		}
+	}
+
+	return result, nil
+}
+
+// Sign signs data with an allowed key.
+func (f *FilteredAgent) Sign(key ssh.PublicKey, data []byte) (*ssh.Signature, error) {
+	if !f.isAllowed(key) {
+		return nil, ErrKeyNotAllowed
+	}
+
+	return f.upstream.Sign(key, data)
+}
+
+// SignWithFlags signs data with an allowed key, e.g. using rsa-sha2 signatures.
+func (f *FilteredAgent) SignWithFlags(key ssh.PublicKey, data []byte, flags agent.SignatureFlags) (*ssh.Signature, error) {
+	if !f.isAllowed(key) {
+		return nil, ErrKeyNotAllowed
+	}
+
+	return f.upstream.SignWithFlags(key, data, flags)
+}
+
+// Add is refused, keys are added to the upstream agent directly.
+func (f *FilteredAgent) Add(key agent.AddedKey) error {
+	return ErrReadOnly
+}
+
+// Remove is refused, keys are removed from the upstream agent directly.
+func (f *FilteredAgent) Remove(key ssh.PublicKey) error {
+	return ErrReadOnly
+}
+
+// RemoveAll is refused, keys are removed from the upstream agent directly.
+func (f *FilteredAgent) RemoveAll() error {
+	return ErrReadOnly
+}
+
+// Lock is refused, it would lock every key of the upstream agent.
+func (f *FilteredAgent) Lock(passphrase []byte) error {
+	return ErrReadOnly
+}
+
+// Unlock is refused, it would unlock every key of the upstream agent.
+func (f *FilteredAgent) Unlock(passphrase []byte) error {
+	return ErrReadOnly
+}
+
+// Signers is not supported, private keys never leave the upstream agent.
+func (f *FilteredAgent) Signers() ([]ssh.Signer, error) {
+	return nil, errors.New("signers are not supported by the filtering agent")
+}
+
+// Extension is not supported by the filtering agent.
+func (f *FilteredAgent) Extension(extensionType string, contents []byte) ([]byte, error) {
+	return nil, agent.ErrExtensionUnsupported
+}
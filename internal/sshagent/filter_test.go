@@ -0,0 +1,133 @@
+package sshagent
+
+import (
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/style77/gas/internal/sshtest"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+// newTestKeyring returns an in-memory agent holding a work and a personal key.
+func newTestKeyring(t *testing.T) (agent.ExtendedAgent, ssh.PublicKey, ssh.PublicKey) {
+	t.Helper()
+	keyring := agent.NewKeyring().(agent.ExtendedAgent)
+
+	workPrivate, work := sshtest.NewKey(t)
+	personalPrivate, personal := sshtest.NewKey(t)
+	for _, key := range []agent.AddedKey{
+		{PrivateKey: workPrivate, Comment: "work"},
+		{PrivateKey: personalPrivate, Comment: "personal"},
+	} {
+		if err := keyring.Add(key); err != nil {
+			t.Fatalf("Failed to add key: %v", err)
+		}
+	}
+
+	return keyring, work, personal
+}
+
+func TestFilteredAgent(t *testing.T) {
+	keyring, work, personal := newTestKeyring(t)
+	filtered := NewFilteredAgent(keyring, []ssh.PublicKey{work})
+
+	keys, err := filtered.List()
+	if err != nil {
+		t.Fatalf("Did not expect an error, but got: %v", err)
+	}
+	if len(keys) != 1 || keys[0].Comment != "work" {
+		t.Fatalf("Expected only the work key to be listed, but got %v", keys)
+	}
+
+	signature, err := filtered.Sign(work, []byte("data"))
+	if err != nil {
+		t.Fatalf("Expected to sign with the work key, but got: %v", err)
+	}
+	if err := work.Verify([]byte("data"), signature); err != nil {
+		t.Errorf("Expected a valid signature, but got: %v", err)
+	}
+
+	if _, err := filtered.Sign(personal, []byte("data")); err != ErrKeyNotAllowed {
+		t.Errorf("Expected ErrKeyNotAllowed for the personal key, but got: %v", err)
+	}
+
+	if err := filtered.RemoveAll(); err != ErrReadOnly {
+		t.Errorf("Expected RemoveAll to be refused, but got: %v", err)
+	}
+	if err := filtered.Remove(work); err != ErrReadOnly {
+		t.Errorf("Expected Remove to be refused, but got: %v", err)
+	}
+	if err := filtered.Lock([]byte("secret")); err != ErrReadOnly {
+		t.Errorf("Expected Lock to be refused, but got: %v", err)
+	}
+	privateKey, _ := sshtest.NewKey(t)
+	if err := filtered.Add(agent.AddedKey{PrivateKey: privateKey}); err != ErrReadOnly {
+		t.Errorf("Expected Add to be refused, but got: %v", err)
+	}
+	remaining, _ := keyring.List()
+	if len(remaining) != 2 {
+		t.Errorf("Expected the upstream keys to be left alone, but got %v", remaining)
+	}
+}
+
+func TestServeConn(t *testing.T) {
+	keyring, work, personal := newTestKeyring(t)
+
+	client, server := net.Pipe()
+	dial := func() (agent.ExtendedAgent, io.Closer, error) {
+		return keyring, io.NopCloser(nil), nil
+	}
+	keys := func() ([]ssh.PublicKey, error) {
+		return []ssh.PublicKey{personal}, nil
+	}
+	go ServeConn(server, dial, keys)
+	defer client.Close()
+
+	agentClient := agent.NewClient(client)
+	listed, err := agentClient.List()
+	if err != nil {
+		t.Fatalf("Did not expect an error, but got: %v", err)
+	}
+	if len(listed) != 1 || listed[0].Comment != "personal" {
+		t.Fatalf("Expected only the personal key to be listed, but got %v", listed)
+	}
+
+	if _, err := agentClient.Sign(work, []byte("data")); err == nil {
+		t.Errorf("Expected signing with the work key to fail, but it succeeded")
+	}
+	if _, err := agentClient.Sign(personal, []byte("data")); err != nil {
+		t.Errorf("Expected to sign with the personal key, but got: %v", err)
+	}
+}
+
+func TestListen(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "agent")
+	if err := os.Mkdir(dir, 0755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+
+	listener, err := Listen(SocketPath(dir, DefaultSocketName))
+	if err != nil {
+		t.Fatalf("Did not expect an error, but got: %v", err)
+	}
+	defer listener.Close()
+
+	info, err := os.Stat(dir)
+	if err != nil {
+		t.Fatalf("Failed to stat directory: %v", err)
+	}
+	if info.Mode().Perm() != 0700 {
+		t.Errorf("Expected the agent directory to be restricted to 0700, but got %o", info.Mode().Perm())
+	}
+	info, err = os.Stat(SocketPath(dir, DefaultSocketName))
+	if err != nil {
+		t.Fatalf("Failed to stat socket: %v", err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("Expected the socket to be restricted to 0600, but got %o", info.Mode().Perm())
+	}
+}
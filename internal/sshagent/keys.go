@@ -0,0 +1,110 @@
+package sshagent
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+// managedCommentPrefix marks the keys gas added to an agent, so they can be told apart from keys added by hand.
+const managedCommentPrefix = "gas:"
+
+// AddOptions controls how long and under which conditions an added key can be used.
+type AddOptions struct {
+	// Lifetime after which the agent forgets the key. Zero keeps it until the agent exits.
+	Lifetime time.Duration
+	// Confirm asks the agent to confirm every use of the key, e.g. through ssh-askpass.
+	Confirm bool
+	// Certificate is added along with the key for accounts authenticating with a certificate.
+	Certificate *ssh.Certificate
+}
+
+// ManagedComment returns the comment gas uses for the key of the provided account.
+func ManagedComment(accountName string) string {
+	return managedCommentPrefix + accountName
+}
+
+// IsManaged checks if a key in the agent was added by gas.
+func IsManaged(key *agent.Key) bool {
+	return strings.HasPrefix(key.Comment, managedCommentPrefix)
+}
+
+// AddKey adds the private key of an account to the agent, replacing a previously added copy of the key or of its
+// certificate.
+func AddKey(upstream agent.Agent, accountName string, privateKey interface{}, options AddOptions) error {
+	if options.Lifetime < 0 || options.Lifetime.Seconds() > float64(^uint32(0)) {
+		return fmt.Errorf("invalid key lifetime '%s'", options.Lifetime)
+	}
+
+	signer, err := ssh.NewSignerFromKey(privateKey)
+	if err != nil {
+		return fmt.Errorf("invalid key: %w", err)
+	}
+	// Only the copies the agent lists are removed, so that failing to remove one isn't mistaken for it missing.
+	keys, err := upstream.List()
+	if err != nil {
+		return fmt.Errorf("could not list keys in ssh-agent: %w", err)
+	}
+	for _, key := range keys {
+		if !certifiesKey(key.Blob, signer.PublicKey()) {
+			continue
+		}
+		if err := upstream.Remove(key); err != nil {
+			return fmt.Errorf("could not replace key '%s' in ssh-agent: %w", key.Comment, err)
+		}
+	}
+
+	err = upstream.Add(agent.AddedKey{
+		PrivateKey:       privateKey,
+		Certificate:      options.Certificate,
+		Comment:          ManagedComment(accountName),
+		LifetimeSecs:     uint32(options.Lifetime.Seconds()),
+		ConfirmBeforeUse: options.Confirm,
+	})
+	if err != nil {
+		return fmt.Errorf("could not add key to ssh-agent: %w", err)
+	}
+
+	return nil
+}
+
+// RemoveManagedKeys removes the keys gas added to the agent, except keep. Keys added by hand are left alone.
+// It returns the comments of the removed keys.
+func RemoveManagedKeys(upstream agent.Agent, keep ssh.PublicKey) ([]string, error) {
+	keys, err := upstream.List()
+	if err != nil {
+		return nil, fmt.Errorf("could not list keys in ssh-agent: %w", err)
+	}
+
+	var removed []string
+	for _, key := range keys {
+		if !IsManaged(key) || (keep != nil && certifiesKey(key.Blob, keep)) {
+			continue
+		}
+
+		if err := upstream.Remove(key); err != nil {
+			return removed, fmt.Errorf("could not remove key '%s' from ssh-agent: %w", key.Comment, err)
+		}
+		removed = append(removed, key.Comment)
+	}
+
+	return removed, nil
+}
+
+// certifiesKey checks if blob is the provided key or a certificate of it.
+func certifiesKey(blob []byte, key ssh.PublicKey) bool {
+	if bytes.Equal(blob, key.Marshal()) {
+		return true
+	}
+
+	publicKey, err := ssh.ParsePublicKey(blob)
+	if err != nil {
+		return false
+	}
+	cert, ok := publicKey.(*ssh.Certificate)
+	return ok && bytes.Equal(cert.Key.Marshal(), key.Marshal())
+}
@@ -0,0 +1,78 @@
+package sshagent
+
+import (
+	"testing"
+	"time"
+
+	"github.com/style77/gas/internal/sshtest"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+// recordingAgent remembers the last key added to the wrapped agent.
+type recordingAgent struct {
+	agent.Agent
+	added agent.AddedKey
+}
+
+func (r *recordingAgent) Add(key agent.AddedKey) error {
+	r.added = key
+	return r.Agent.Add(key)
+}
+
+func TestAddKey(t *testing.T) {
+	upstream := &recordingAgent{Agent: agent.NewKeyring()}
+	privateKey, publicKey := sshtest.NewKey(t)
+
+	err := AddKey(upstream, "work", privateKey, AddOptions{Lifetime: time.Hour, Confirm: true})
+	if err != nil {
+		t.Fatalf("Did not expect an error, but got: %v", err)
+	}
+	if upstream.added.LifetimeSecs != 3600 || !upstream.added.ConfirmBeforeUse {
+		t.Errorf("Expected a one hour lifetime with confirmation, but got %d, %v", upstream.added.LifetimeSecs, upstream.added.ConfirmBeforeUse)
+	}
+
+	keys, _ := upstream.List()
+	if len(keys) != 1 || keys[0].Comment != "gas:work" || string(keys[0].Blob) != string(publicKey.Marshal()) {
+		t.Errorf("Expected the work key to be added as 'gas:work', but got %v", keys)
+	}
+
+	if err := AddKey(upstream, "work", privateKey, AddOptions{Lifetime: time.Minute}); err != nil {
+		t.Fatalf("Did not expect an error, but got: %v", err)
+	}
+	keys, _ = upstream.List()
+	if len(keys) != 1 || upstream.added.LifetimeSecs != 60 {
+		t.Errorf("Expected the key to be replaced, but got %v", keys)
+	}
+
+	if err := AddKey(upstream, "work", privateKey, AddOptions{Lifetime: -time.Second}); err == nil {
+		t.Errorf("Expected an error for a negative lifetime, but got none")
+	}
+}
+
+func TestRemoveManagedKeys(t *testing.T) {
+	keyring := agent.NewKeyring()
+	workPrivate, work := sshtest.NewKey(t)
+	personalPrivate, _ := sshtest.NewKey(t)
+	manualPrivate, _ := sshtest.NewKey(t)
+
+	AddKey(keyring, "work", workPrivate, AddOptions{})
+	AddKey(keyring, "personal", personalPrivate, AddOptions{})
+	keyring.Add(agent.AddedKey{PrivateKey: manualPrivate, Comment: "me@laptop"})
+
+	removed, err := RemoveManagedKeys(keyring, work)
+	if err != nil {
+		t.Fatalf("Did not expect an error, but got: %v", err)
+	}
+	if len(removed) != 1 || removed[0] != "gas:personal" {
+		t.Errorf("Expected only 'gas:personal' to be removed, but got %v", removed)
+	}
+
+	keys, _ := keyring.List()
+	comments := map[string]bool{}
+	for _, key := range keys {
+		comments[key.Comment] = true
+	}
+	if len(keys) != 2 || !comments["gas:work"] || !comments["me@laptop"] {
+		t.Errorf("Expected the work key and the manually added key to stay, but got %v", keys)
+	}
+}
@@ -0,0 +1,127 @@
+package sshagent
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+// DefaultSocketName is the socket serving the keys of the currently selected account.
+const DefaultSocketName = "default"
+
+// KeysFunc returns the keys a socket exposes. It is called for every client connection,
+// so that switching accounts takes effect without restarting the agent.
+type KeysFunc func() ([]ssh.PublicKey, error)
+
+// Dialer connects to the upstream agent.
+type Dialer func() (agent.ExtendedAgent, io.Closer, error)
+
+// DefaultDir returns the directory holding the agent sockets.
+func DefaultDir() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("could not find user home directory: %w", err)
+	}
+
+	return filepath.Join(homeDir, ".gas", "agent"), nil
+}
+
+// SocketPath returns the path of the socket with the provided name in dir.
+func SocketPath(dir, name string) string {
+	return filepath.Join(dir, name+".sock")
+}
+
+// UpstreamDialer connects to the agent listening on socket, usually $SSH_AUTH_SOCK.
+func UpstreamDialer(socket string) Dialer {
+	return func() (agent.ExtendedAgent, io.Closer, error) {
+		if socket == "" {
+			return nil, nil, errors.New("SSH_AUTH_SOCK is not set, start ssh-agent first")
+		}
+
+		conn, err := net.Dial("unix", socket)
+		if err != nil {
+			return nil, nil, fmt.Errorf("could not connect to ssh-agent: %w", err)
+		}
+
+		return agent.NewClient(conn), conn, nil
+	}
+}
+
+// Listen creates a unix socket only the current user can connect to, replacing a stale one. The socket is created
+// in a directory only the current user can enter, so that nobody else can connect before its permissions are
+// restricted.
+func Listen(path string) (net.Listener, error) {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("could not create agent directory: %w", err)
+	}
+	// MkdirAll leaves the permissions of an existing directory alone
+	if err := os.Chmod(dir, 0700); err != nil {
+		return nil, fmt.Errorf("could not restrict agent directory permissions: %w", err)
+	}
+
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("could not remove stale socket '%s': %w", path, err)
+	}
+
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, fmt.Errorf("could not listen on '%s': %w", path, err)
+	}
+
+	if err := os.Chmod(path, 0600); err != nil {
+		listener.Close()
+		return nil, fmt.Errorf("could not restrict socket permissions: %w", err)
+	}
+
+	return listener, nil
+}
+
+// Serve accepts connections on listener until it is closed, serving each one through a
+// filtered view of a fresh upstream connection.
+func Serve(listener net.Listener, dial Dialer, keys KeysFunc) error {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return err
+		}
+
+		go func() {
+			if err := ServeConn(conn, dial, keys); err != nil {
+				fmt.Fprintf(os.Stderr, "gas agent: %v\n", err)
+			}
+		}()
+	}
+}
+
+// ServeConn serves a single client connection and closes it when done.
+func ServeConn(conn io.ReadWriteCloser, dial Dialer, keys KeysFunc) error {
+	defer conn.Close()
+
+	allowed, err := keys()
+	if err != nil {
+		return err
+	}
+
+	upstream, closer, err := dial()
+	if err != nil {
+		return err
+	}
+	defer closer.Close()
+
+	err = agent.ServeAgent(NewFilteredAgent(upstream, allowed), conn)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return err
+	}
+
+	return nil
+}
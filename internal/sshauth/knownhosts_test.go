@@ -0,0 +1,119 @@
+package sshauth
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/style77/gas/internal/sshtest"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+func TestKnownHostsContains(t *testing.T) {
+	key := sshtest.NewSigner(t).PublicKey()
+	content := strings.Join([]string{
+		knownhosts.Line([]string{"github.com"}, key),
+		knownhosts.Line([]string{knownhosts.HashHostname("gitlab.com")}, key),
+		knownhosts.Line([]string{"[ssh.github.com]:443"}, key),
+		knownhosts.Line([]string{"*.example.com", "!git.example.com"}, key),
+	}, "\n")
+
+	path := filepath.Join(t.TempDir(), "known_hosts")
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatalf("Failed to write known_hosts: %v", err)
+	}
+
+	tests := []struct {
+		host string
+		port int
+		want bool
+	}{
+		{"github.com", 22, true},
+		{"gitlab.com", 22, true},
+		{"ssh.github.com", 443, true},
+		{"ssh.github.com", 22, false},
+		{"code.example.com", 22, true},
+		{"git.example.com", 22, false},
+		{"bitbucket.org", 22, false},
+	}
+
+	for _, tt := range tests {
+		got, err := KnownHostsContains([]string{path, filepath.Join(t.TempDir(), "missing")}, tt.host, tt.port)
+		if err != nil {
+			t.Fatalf("Did not expect an error, but got: %v", err)
+		}
+		if got != tt.want {
+			t.Errorf("KnownHostsContains(%s, %d) = %v, want %v", tt.host, tt.port, got, tt.want)
+		}
+	}
+}
+
+const (
+	githubEd25519 = "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIOMqqnkVzrm0SdG6UOoqKLsabgH5C9okWi0dh2l9GKJl"
+	githubECDSA   = "ecdsa-sha2-nistp256 AAAAE2VjZHNhLXNoYTItbmlzdHAyNTYAAAAIbmlzdHAyNTYAAABBBEmKSENjQEezOmxkZMy7opKgwFB9nkt5YRrYMjNuG5N87uRgg6CLrbo5wAdT/y6v0mKV0U2w0WZ2YB/++Tpockg="
+)
+
+func TestVerifyPinnedHostKeys(t *testing.T) {
+	keys, err := ParseHostKeys([]string{githubEd25519, githubECDSA})
+	if err != nil {
+		t.Fatalf("Did not expect an error, but got: %v", err)
+	}
+	if mismatched := VerifyPinnedHostKeys("github.com", keys); len(mismatched) != 0 {
+		t.Errorf("Expected the published keys to match the pins, but got %v", mismatched)
+	}
+
+	forged := sshtest.NewSigner(t).PublicKey()
+	mismatched := VerifyPinnedHostKeys("github.com", append(keys, forged))
+	if len(mismatched) != 1 || mismatched[0] != ssh.FingerprintSHA256(forged) {
+		t.Errorf("Expected the forged key to be reported, but got %v", mismatched)
+	}
+
+	if mismatched := VerifyPinnedHostKeys("github.example.com", []ssh.PublicKey{forged}); len(mismatched) != 0 {
+		t.Errorf("Expected hosts without pins to be accepted, but got %v", mismatched)
+	}
+
+	if _, err := ParseHostKeys([]string{"ssh-ed25519 not-base64"}); err == nil {
+		t.Errorf("Expected an error for an invalid key, but got none")
+	}
+}
+
+func TestUpdateKnownHosts(t *testing.T) {
+	stale := sshtest.NewSigner(t).PublicKey()
+	other := sshtest.NewSigner(t).PublicKey()
+	path := filepath.Join(t.TempDir(), "known_hosts")
+	content := strings.Join([]string{
+		"# managed by hand",
+		knownhosts.Line([]string{"github.com", "140.82.121.4"}, stale),
+		knownhosts.Line([]string{"gitlab.com"}, other),
+		knownhosts.Line([]string{knownhosts.HashHostname("github.com")}, stale),
+	}, "\n")
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatalf("Failed to write known_hosts: %v", err)
+	}
+
+	keys, _ := ParseHostKeys([]string{githubEd25519})
+	replaced, err := UpdateKnownHosts(path, "github.com", 22, keys)
+	if err != nil {
+		t.Fatalf("Did not expect an error, but got: %v", err)
+	}
+	if replaced != 2 {
+		t.Errorf("Expected 2 replaced lines, but got %d", replaced)
+	}
+
+	updated, _ := os.ReadFile(path)
+	want := "# managed by hand\n" + knownhosts.Line([]string{"gitlab.com"}, other) + "\ngithub.com " + githubEd25519 + "\n"
+	if string(updated) != want {
+		t.Errorf("Expected:\n%s\nbut got:\n%s", want, updated)
+	}
+
+	callback, err := KnownHostsCallback(path)
+	if err != nil {
+		t.Fatalf("Did not expect an error, but got: %v", err)
+	}
+	if err := callback("github.com:22", &net.TCPAddr{IP: net.ParseIP("140.82.121.4"), Port: 22}, keys[0]); err != nil {
+		t.Errorf("Expected the synced key to be accepted, but got: %v", err)
+	}
+}
@@ -0,0 +1,200 @@
+package sshauth
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/style77/gas/internal/sshconfig"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// Target is the SSH server gas authenticates against.
+type Target struct {
+	Host string
+	Port int
+	User string
+}
+
+// Result is the outcome of authenticating against a forge.
+type Result struct {
+	// Login is the user the forge authenticated the key as. Empty if the forge doesn't tell, like Bitbucket Cloud.
+	Login string
+	// Banner is the message the forge sent after authenticating.
+	Banner string
+}
+
+// greetingPatterns match the greeting forges send after authenticating, capturing the login.
+var greetingPatterns = []*regexp.Regexp{
+	// GitHub: "Hi johnDoe98! You've successfully authenticated, but GitHub does not provide shell access."
+	regexp.MustCompile(`Hi ([^!\s]+)! You've successfully authenticated`),
+	// Gitea and Forgejo: "Hi there, johnDoe98! You've successfully authenticated with the key named laptop, but Forgejo does not provide shell access."
+	regexp.MustCompile(`Hi there, ([^!\s]+)! You've successfully authenticated`),
+	// GitLab: "Welcome to GitLab, @johnDoe98!"
+	regexp.MustCompile(`Welcome to GitLab, @([^!\s]+)!`),
+	// Bitbucket Server and older Bitbucket Cloud: "logged in as johnDoe98."
+	regexp.MustCompile(`logged in as ([\w-]+)\.`),
+}
+
+// anonymousGreetingPatterns match the greeting of forges that confirm the authentication without telling the login.
+var anonymousGreetingPatterns = []*regexp.Regexp{
+	// Bitbucket Cloud: "authenticated via ssh key.\n\nYou can use git to connect to Bitbucket. Shell access is disabled"
+	regexp.MustCompile(`^authenticated via (ssh|a deploy) key\.`),
+}
+
+// DefaultTimeout limits how long connecting to the forge may take.
+const DefaultTimeout = 15 * time.Second
+
+func (t Target) address() string {
+	return net.JoinHostPort(t.Host, strconv.Itoa(t.Port))
+}
+
+func (t Target) String() string {
+	return fmt.Sprintf("%s@%s", t.User, t.address())
+}
+
+// WhoAmI authenticates against target using only the provided signer and reports the login the forge greets with.
+func WhoAmI(target Target, signer ssh.Signer, hostKeyCallback ssh.HostKeyCallback) (Result, error) {
+	config := &ssh.ClientConfig{
+		User:            target.User,
+		Auth:            []ssh.AuthMethod{ssh.PublicKeys(signer)},
+		HostKeyCallback: hostKeyCallback,
+		Timeout:         DefaultTimeout,
+	}
+
+	client, err := ssh.Dial("tcp", target.address(), config)
+	if err != nil {
+		return Result{}, fmt.Errorf("could not authenticate to %s: %w", target, err)
+	}
+	defer client.Close()
+
+	session, err := client.NewSession()
+	if err != nil {
+		return Result{}, fmt.Errorf("could not open session on %s: %w", target, err)
+	}
+	defer session.Close()
+
+	var output bytes.Buffer
+	session.Stdout = &output
+	session.Stderr = &output
+
+	if err := session.Shell(); err != nil {
+		return Result{}, fmt.Errorf("could not request shell on %s: %w", target, err)
+	}
+
+	// forges close the session right after the greeting, usually with a non-zero exit status
+	err = session.Wait()
+	var exitErr *ssh.ExitError
+	var exitMissingErr *ssh.ExitMissingError
+	if err != nil && !errors.As(err, &exitErr) && !errors.As(err, &exitMissingErr) {
+		return Result{}, fmt.Errorf("session on %s failed: %w", target, err)
+	}
+
+	banner := output.String()
+	login, err := ParseGreeting(banner)
+	if err != nil {
+		return Result{Banner: banner}, err
+	}
+
+	return Result{Login: login, Banner: banner}, nil
+}
+
+// ParseGreeting extracts the authenticated login from a forge greeting. The login is empty, without an error, for
+// forges that confirm the authentication without telling who the key belongs to.
+func ParseGreeting(banner string) (string, error) {
+	for _, pattern := range greetingPatterns {
+		if matches := pattern.FindStringSubmatch(banner); matches != nil {
+			return matches[1], nil
+		}
+	}
+	for _, pattern := range anonymousGreetingPatterns {
+		if pattern.MatchString(strings.TrimSpace(banner)) {
+			return "", nil
+		}
+	}
+
+	return "", fmt.Errorf("could not find the authenticated user in the server greeting: %q", banner)
+}
+
+// LoadSigner reads the private key at path. For passphrase protected keys, passphrase is called to ask for it.
+func LoadSigner(path string, passphrase func() ([]byte, error)) (ssh.Signer, error) {
+	privateKey, err := LoadPrivateKey(path, passphrase)
+	if err != nil {
+		return nil, err
+	}
+
+	signer, err := ssh.NewSignerFromKey(privateKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid key: %w", err)
+	}
+
+	return signer, nil
+}
+
+// LoadPrivateKey works like LoadSigner, but returns the raw private key, e.g. to add it to an agent.
+func LoadPrivateKey(path string, passphrase func() ([]byte, error)) (interface{}, error) {
+	keyData, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.New("could not read key")
+	}
+
+	privateKey, err := ssh.ParseRawPrivateKey(keyData)
+	var missingErr *ssh.PassphraseMissingError
+	if errors.As(err, &missingErr) && passphrase != nil {
+		secret, err := passphrase()
+		if err != nil {
+			return nil, err
+		}
+		return ssh.ParseRawPrivateKeyWithPassphrase(keyData, secret)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid key: %w", err)
+	}
+
+	return privateKey, nil
+}
+
+// KnownHostsCallback verifies host keys against the provided known_hosts files.
+func KnownHostsCallback(files ...string) (ssh.HostKeyCallback, error) {
+	var existing []string
+	for _, file := range files {
+		if _, err := os.Stat(file); err == nil {
+			existing = append(existing, file)
+		}
+	}
+
+	if len(existing) == 0 {
+		return nil, errors.New("no known_hosts file found, connect with ssh once or run 'ssh-keyscan' to add the host key")
+	}
+
+	return knownhosts.New(existing...)
+}
+
+// ResolveTarget returns the server ssh connects to for the resolved alias. Forges expect the "git" user by default.
+func ResolveTarget(resolved sshconfig.Resolved) Target {
+	target := Target{Host: resolved.HostName, Port: resolved.Port, User: resolved.User}
+	if target.User == "" {
+		target.User = "git"
+	}
+
+	return target
+}
+
+// WithHostKeyAlias makes callback look up host keys under alias instead of the real host name, like ssh's HostKeyAlias.
+// Like ssh, the port is not part of the lookup when an alias is used.
+func WithHostKeyAlias(callback ssh.HostKeyCallback, alias string) ssh.HostKeyCallback {
+	if alias == "" {
+		return callback
+	}
+
+	return func(_ string, remote net.Addr, key ssh.PublicKey) error {
+		return callback(net.JoinHostPort(alias, "22"), remote, key)
+	}
+}
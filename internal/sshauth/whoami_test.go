@@ -0,0 +1,203 @@
+package sshauth
+
+import (
+	"fmt"
+	"net"
+	"path/filepath"
+	"testing"
+
+	"github.com/style77/gas/internal/sshtest"
+	"golang.org/x/crypto/ssh"
+)
+
+// startForge starts an in-process SSH server greeting like GitHub. users maps authorized keys to logins.
+func startForge(t *testing.T, hostKey ssh.Signer, users map[string]string) Target {
+	t.Helper()
+
+	config := &ssh.ServerConfig{
+		PublicKeyCallback: func(conn ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+			login, ok := users[string(key.Marshal())]
+			if !ok || conn.User() != "git" {
+				return nil, fmt.Errorf("unknown key")
+			}
+			return &ssh.Permissions{Extensions: map[string]string{"login": login}}, nil
+		},
+	}
+	config.AddHostKey(hostKey)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go serveForgeConn(conn, config)
+		}
+	}()
+
+	addr := listener.Addr().(*net.TCPAddr)
+	return Target{Host: "127.0.0.1", Port: addr.Port, User: "git"}
+}
+
+func serveForgeConn(conn net.Conn, config *ssh.ServerConfig) {
+	serverConn, channels, requests, err := ssh.NewServerConn(conn, config)
+	if err != nil {
+		conn.Close()
+		return
+	}
+	defer serverConn.Close()
+	go ssh.DiscardRequests(requests)
+
+	for newChannel := range channels {
+		if newChannel.ChannelType() != "session" {
+			newChannel.Reject(ssh.UnknownChannelType, "unsupported channel")
+			continue
+		}
+
+		channel, channelRequests, err := newChannel.Accept()
+		if err != nil {
+			return
+		}
+
+		for request := range channelRequests {
+			if request.Type != "shell" {
+				request.Reply(false, nil)
+				continue
+			}
+
+			request.Reply(true, nil)
+			login := serverConn.Permissions.Extensions["login"]
+			fmt.Fprintf(channel.Stderr(), "Hi %s! You've successfully authenticated, but GitHub does not provide shell access.\n", login)
+			channel.SendRequest("exit-status", false, ssh.Marshal(struct{ Status uint32 }{1}))
+			channel.Close()
+			break
+		}
+	}
+}
+
+func TestWhoAmI(t *testing.T) {
+	hostKey := sshtest.NewSigner(t)
+	workKey := sshtest.NewSigner(t)
+	personalKey := sshtest.NewSigner(t)
+	unknownKey := sshtest.NewSigner(t)
+
+	target := startForge(t, hostKey, map[string]string{
+		string(workKey.PublicKey().Marshal()):     "john-work",
+		string(personalKey.PublicKey().Marshal()): "johnDoe98",
+	})
+	hostKeyCallback := ssh.FixedHostKey(hostKey.PublicKey())
+
+	result, err := WhoAmI(target, workKey, hostKeyCallback)
+	if err != nil {
+		t.Fatalf("Did not expect an error, but got: %v", err)
+	}
+	if result.Login != "john-work" {
+		t.Errorf("Expected login 'john-work', but got '%s'", result.Login)
+	}
+
+	result, err = WhoAmI(target, personalKey, hostKeyCallback)
+	if err != nil || result.Login != "johnDoe98" {
+		t.Errorf("Expected login 'johnDoe98', but got '%s', %v", result.Login, err)
+	}
+
+	if _, err := WhoAmI(target, unknownKey, hostKeyCallback); err == nil {
+		t.Errorf("Expected an error for an unregistered key, but got none")
+	}
+
+	if _, err := WhoAmI(target, workKey, ssh.FixedHostKey(sshtest.NewSigner(t).PublicKey())); err == nil {
+		t.Errorf("Expected an error for a mismatched host key, but got none")
+	}
+}
+
+func TestWithHostKeyAlias(t *testing.T) {
+	var got string
+	callback := WithHostKeyAlias(func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+		got = hostname
+		return nil
+	}, "github.com")
+
+	callback("ssh.github.com:443", nil, nil)
+	if got != "github.com:22" {
+		t.Errorf("Expected host key lookup for 'github.com:22', but got '%s'", got)
+	}
+}
+
+func TestParseGreeting(t *testing.T) {
+	login, err := ParseGreeting("Hi johnDoe98! You've successfully authenticated, but GitHub does not provide shell access.\n")
+	if err != nil || login != "johnDoe98" {
+		t.Errorf("Expected login 'johnDoe98', but got '%s', %v", login, err)
+	}
+
+	login, err = ParseGreeting("Hi there, john_doe! You've successfully authenticated with the key named laptop, but Forgejo does not provide shell access.\n")
+	if err != nil || login != "john_doe" {
+		t.Errorf("Expected login 'john_doe', but got '%s', %v", login, err)
+	}
+
+	login, err = ParseGreeting("Welcome to GitLab, @john.doe!\n")
+	if err != nil || login != "john.doe" {
+		t.Errorf("Expected login 'john.doe', but got '%s', %v", login, err)
+	}
+
+	login, err = ParseGreeting("logged in as john-doe.\n\nYou can use git to connect to Bitbucket. Shell access is disabled.\n")
+	if err != nil || login != "john-doe" {
+		t.Errorf("Expected login 'john-doe', but got '%s', %v", login, err)
+	}
+
+	login, err = ParseGreeting("authenticated via ssh key.\n\nYou can use git to connect to Bitbucket. Shell access is disabled\n")
+	if err != nil || login != "" {
+		t.Errorf("Expected the Bitbucket Cloud greeting to authenticate without a login, but got '%s', %v", login, err)
+	}
+
+	if _, err := ParseGreeting("Permission denied (publickey)."); err == nil {
+		t.Errorf("Expected an error for an unknown greeting, but got none")
+	}
+}
+
+func TestLoadSigner(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "id_test")
+	publicKey := sshtest.WriteEncryptedKey(t, path, "secret")
+
+	asked := false
+	signer, err := LoadSigner(path, func() ([]byte, error) {
+		asked = true
+		return []byte("secret"), nil
+	})
+	if err != nil {
+		t.Fatalf("Did not expect an error, but got: %v", err)
+	}
+	if !asked {
+		t.Errorf("Expected to be asked for the passphrase")
+	}
+	if string(signer.PublicKey().Marshal()) != string(publicKey.Marshal()) {
+		t.Errorf("Expected the loaded key to match the written one")
+	}
+}
+
+func TestLoadPrivateKey(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "id_test")
+	publicKey := sshtest.WriteEncryptedKey(t, path, "secret")
+
+	loaded, err := LoadPrivateKey(path, func() ([]byte, error) {
+		return []byte("secret"), nil
+	})
+	if err != nil {
+		t.Fatalf("Did not expect an error, but got: %v", err)
+	}
+	signer, err := ssh.NewSignerFromKey(loaded)
+	if err != nil {
+		t.Fatalf("Expected a usable private key, but got: %v", err)
+	}
+	if string(signer.PublicKey().Marshal()) != string(publicKey.Marshal()) {
+		t.Errorf("Expected the loaded key to match the written one")
+	}
+
+	if _, err := LoadPrivateKey(path, nil); err == nil {
+		t.Errorf("Expected an error without a passphrase, but got none")
+	}
+}
@@ -0,0 +1,104 @@
+package sshconfig
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ResolutionOrder explains how ssh picks the key it authenticates with.
+const ResolutionOrder = `ssh reads the config from top to bottom and applies every block whose Host pattern matches.
+For most keywords the first value wins, but IdentityFile accumulates: every IdentityFile of every
+matching block is offered in file order, and the forge accepts the first key it knows. Unless
+IdentitiesOnly is set to yes, keys in ssh-agent are offered before all of them.`
+
+// IdentityConflict is an IdentityFile that can make ssh authenticate with a key other than the account's.
+type IdentityConflict struct {
+	Host   *Host
+	Option Option
+	// Target is the host name being connected to, either the account's alias or the real host name.
+	Target string
+	// Precedes is true when the file is offered before the account's key when connecting to Target.
+	// When Target is the real host name, the account's key is not offered at all.
+	Precedes bool
+}
+
+// String describes the conflict.
+func (c IdentityConflict) String() string {
+	block := "before the first Host block"
+	if c.Host.Line != -1 {
+		block = fmt.Sprintf("in 'Host %s'", strings.Join(c.Host.Patterns, " "))
+	}
+	location := fmt.Sprintf("IdentityFile %s (line %d, %s)", c.Option.Value, c.Option.Line+1, block)
+
+	if c.Precedes {
+		return fmt.Sprintf("%s is offered before the account's key when connecting to '%s'", location, c.Target)
+	}
+
+	return fmt.Sprintf("%s is offered after the account's key when connecting to '%s', and used if the account's key is rejected", location, c.Target)
+}
+
+// IdentityConflicts finds the IdentityFiles other than keyPath that ssh offers when connecting to alias,
+// and the ones used for remotes connecting to the alias's real host name directly.
+func (c *Config) IdentityConflicts(alias, keyPath string) []IdentityConflict {
+	var conflicts []IdentityConflict
+	conflicts = append(conflicts, c.identityConflicts(alias, keyPath)...)
+
+	hostName := c.Resolve(alias).HostName
+	if hostName != alias {
+		// remotes using the real host name never offer the account's key
+		conflicts = append(conflicts, c.identityConflicts(hostName, "")...)
+	}
+
+	return conflicts
+}
+
+// identityConflicts returns the IdentityFiles other than keyPath offered when connecting to target.
+func (c *Config) identityConflicts(target, keyPath string) []IdentityConflict {
+	var conflicts []IdentityConflict
+	found := false
+
+	for _, host := range c.Hosts {
+		if !host.Matches(target) {
+			continue
+		}
+
+		for _, option := range host.Options {
+			if !strings.EqualFold(option.Key, "identityfile") {
+				continue
+			}
+			if keyPath != "" && SamePath(option.Value, keyPath) {
+				found = true
+				continue
+			}
+
+			conflicts = append(conflicts, IdentityConflict{Host: host, Option: option, Target: target, Precedes: !found})
+		}
+	}
+
+	if !found {
+		// the account's key is not offered at all, so every other file takes its place
+		for i := range conflicts {
+			conflicts[i].Precedes = true
+		}
+	}
+
+	return conflicts
+}
+
+// SamePath checks if two key paths refer to the same file, expanding '~' like ssh does.
+func SamePath(a, b string) bool {
+	return expandHome(a) == expandHome(b)
+}
+
+func expandHome(path string) string {
+	path = strings.ReplaceAll(path, "\\", "/")
+	if strings.HasPrefix(path, "~/") {
+		if homeDir, err := os.UserHomeDir(); err == nil {
+			path = filepath.ToSlash(homeDir) + path[1:]
+		}
+	}
+
+	return filepath.Clean(path)
+}
@@ -0,0 +1,326 @@
+package sshconfig
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Option is a single "Keyword value" line of an SSH config file.
+type Option struct {
+	Key   string
+	Value string
+	// Line is the zero-based index of the option in the file.
+	Line int
+}
+
+// Host is a "Host" block of an SSH config file. Options before the first block belong to a block matching everything.
+type Host struct {
+	Patterns []string
+	Options  []Option
+	// Line is the zero-based index of the "Host" line, or -1 for the options before the first block.
+	Line int
+}
+
+// Config is a parsed SSH config file.
+type Config struct {
+	Path  string
+	Lines []string
+	Hosts []*Host
+}
+
+// Resolved is the configuration ssh uses to connect to a host.
+type Resolved struct {
+	Alias          string
+	HostName       string
+	User           string
+	Port           int
+	IdentityFiles  []string
+	IdentitiesOnly bool
+	// Options holds the first value of every keyword, lowercased, matching how ssh picks values.
+	Options map[string]string
+}
+
+// DefaultPath returns the path of the user's SSH config file.
+func DefaultPath() string {
+	return filepath.Join(os.Getenv("HOME"), ".ssh", "config")
+}
+
+// Load reads and parses the SSH config file at path. A missing file is an empty config.
+func Load(path string) (*Config, error) {
+	content, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	config := Parse(string(content))
+	config.Path = path
+	return config, nil
+}
+
+// Parse parses the content of an SSH config file. Match blocks and Include directives are kept but not evaluated.
+func Parse(content string) *Config {
+	config := &Config{}
+	if content != "" {
+		config.Lines = strings.Split(strings.TrimRight(content, "\n"), "\n")
+	}
+
+	current := &Host{Patterns: []string{"*"}, Line: -1}
+	config.Hosts = append(config.Hosts, current)
+
+	for i, line := range config.Lines {
+		key, value := parseLine(line)
+		if key == "" {
+			continue
+		}
+
+		switch strings.ToLower(key) {
+		case "host":
+			current = &Host{Patterns: strings.Fields(value), Line: i}
+			config.Hosts = append(config.Hosts, current)
+		case "match":
+			// Match criteria are not evaluated, so the block never matches
+			current = &Host{Line: i}
+			config.Hosts = append(config.Hosts, current)
+		default:
+			current.Options = append(current.Options, Option{Key: key, Value: value, Line: i})
+		}
+	}
+
+	return config
+}
+
+// parseLine splits a config line into its keyword and value, supporting both "Key value" and "Key=value".
+func parseLine(line string) (string, string) {
+	trimmed := strings.TrimSpace(line)
+	if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+		return "", ""
+	}
+
+	index := strings.IndexAny(trimmed, " \t=")
+	if index == -1 {
+		return trimmed, ""
+	}
+
+	key := trimmed[:index]
+	value := strings.TrimSpace(trimmed[index:])
+	value = strings.TrimSpace(strings.TrimPrefix(value, "="))
+
+	return key, strings.Trim(value, `"`)
+}
+
+// Matches checks if the block applies to the provided host name, honouring wildcards and negated patterns.
+func (h *Host) Matches(name string) bool {
+	matched := false
+	for _, pattern := range h.Patterns {
+		negated := strings.HasPrefix(pattern, "!")
+		pattern = strings.TrimPrefix(pattern, "!")
+
+		if ok, _ := filepath.Match(strings.ToLower(pattern), strings.ToLower(name)); ok {
+			if negated {
+				return false
+			}
+			matched = true
+		}
+	}
+
+	return matched
+}
+
+// Get returns the first value of the provided keyword in the block.
+func (h *Host) Get(key string) (string, bool) {
+	for _, option := range h.Options {
+		if strings.EqualFold(option.Key, key) {
+			return option.Value, true
+		}
+	}
+
+	return "", false
+}
+
+// FindHost returns the block declaring exactly the provided alias.
+func (c *Config) FindHost(alias string) *Host {
+	for _, host := range c.Hosts {
+		if host.Line == -1 {
+			continue
+		}
+		for _, pattern := range host.Patterns {
+			if pattern == alias {
+				return host
+			}
+		}
+	}
+
+	return nil
+}
+
+// Resolve works out the settings ssh uses when connecting to alias.
+// Like ssh, the first value of a keyword wins, except IdentityFile which accumulates in file order.
+func (c *Config) Resolve(alias string) Resolved {
+	resolved := Resolved{Alias: alias, Options: map[string]string{}}
+
+	for _, host := range c.Hosts {
+		if !host.Matches(alias) {
+			continue
+		}
+
+		for _, option := range host.Options {
+			key := strings.ToLower(option.Key)
+			if key == "identityfile" {
+				resolved.IdentityFiles = append(resolved.IdentityFiles, option.Value)
+				continue
+			}
+			if _, ok := resolved.Options[key]; !ok {
+				resolved.Options[key] = option.Value
+			}
+		}
+	}
+
+	resolved.HostName = alias
+	if hostName, ok := resolved.Options["hostname"]; ok {
+		resolved.HostName = strings.ReplaceAll(hostName, "%h", alias)
+	}
+
+	resolved.User = resolved.Options["user"]
+
+	resolved.Port = 22
+	if port, err := strconv.Atoi(resolved.Options["port"]); err == nil {
+		resolved.Port = port
+	}
+
+	resolved.IdentitiesOnly = strings.EqualFold(resolved.Options["identitiesonly"], "yes")
+
+	return resolved
+}
+
+// HasIdentityFile checks if ssh offers the provided key when connecting to the alias.
+func (r Resolved) HasIdentityFile(path string) bool {
+	for _, identityFile := range r.IdentityFiles {
+		if SamePath(identityFile, path) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// String renders the config back into the SSH config format.
+func (c *Config) String() string {
+	if len(c.Lines) == 0 {
+		return ""
+	}
+
+	return strings.Join(c.Lines, "\n") + "\n"
+}
+
+// SetOption sets key to value in the block declaring alias, replacing the existing value if there is one.
+func (c *Config) SetOption(alias, key, value string) error {
+	host := c.FindHost(alias)
+	if host == nil {
+		return fmt.Errorf("host '%s' not found in SSH config", alias)
+	}
+
+	value = quoteValue(key, value)
+
+	for _, option := range host.Options {
+		if strings.EqualFold(option.Key, key) {
+			line := c.Lines[option.Line]
+			c.Lines[option.Line] = line[:len(line)-len(strings.TrimLeft(line, " \t"))] + key + " " + value
+			c.reparse()
+			return nil
+		}
+	}
+
+	indent := "    "
+	insertAt := host.Line + 1
+	if len(host.Options) > 0 {
+		last := host.Options[len(host.Options)-1]
+		line := c.Lines[last.Line]
+		indent = line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+		insertAt = last.Line + 1
+	}
+
+	lines := append([]string{}, c.Lines[:insertAt]...)
+	lines = append(lines, indent+key+" "+value)
+	c.Lines = append(lines, c.Lines[insertAt:]...)
+	c.reparse()
+
+	return nil
+}
+
+// RemoveOption removes every value of key from the block declaring alias.
+func (c *Config) RemoveOption(alias, key string) error {
+	host := c.FindHost(alias)
+	if host == nil {
+		return fmt.Errorf("host '%s' not found in SSH config", alias)
+	}
+
+	remove := map[int]bool{}
+	for _, option := range host.Options {
+		if strings.EqualFold(option.Key, key) {
+			remove[option.Line] = true
+		}
+	}
+
+	var lines []string
+	for i, line := range c.Lines {
+		if !remove[i] {
+			lines = append(lines, line)
+		}
+	}
+	c.Lines = lines
+	c.reparse()
+
+	return nil
+}
+
+// AddHost appends a block declaring alias with the provided options.
+func (c *Config) AddHost(alias string, options []Option) {
+	if len(c.Lines) > 0 && strings.TrimSpace(c.Lines[len(c.Lines)-1]) != "" {
+		c.Lines = append(c.Lines, "")
+	}
+
+	c.Lines = append(c.Lines, "Host "+alias)
+	for _, option := range options {
+		c.Lines = append(c.Lines, "    "+option.Key+" "+quoteValue(option.Key, option.Value))
+	}
+	c.reparse()
+}
+
+// commandKeywords take the rest of the line as a command, which must not be quoted as a whole.
+var commandKeywords = map[string]bool{
+	"proxycommand":      true,
+	"localcommand":      true,
+	"remotecommand":     true,
+	"knownhostscommand": true,
+}
+
+// quoteValue quotes values containing whitespace, except for keywords taking a command.
+func quoteValue(key, value string) string {
+	if commandKeywords[strings.ToLower(key)] || !strings.ContainsAny(value, " \t") {
+		return value
+	}
+
+	return `"` + value + `"`
+}
+
+// Save writes the config back to its file.
+func (c *Config) Save() error {
+	if err := os.MkdirAll(filepath.Dir(c.Path), 0700); err != nil {
+		return fmt.Errorf("could not create SSH config directory: %w", err)
+	}
+
+	if err := os.WriteFile(c.Path, []byte(c.String()), 0600); err != nil {
+		return fmt.Errorf("could not write SSH config file: %w", err)
+	}
+
+	return nil
+}
+
+// reparse refreshes the parsed blocks after the lines changed.
+func (c *Config) reparse() {
+	parsed := Parse(c.String())
+	c.Hosts = parsed.Hosts
+}
@@ -0,0 +1,185 @@
+package sshconfig
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testConfig = `# global defaults
+IdentityFile ~/.ssh/id_default
+
+Host github-work
+    HostName github.com
+    User git
+    IdentityFile ~/.ssh/id_work
+    IdentitiesOnly yes
+
+Host github-* !github-personal
+    Port 2222
+
+Host=github-personal
+    HostName=ssh.github.com
+    Port 443
+    IdentityFile "~/.ssh/id personal"
+
+Host *
+    User nobody
+    IdentityFile ~/.ssh/id_rsa
+`
+
+func TestResolve(t *testing.T) {
+	config := Parse(testConfig)
+
+	tests := []struct {
+		alias string
+		want  Resolved
+	}{
+		{
+			alias: "github-work",
+			want: Resolved{
+				Alias:          "github-work",
+				HostName:       "github.com",
+				User:           "git",
+				Port:           2222,
+				IdentityFiles:  []string{"~/.ssh/id_default", "~/.ssh/id_work", "~/.ssh/id_rsa"},
+				IdentitiesOnly: true,
+			},
+		},
+		{
+			alias: "github-personal",
+			want: Resolved{
+				Alias:         "github-personal",
+				HostName:      "ssh.github.com",
+				User:          "nobody",
+				Port:          443,
+				IdentityFiles: []string{"~/.ssh/id_default", "~/.ssh/id personal", "~/.ssh/id_rsa"},
+			},
+		},
+		{
+			alias: "gitlab.com",
+			want: Resolved{
+				Alias:         "gitlab.com",
+				HostName:      "gitlab.com",
+				User:          "nobody",
+				Port:          22,
+				IdentityFiles: []string{"~/.ssh/id_default", "~/.ssh/id_rsa"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.alias, func(t *testing.T) {
+			got := config.Resolve(tt.alias)
+			got.Options = nil
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Resolve() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFindHostAndString(t *testing.T) {
+	config := Parse(testConfig)
+
+	host := config.FindHost("github-personal")
+	if host == nil {
+		t.Fatalf("Expected to find host block")
+	}
+	if hostName, _ := host.Get("hostname"); hostName != "ssh.github.com" {
+		t.Errorf("Expected HostName 'ssh.github.com', but got '%s'", hostName)
+	}
+	if config.FindHost("github-*") == nil || config.FindHost("github-missing") != nil {
+		t.Errorf("Expected only declared aliases to be found")
+	}
+
+	if config.String() != testConfig {
+		t.Errorf("Expected config to round trip, but got:\n%s", config.String())
+	}
+}
+
+func TestSetAndRemoveOption(t *testing.T) {
+	config := Parse(testConfig)
+
+	if err := config.SetOption("github-work", "IdentityAgent", "~/.gas/agent/github-work.sock"); err != nil {
+		t.Fatalf("Did not expect an error, but got: %v", err)
+	}
+	if err := config.SetOption("github-personal", "Port", "22"); err != nil {
+		t.Fatalf("Did not expect an error, but got: %v", err)
+	}
+	if err := config.SetOption("github-missing", "Port", "22"); err == nil {
+		t.Errorf("Expected an error for a missing host, but got none")
+	}
+
+	if got := config.Resolve("github-work").Options["identityagent"]; got != "~/.gas/agent/github-work.sock" {
+		t.Errorf("Expected IdentityAgent to be set, but got '%s'", got)
+	}
+	if got := config.Resolve("github-personal").Port; got != 22 {
+		t.Errorf("Expected Port to be replaced, but got %d", got)
+	}
+	if config.Lines[8] != "    IdentityAgent ~/.gas/agent/github-work.sock" {
+		t.Errorf("Expected IdentityAgent after the last option of the block, but got '%s'", config.Lines[8])
+	}
+
+	if err := config.RemoveOption("github-work", "identityagent"); err != nil {
+		t.Fatalf("Did not expect an error, but got: %v", err)
+	}
+	if err := config.SetOption("github-personal", "Port", "443"); err != nil {
+		t.Fatalf("Did not expect an error, but got: %v", err)
+	}
+	if config.String() != testConfig {
+		t.Errorf("Expected the original config after removing the option, but got:\n%s", config.String())
+	}
+}
+
+func TestQuoting(t *testing.T) {
+	config := Parse(testConfig)
+
+	config.SetOption("github-work", "ProxyCommand", "nc -X connect -x proxy:8080 %h %p")
+	config.AddHost("github-jump", []Option{{Key: "ProxyCommand", Value: "ssh -W %h:%p jump"}, {Key: "IdentityFile", Value: "~/My Keys/id_work"}})
+
+	want := []string{"    ProxyCommand nc -X connect -x proxy:8080 %h %p", "    ProxyCommand ssh -W %h:%p jump", `    IdentityFile "~/My Keys/id_work"`}
+	for _, line := range want {
+		if !strings.Contains(config.String(), line+"\n") {
+			t.Errorf("Expected the line '%s', but got:\n%s", line, config.String())
+		}
+	}
+
+	if got := config.Resolve("github-jump").Options["proxycommand"]; got != "ssh -W %h:%p jump" {
+		t.Errorf("Expected the ProxyCommand to be read back, but got '%s'", got)
+	}
+}
+
+func TestIdentityConflicts(t *testing.T) {
+	config := Parse(testConfig + `
+Host github.com
+    IdentityFile ~/.ssh/id_personal
+`)
+
+	conflicts := config.IdentityConflicts("github-work", "~/.ssh/id_work")
+
+	type summary struct {
+		Value    string
+		Target   string
+		Precedes bool
+	}
+	var got []summary
+	for _, conflict := range conflicts {
+		got = append(got, summary{conflict.Option.Value, conflict.Target, conflict.Precedes})
+	}
+
+	want := []summary{
+		{"~/.ssh/id_default", "github-work", true},
+		{"~/.ssh/id_rsa", "github-work", false},
+		{"~/.ssh/id_default", "github.com", true},
+		{"~/.ssh/id_rsa", "github.com", true},
+		{"~/.ssh/id_personal", "github.com", true},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("IdentityConflicts() = %+v, want %+v", got, want)
+	}
+
+	if conflicts[0].String() != "IdentityFile ~/.ssh/id_default (line 2, before the first Host block) is offered before the account's key when connecting to 'github-work'" {
+		t.Errorf("Unexpected description: %s", conflicts[0])
+	}
+}
@@ -0,0 +1,101 @@
+// Package sshtest provides throwaway SSH keys and certificates for tests.
+package sshtest
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"os"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// NewKey generates a throwaway ed25519 key and returns it with its SSH public key.
+func NewKey(t testing.TB) (ed25519.PrivateKey, ssh.PublicKey) {
+	t.Helper()
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	sshPublicKey, err := ssh.NewPublicKey(publicKey)
+	if err != nil {
+		t.Fatalf("Failed to convert key: %v", err)
+	}
+
+	return privateKey, sshPublicKey
+}
+
+// NewSigner generates a throwaway ed25519 key and returns a signer of it.
+func NewSigner(t testing.TB) ssh.Signer {
+	t.Helper()
+	privateKey, _ := NewKey(t)
+	signer, err := ssh.NewSignerFromKey(privateKey)
+	if err != nil {
+		t.Fatalf("Failed to create signer: %v", err)
+	}
+
+	return signer
+}
+
+// WriteKey writes a throwaway private key to path in the OpenSSH format with the provided permissions and returns
+// its public key.
+func WriteKey(t testing.TB, path string, perm os.FileMode) ssh.PublicKey {
+	t.Helper()
+	privateKey, publicKey := NewKey(t)
+	block, err := ssh.MarshalPrivateKey(privateKey, "")
+	if err != nil {
+		t.Fatalf("Failed to marshal key: %v", err)
+	}
+	writeFile(t, path, pem.EncodeToMemory(block), perm)
+
+	return publicKey
+}
+
+// WriteEncryptedKey works like WriteKey, but encrypts the key with passphrase and only lets the owner read it.
+func WriteEncryptedKey(t testing.TB, path, passphrase string) ssh.PublicKey {
+	t.Helper()
+	privateKey, publicKey := NewKey(t)
+	block, err := ssh.MarshalPrivateKeyWithPassphrase(privateKey, "", []byte(passphrase))
+	if err != nil {
+		t.Fatalf("Failed to marshal key: %v", err)
+	}
+	writeFile(t, path, pem.EncodeToMemory(block), 0600)
+
+	return publicKey
+}
+
+// WriteCertificate writes a certificate of key for principals to path, signed by a throwaway CA. It's valid for 8
+// hours from validAfter.
+func WriteCertificate(t testing.TB, path string, key ssh.PublicKey, certType uint32, principals []string, validAfter time.Time) {
+	t.Helper()
+	caKey, _ := NewKey(t)
+	caSigner, err := ssh.NewSignerFromKey(caKey)
+	if err != nil {
+		t.Fatalf("Failed to create CA signer: %v", err)
+	}
+
+	cert := &ssh.Certificate{
+		Key:             key,
+		CertType:        certType,
+		ValidPrincipals: principals,
+		ValidAfter:      uint64(validAfter.Unix()),
+		ValidBefore:     uint64(validAfter.Add(8 * time.Hour).Unix()),
+	}
+	if err := cert.SignCert(rand.Reader, caSigner); err != nil {
+		t.Fatalf("Failed to sign certificate: %v", err)
+	}
+	writeFile(t, path, ssh.MarshalAuthorizedKey(cert), 0644)
+}
+
+// writeFile writes content to path with exactly the provided permissions, regardless of the umask.
+func writeFile(t testing.TB, path string, content []byte, perm os.FileMode) {
+	t.Helper()
+	if err := os.WriteFile(path, content, perm); err != nil {
+		t.Fatalf("Failed to write '%s': %v", path, err)
+	}
+	if err := os.Chmod(path, perm); err != nil {
+		t.Fatalf("Failed to chmod '%s': %v", path, err)
+	}
+}